@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"folder_to_git/pkg/gitconverter"
+)
+
+// passphraseEnv — переменная окружения с парольной фразой файла учетных данных
+const passphraseEnv = "FOLDER_TO_GIT_PASSPHRASE"
+
+// credentialStore возвращает файловое хранилище, если указан файл, иначе системное
+func credentialStore(file string) (gitconverter.CredentialStore, error) {
+	if file == "" {
+		return gitconverter.NewKeyringStore(), nil
+	}
+	passphrase := os.Getenv(passphraseEnv)
+	if passphrase == "" {
+		return nil, fmt.Errorf("для файла учетных данных задайте парольную фразу в %s", passphraseEnv)
+	}
+	return gitconverter.NewFileStore(file, passphrase), nil
+}
+
+// runAuth выполняет команду auth: set, delete или check для записи name.
+// Секрет для set читается из стандартного ввода, чтобы не попасть в историю оболочки
+func runAuth(args []string) {
+	fs := flag.NewFlagSet("auth", flag.ExitOnError)
+	file := fs.String("file", "", "зашифрованный файл учетных данных вместо системного хранилища")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Использование: folder-to-git auth set|delete|check [-file путь] имя")
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	action := args[0]
+	fs.Parse(args[1:])
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	name := fs.Arg(0)
+
+	store, err := credentialStore(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch action {
+	case "set":
+		fmt.Fprint(os.Stderr, "Токен или пользователь:токен: ")
+		secret, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && secret == "" {
+			log.Fatalf("ошибка чтения секрета: %v", err)
+		}
+		secret = strings.TrimRight(secret, "\r\n")
+		if secret == "" {
+			log.Fatal("пустой секрет")
+		}
+		if err := store.Set(name, secret); err != nil {
+			log.Fatalf("ошибка сохранения учетных данных: %v", err)
+		}
+		log.Printf("Учетные данные %s сохранены", name)
+	case "delete":
+		if err := store.Delete(name); err != nil {
+			log.Fatalf("ошибка удаления учетных данных: %v", err)
+		}
+		log.Printf("Учетные данные %s удалены", name)
+	case "check":
+		if _, err := store.Get(name); err != nil {
+			log.Fatalf("ошибка чтения учетных данных: %v", err)
+		}
+		log.Printf("Учетные данные %s найдены", name)
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"folder_to_git/pkg/gitconverter"
+)
+
+// runAuthors выполняет команду authors rewrite: переписывает авторов
+// перенесенной истории по файлу авторов в новую ветку
+func runAuthors(args []string) {
+	fs := flag.NewFlagSet("authors", flag.ExitOnError)
+	config := gitconverter.Config{}
+	opts := gitconverter.RewriteOptions{}
+	fs.StringVar(&config.TargetDir, "target", ".", "директория Git-репозитория")
+	fs.StringVar(&config.AuthorsFile, "authors", "", "файл с авторами версий")
+	fs.StringVar(&opts.Branch, "branch", "", "ветка для новой истории (по умолчанию <текущая>-authors)")
+	fs.BoolVar(&opts.InPlace, "in-place", false, "перенести текущую ветку на новую историю")
+	fs.BoolVar(&opts.Force, "force", false, "переписывать, даже если в истории есть коммиты без версии")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Использование: folder-to-git authors rewrite -authors файл [-target путь] [-branch имя] [-in-place] [-force]")
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 || args[0] != "rewrite" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	fs.Parse(args[1:])
+
+	result, err := gitconverter.RewriteAuthors(config, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, commit := range result.Rewritten {
+		fmt.Printf("%s -> %s  %s  %s\n", commit.Old[:7], commit.New[:7], commit.Version, commit.Author)
+	}
+	if result.Unmapped > 0 {
+		fmt.Printf("Коммитов без версии перенесено как есть: %d\n", result.Unmapped)
+	}
+	fmt.Printf("Переписано коммитов: %d, ветка %s -> %s\n", len(result.Rewritten), result.Branch, result.Head[:7])
+}
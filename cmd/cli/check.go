@@ -0,0 +1,23 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"folder_to_git/pkg/gitconverter"
+)
+
+// runCheck проверяет, что в репозиторий можно добавлять версии: объекты
+// последних коммитов читаются, а индекс и рабочая директория совпадают с HEAD
+func runCheck(args []string) {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	config := gitconverter.Config{}
+	fs.StringVar(&config.TargetDir, "target", ".", "директория Git-репозитория")
+	fs.Parse(args)
+
+	if err := gitconverter.Check(config); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Репозиторий %s исправен\n", config.TargetDir)
+}
@@ -0,0 +1,277 @@
+// Команда folder-to-git переносит папки с версиями в Git без графического интерфейса
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"folder_to_git/pkg/gitconverter"
+)
+
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "auth":
+			runAuth(os.Args[2:])
+			return
+		case "release-notes":
+			runReleaseNotes(os.Args[2:])
+			return
+		case "tags":
+			runTags(os.Args[2:])
+			return
+		case "authors":
+			runAuthors(os.Args[2:])
+			return
+		case "commit-folder":
+			runCommitFolder(os.Args[2:])
+			return
+		case "explain-order":
+			runExplainOrder(os.Args[2:])
+			return
+		case "check":
+			runCheck(os.Args[2:])
+			return
+		}
+	}
+
+	config := gitconverter.Config{}
+	flag.StringVar(&config.SourceDir, "source", "", "директория с папками версий, архив .tar.gz или адрес sftp://пользователь@сервер/путь")
+	flag.StringVar(&config.TargetDir, "target", "", "директория Git-репозитория")
+	flag.StringVar(&config.Pattern, "pattern", "*", "шаблон имен папок с версиями")
+	flag.StringVar(&config.ExtractPattern, "extract", "[0-9]+(\\.[0-9]+)?", "регулярное выражение для извлечения версии")
+	flag.BoolVar(&config.Recursive, "recursive", false, "искать папки с версиями во вложенных директориях")
+	flag.IntVar(&config.MaxDepth, "max-depth", 0, "наибольшая глубина папки с версией при -recursive (0 — без ограничения)")
+	flag.StringVar(&config.Author, "author", "Developer", "автор коммитов")
+	flag.StringVar(&config.Email, "email", "dev@example.com", "email автора коммитов")
+	flag.StringVar(&config.AuthorsFile, "authors", "", "файл с сопоставлением версий и авторов")
+	flag.StringVar(&config.CommitterName, "committer", "", "имя коммиттера, например бота миграции (по умолчанию автор версии)")
+	flag.StringVar(&config.CommitterEmail, "committer-email", "", "email коммиттера, задается вместе с -committer")
+	commitDate := flag.String("commit-date", "", "дата коммиттера: author — время версии, now — время переноса (по умолчанию author)")
+	flag.IntVar(&config.SubjectLimit, "subject-limit", 0, "максимальная длина первой строки сообщения коммита (по умолчанию 72, -1 — без ограничения)")
+	versionsFile := flag.String("versions", "", "файл с версиями, заданными вручную: имя_папки=версия")
+	flag.StringVar(&config.TimeSourceFile, "time-file", "", "файл со временем создания версий: версия=ГГГГ-ММ-ДД или время в RFC3339")
+	exclude := flag.String("exclude", "", "папки через запятую (путь или имя), которые не переносятся в этом и следующих запусках")
+	clearExclusions := flag.Bool("clear-exclusions", false, "забыть папки, исключенные в прошлых запусках")
+	flag.BoolVar(&config.Append, "append", false, "добавить новые версии в существующий репозиторий")
+	flag.StringVar(&config.Branch, "branch", "", "ветка для коммитов (по умолчанию master или текущая ветка)")
+	flag.BoolVar(&config.Bare, "bare", false, "создать целевой репозиторий голым, без рабочей директории")
+	flag.BoolVar(&config.AdoptExisting, "adopt", false, "разрешить -append продолжить репозиторий, созданный не конвертером")
+	flag.BoolVar(&config.IgnoreHealthCheck, "force", false, "добавлять версии с -append, даже если проверка нашла повреждения репозитория")
+	flag.BoolVar(&config.IgnorePreviousConfig, "ignore-previous-config", false, "не брать в режиме -append настройки прошлого запуска")
+	flag.BoolVar(&config.Incremental, "incremental", false, "применять только изменения между версиями")
+	flag.BoolVar(&config.KeepVersionDir, "keep-version-dir", false, "класть каждую версию в свою папку, не удаляя предыдущие")
+	flag.BoolVar(&config.WorkspaceMode, "workspace", false, "собирать версию во временной папке и переносить в репозиторий одним шагом")
+	flag.BoolVar(&config.HashCache, "hash-cache", false, "хранить хеши файлов источника между запусками")
+	clearHashCache := flag.Bool("clear-hash-cache", false, "удалить кэш хешей перед запуском")
+	flag.BoolVar(&config.Paranoid, "paranoid", false, "не доверять кэшу хешей и заново считать хеши всех файлов")
+	flag.BoolVar(&config.DryRun, "dry-run", false, "только показать найденные версии")
+	flag.BoolVar(&config.Verbose, "verbose", false, "подробный лог")
+	flag.BoolVar(&config.PreferVersionOrder, "prefer-version-order", false, "упорядочивать по номерам версии, даты которых противоречат номерам")
+	flag.BoolVar(&config.KeepAppleDouble, "keep-apple-double", false, "копировать файлы AppleDouble (._имя) из архивов macOS")
+	ignoreDirs := flag.String("ignore-dirs", "", "имена директорий через запятую, которые не копируются (вместо списка по умолчанию)")
+	ignoreFiles := flag.String("ignore-files", "", "шаблоны имен файлов через запятую, которые не копируются (вместо списка по умолчанию)")
+	flag.BoolVar(&config.RespectGitignore, "respect-gitignore", false, "не копировать файлы, исключенные .gitignore в папках версий")
+	flag.BoolVar(&config.KeepGitignore, "keep-gitignore", false, "копировать сами файлы .gitignore из папок версий")
+	flag.BoolVar(&config.WriteGitignore, "write-gitignore", false, "добавить в первый коммит .gitignore с действующими правилами пропуска файлов")
+	eol := flag.String("eol", "", "концы строк текстовых файлов в коммитах: lf, crlf или none (по умолчанию без изменений)")
+	flag.BoolVar(&config.WriteEOLAttributes, "eol-attributes", false, "добавить в первый коммит .gitattributes с правилом концов строк -eol")
+	skipTypes := flag.String("skip-types", "", "категории содержимого через запятую, которые не копируются: archive, image, video, core-dump")
+	sortMode := flag.String("sort", "time", "порядок версий: time, semver или natural")
+	warnCommitMB := flag.Int64("warn-commit-mb", 0, "предупреждать о версиях, добавляющих больше указанного числа МБ")
+	lfsPatterns := flag.String("lfs", "", "шаблоны файлов через запятую, которые хранятся в Git LFS, например *.iso,*.zip")
+	lfsMB := flag.Int64("lfs-mb", 0, "хранить в Git LFS файлы больше указанного числа МБ")
+	flag.BoolVar(&config.SkipUnchanged, "skip-unchanged", false, "не создавать коммиты для версий, совпадающих с предыдущей")
+	flag.BoolVar(&config.MergeSameVersion, "merge-versions", false, "объединять папки с одинаковой версией в один коммит")
+	groupBy := flag.String("group-by", "", "один коммит на период: day, week или month — последний снимок периода (по умолчанию коммит на каждую папку)")
+	flag.Float64Var(&config.ThrottleMBps, "throttle-mb", 0, "ограничение скорости копирования, МБ/с")
+	flag.Float64Var(&config.ThrottleFilesPerSec, "throttle-files", 0, "ограничение числа копируемых файлов в секунду")
+	flag.BoolVar(&config.IdlePriority, "idle", false, "делать паузы между папками, чтобы не мешать другим программам")
+	flag.IntVar(&config.Concurrency, "concurrency", 0, "сколько папок проверять одновременно при сканировании (по умолчанию число процессоров)")
+	flag.StringVar(&config.HookCommand, "hook", "", "команда, выполняемая в репозитории перед каждым коммитом")
+	flag.DurationVar(&config.HookTimeout, "hook-timeout", 0, "ограничение времени команды -hook (по умолчанию 5m)")
+	flag.StringVar(&config.WebhookURL, "webhook", "", "адрес для уведомлений о ходе миграции (токен берется из FOLDER_TO_GIT_WEBHOOK_TOKEN)")
+	webhookEvents := flag.String("webhook-events", "done", "события для webhook через запятую: folder, failed, done")
+	flag.BoolVar(&config.PushAfterMigrate, "push", false, "отправить репозиторий в удаленный после миграции")
+	flag.BoolVar(&config.AnnotatedTags, "tags", false, "создавать аннотированный тег для каждой версии")
+	flag.BoolVar(&config.CreateTags, "version-tags", false, "ставить тег каждой версии: аннотированный, если автор найден в файле авторов, иначе легковесный")
+	flag.StringVar(&config.TagPrefix, "tag-prefix", "", "префикс имен тегов версий, например v")
+	flag.StringVar(&config.SignKeyPath, "sign-key", "", "файл закрытого ключа OpenPGP для подписи коммитов и тегов (пароль берется из FOLDER_TO_GIT_SIGN_PASSPHRASE)")
+	tagConflict := flag.String("tag-conflict", "skip", "если тег версии уже есть: skip (предупредить) или fail (ошибка)")
+	flag.StringVar(&config.RemoteURL, "remote-url", "", "адрес удаленного репозитория, если он еще не настроен")
+	flag.StringVar(&config.RemoteName, "remote", "", "имя удаленного репозитория для -push (по умолчанию origin)")
+	flag.StringVar(&config.PushCredential, "credential", "", "имя учетных данных для отправки (см. folder-to-git auth set)")
+	flag.StringVar(&config.PushSSHKey, "ssh-key", "", "закрытый SSH-ключ для отправки (пароль берется из FOLDER_TO_GIT_SSH_PASSPHRASE)")
+	flag.StringVar(&config.SFTPKeyPath, "sftp-key", "", "закрытый SSH-ключ для источника sftp:// (пароль берется из FOLDER_TO_GIT_SFTP_PASSPHRASE)")
+	flag.StringVar(&config.SFTPKnownHosts, "sftp-known-hosts", "", "файл известных серверов для источника sftp:// (по умолчанию ~/.ssh/known_hosts)")
+	flag.BoolVar(&config.SFTPIgnoreHostKey, "sftp-insecure", false, "не проверять ключ сервера SFTP")
+	flag.IntVar(&config.SFTPConcurrency, "sftp-concurrency", 0, "наибольшее число одновременных запросов к серверу SFTP (по умолчанию 4)")
+	flag.IntVar(&config.SFTPRetries, "sftp-retries", 0, "число повторов запроса SFTP при обрыве соединения (по умолчанию 3)")
+	credentialsFile := flag.String("credentials-file", "", "зашифрованный файл учетных данных вместо системного хранилища")
+	watch := flag.Bool("watch", false, "следить за источником и переносить новые версии по мере появления")
+	flag.DurationVar(&config.WatchDebounce, "debounce", 0, "пауза после изменения в источнике (по умолчанию 2s)")
+	flag.DurationVar(&config.WatchSettle, "settle", 0, "сколько папка не должна меняться перед переносом (по умолчанию 10s)")
+	flag.Parse()
+
+	if config.SourceDir == "" || config.TargetDir == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	config.TagConflictPolicy = gitconverter.TagConflictPolicy(*tagConflict)
+	config.SortMode = gitconverter.SortMode(*sortMode)
+	config.CommitDateSource = gitconverter.CommitDateSource(*commitDate)
+	config.GroupBy = gitconverter.GroupPeriod(*groupBy)
+	config.NormalizeEOL = gitconverter.EOLMode(*eol)
+	if config.Append && !config.IgnorePreviousConfig {
+		usePreviousDefaults(&config)
+	}
+	if *exclude != "" {
+		config.ExcludeFolders = strings.Split(*exclude, ",")
+	}
+	if *clearExclusions {
+		if err := gitconverter.ClearExcludedFolders(config.TargetDir); err != nil {
+			log.Fatalf("ошибка очистки исключений: %v", err)
+		}
+	}
+	if *clearHashCache {
+		if err := gitconverter.ClearHashCache(config.TargetDir); err != nil {
+			log.Fatalf("ошибка удаления кэша хешей: %v", err)
+		}
+	}
+	if *versionsFile != "" {
+		overrides, err := gitconverter.LoadVersionOverrides(*versionsFile)
+		if err != nil {
+			log.Fatalf("ошибка чтения файла версий: %v", err)
+		}
+		config.VersionOverrides = overrides
+	}
+	if *credentialsFile != "" {
+		store, err := credentialStore(*credentialsFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		config.CredentialStore = store
+	}
+
+	// Токен не передается флагом, чтобы не светиться в списке процессов
+	config.WebhookToken = os.Getenv("FOLDER_TO_GIT_WEBHOOK_TOKEN")
+	config.SignKeyPassphrase = os.Getenv("FOLDER_TO_GIT_SIGN_PASSPHRASE")
+	config.PushSSHKeyPassphrase = os.Getenv("FOLDER_TO_GIT_SSH_PASSPHRASE")
+	config.SFTPKeyPassphrase = os.Getenv("FOLDER_TO_GIT_SFTP_PASSPHRASE")
+	if *webhookEvents != "" {
+		config.WebhookEvents = strings.Split(*webhookEvents, ",")
+	}
+	config.WarnCommitSize = *warnCommitMB << 20
+	config.LFSThresholdBytes = *lfsMB << 20
+	if *lfsPatterns != "" {
+		config.LFSPatterns = strings.Split(*lfsPatterns, ",")
+	}
+	if *skipTypes != "" {
+		config.SkipContentTypes = strings.Split(*skipTypes, ",")
+	}
+	if *ignoreDirs != "" {
+		config.IgnoreDirs = strings.Split(*ignoreDirs, ",")
+	}
+	if *ignoreFiles != "" {
+		config.IgnoreFiles = strings.Split(*ignoreFiles, ",")
+	}
+
+	// Ctrl+C останавливает миграцию, созданные коммиты сохраняются
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *watch {
+		if err := gitconverter.Watch(ctx, config); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	converter, err := gitconverter.New(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	scan, err := converter.Scan()
+	// Без -extract подсказываем шаблон, если у большинства папок нет своей версии
+	if scan != nil && (config.DryRun || err != nil) && !flagSet("extract") {
+		printPatternSuggestions(scan)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	result, err := converter.MigrateContext(ctx, scan.Folders)
+	pushFailed := errors.Is(err, gitconverter.ErrPushFailed)
+	if err != nil && !pushFailed {
+		if n := len(result.Commits); n > 0 {
+			log.Printf("Создано коммитов до ошибки: %d, последняя перенесенная версия: %s",
+				result.CommitsCreated, gitconverter.Printable(result.Commits[n-1].Version))
+		}
+		log.Fatal(err)
+	}
+	for _, failure := range result.Failed {
+		log.Printf("Пропущена версия %s: %s", gitconverter.Printable(failure.Version), gitconverter.Printable(failure.Err))
+	}
+	for _, version := range result.Unchanged {
+		log.Printf("Без изменений, коммит не создан: %s", gitconverter.Printable(version))
+	}
+	for _, vanished := range result.Vanished {
+		log.Printf("Папка исчезла после сканирования, версия пропущена: %s", gitconverter.Printable(vanished.Version))
+	}
+	if !config.DryRun && !config.NoGit {
+		log.Printf("Создано коммитов: %d, версий уже было в репозитории: %d", result.CommitsCreated, len(result.SkippedVersions))
+	}
+	if pushFailed {
+		// Отдельный код выхода: история готова, повторить нужно только отправку
+		log.Print(err)
+		os.Exit(3)
+	}
+	if len(result.Failed) > 0 {
+		os.Exit(1)
+	}
+}
+
+// usePreviousDefaults сбрасывает флаги, оставленные по умолчанию, если в
+// репозитории сохранены настройки прошлого запуска: тогда вместо значений
+// по умолчанию будут использованы сохраненные
+func usePreviousDefaults(config *gitconverter.Config) {
+	previous, err := gitconverter.LoadPreviousSettings(config.TargetDir)
+	if err != nil {
+		log.Printf("Предупреждение: %v", err)
+		return
+	}
+	if previous == nil {
+		return
+	}
+
+	defaults := map[string][2]*string{
+		"pattern": {&config.Pattern, &previous.Pattern},
+		"extract": {&config.ExtractPattern, &previous.ExtractPattern},
+		"author":  {&config.Author, &previous.Author},
+		"email":   {&config.Email, &previous.Email},
+	}
+	for name, value := range defaults {
+		if !flagSet(name) && *value[1] != "" {
+			*value[0] = ""
+		}
+	}
+	if !flagSet("sort") && previous.SortMode != "" {
+		config.SortMode = ""
+	}
+	log.Printf("Используются настройки прошлого запуска из %s (отключить: -ignore-previous-config)", config.TargetDir)
+}
+
+// flagSet проверяет, задан ли флаг name в командной строке явно
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"folder_to_git/pkg/gitconverter"
+)
+
+// runReleaseNotes выводит заметки к выпускам в Markdown: к одной версии
+// или ко всем версиям с тегами
+func runReleaseNotes(args []string) {
+	fs := flag.NewFlagSet("release-notes", flag.ExitOnError)
+	config := gitconverter.Config{}
+	fs.StringVar(&config.TargetDir, "target", ".", "директория Git-репозитория")
+	version := fs.String("version", "", "версия; без нее выводятся заметки ко всем версиям")
+	output := fs.String("o", "", "файл для записи, например RELEASES.md (по умолчанию стандартный вывод)")
+	fs.Parse(args)
+
+	var notes string
+	var err error
+	if *version != "" {
+		notes, err = gitconverter.ReleaseNotes(config, *version)
+	} else {
+		notes, err = gitconverter.AllReleaseNotes(config)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *output == "" {
+		fmt.Print(notes)
+		return
+	}
+	if err := os.WriteFile(*output, []byte(notes), 0644); err != nil {
+		log.Fatalf("ошибка записи %s: %v", *output, err)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"folder_to_git/pkg/gitconverter"
+)
+
+// orderRules поясняет правила OrderDecision.Rule для таблицы
+var orderRules = map[gitconverter.OrderRule]string{
+	gitconverter.OrderByTime:        "по времени создания",
+	gitconverter.OrderTimeTie:       "время совпало с соседней, по имени папки",
+	gitconverter.OrderByVersion:     "по номеру версии",
+	gitconverter.OrderVersionTie:    "версия совпала с соседней, по времени",
+	gitconverter.OrderNotSemver:     "не SemVer, в конце по времени",
+	gitconverter.OrderInversion:     "дата противоречит номеру, оставлен порядок дат",
+	gitconverter.OrderPreferVersion: "дата противоречит номеру, переставлена по номеру",
+	gitconverter.OrderManual:        "порядок изменен вручную",
+}
+
+// timeStrategies поясняет источники времени OrderDecision.TimeStrategy
+var timeStrategies = map[gitconverter.TimestampStrategy]string{
+	gitconverter.TimestampTimeFile: "из файла времени",
+}
+
+// runExplainOrder выводит таблицу с объяснением порядка версий: из манифеста
+// репозитория или, если указан -source, по результатам нового поиска
+func runExplainOrder(args []string) {
+	fs := flag.NewFlagSet("explain-order", flag.ExitOnError)
+	config := gitconverter.Config{}
+	fs.StringVar(&config.TargetDir, "target", ".", "директория Git-репозитория с манифестом последнего запуска")
+	fs.StringVar(&config.SourceDir, "source", "", "найти версии заново в этой директории вместо чтения манифеста")
+	fs.StringVar(&config.Pattern, "pattern", "*", "шаблон имен папок с версиями (с -source)")
+	fs.StringVar(&config.ExtractPattern, "extract", "[0-9]+(\\.[0-9]+)?", "регулярное выражение для извлечения версии (с -source)")
+	fs.BoolVar(&config.Recursive, "recursive", false, "искать папки с версиями во вложенных директориях (с -source)")
+	fs.IntVar(&config.MaxDepth, "max-depth", 0, "наибольшая глубина папки с версией при -recursive (с -source)")
+	sortMode := fs.String("sort", "time", "порядок версий: time, semver или natural (с -source)")
+	fs.StringVar(&config.TimeSourceFile, "time-file", "", "файл со временем создания версий: версия=время (с -source)")
+	fs.BoolVar(&config.PreferVersionOrder, "prefer-version-order", false, "упорядочивать по номерам версии, даты которых противоречат номерам (с -source)")
+	fs.Parse(args)
+	config.SortMode = gitconverter.SortMode(*sortMode)
+
+	var order []gitconverter.OrderDecision
+	if config.SourceDir != "" {
+		converter, err := gitconverter.New(config)
+		if err != nil {
+			log.Fatal(err)
+		}
+		scan, err := converter.Scan()
+		if err != nil {
+			log.Fatal(err)
+		}
+		order = scan.Order
+	} else {
+		var err error
+		order, err = gitconverter.LoadOrderAudit(config.TargetDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if order == nil {
+			log.Fatalf("в %s нет сведений о порядке версий: репозиторий перенесен более старой версией или без миграции", config.TargetDir)
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "№\tВерсия\tПапка\tСоздана\tИсточник времени\tПравило")
+	for _, d := range order {
+		strategy := timeStrategies[d.TimeStrategy]
+		if strategy == "" {
+			strategy = string(d.TimeStrategy)
+		}
+		if d.TimeFallback {
+			strategy += " (запасной способ)"
+		}
+		rule := orderRules[d.Rule]
+		if rule == "" {
+			rule = string(d.Rule)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", d.Index, gitconverter.Printable(d.Version),
+			gitconverter.Printable(filepath.Base(d.Path)), d.CreationTime.Local().Format("2006-01-02 15:04:05"), strategy, rule)
+	}
+	w.Flush()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"folder_to_git/pkg/gitconverter"
+)
+
+// runCommitFolder выполняет команду commit-folder: переносит одну папку
+// следующим коммитом без поиска версий по шаблону
+func runCommitFolder(args []string) {
+	fs := flag.NewFlagSet("commit-folder", flag.ExitOnError)
+	config := gitconverter.Config{}
+	fs.StringVar(&config.TargetDir, "target", ".", "директория Git-репозитория")
+	version := fs.String("version", "", "версия папки (по умолчанию имя папки)")
+	fs.StringVar(&config.Author, "author", "", "автор коммита (по умолчанию как в прошлом запуске или Developer)")
+	fs.StringVar(&config.Email, "email", "", "email автора коммита (по умолчанию как в прошлом запуске или dev@example.com)")
+	fs.StringVar(&config.AuthorsFile, "authors", "", "файл с сопоставлением версий и авторов")
+	fs.StringVar(&config.TimeSourceFile, "time-file", "", "файл со временем создания версий: версия=время")
+	fs.StringVar(&config.Branch, "branch", "", "ветка для коммита")
+	fs.BoolVar(&config.AnnotatedTags, "tags", false, "создать аннотированный тег версии")
+	fs.StringVar(&config.TagPrefix, "tag-prefix", "", "префикс имени тега, например v")
+	fs.StringVar(&config.SignKeyPath, "sign-key", "", "файл закрытого ключа OpenPGP для подписи (пароль берется из FOLDER_TO_GIT_SIGN_PASSPHRASE)")
+	fs.BoolVar(&config.IgnorePreviousConfig, "ignore-previous-config", false, "не брать настройки прошлого запуска")
+	fs.BoolVar(&config.DryRun, "dry-run", false, "только показать коммит, который будет создан")
+	fs.BoolVar(&config.Verbose, "verbose", false, "подробный лог")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Использование: folder-to-git commit-folder [-target путь] [-version версия] [флаги] папка")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	useDefaultAuthor(&config)
+	config.SignKeyPassphrase = os.Getenv("FOLDER_TO_GIT_SIGN_PASSPHRASE")
+
+	// Ctrl+C прерывает перенос, рабочая директория возвращается к последнему коммиту
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := gitconverter.MigrateSingleContext(ctx, config, fs.Arg(0), *version)
+	if err != nil {
+		log.Fatal(gitconverter.Printable(err.Error()))
+	}
+	for _, commit := range result.Plan {
+		if commit.Skipped {
+			fmt.Printf("Коммит не будет создан: %s\n", commit.Reason)
+			continue
+		}
+		fmt.Printf("Будет создан коммит: %s (%s <%s>), добавлено %d, изменено %d, удалено %d\n",
+			gitconverter.Printable(commit.Subject), commit.Author, commit.Email, commit.Added, commit.Modified, commit.Deleted)
+	}
+	for _, version := range result.Unchanged {
+		log.Printf("Без изменений, коммит не создан: %s", gitconverter.Printable(version))
+	}
+}
+
+// useDefaultAuthor подставляет автора по умолчанию, если он не задан флагами
+// и не сохранен в настройках прошлого запуска
+func useDefaultAuthor(config *gitconverter.Config) {
+	var previous *gitconverter.RunSettings
+	if !config.IgnorePreviousConfig {
+		previous, _ = gitconverter.LoadPreviousSettings(config.TargetDir)
+	}
+	if config.Author == "" && (previous == nil || previous.Author == "") {
+		config.Author = "Developer"
+	}
+	if config.Email == "" && (previous == nil || previous.Email == "") {
+		config.Email = "dev@example.com"
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+
+	"folder_to_git/pkg/gitconverter"
+)
+
+// suggestionExamples — сколько версий показывать у предложенного шаблона
+const suggestionExamples = 3
+
+// printPatternSuggestions выводит шаблоны -extract, которые различают версии
+// папок лучше текущего
+func printPatternSuggestions(scan *gitconverter.ScanResult) {
+	suggestions := scan.SuggestPatterns()
+	if len(suggestions) == 0 {
+		return
+	}
+
+	names := scan.SampleNames()
+	fmt.Printf("Не у всех папок нашлась своя версия (найдено %d из %d). Возможные шаблоны -extract:\n", len(scan.Folders), len(names))
+	for _, s := range suggestions {
+		fmt.Printf("  %s — %s, совпадает с %d из %d\n", s.Pattern, s.Description, s.Matched, len(names))
+		shown := 0
+		for _, name := range names {
+			version, ok := s.Versions[name]
+			if !ok {
+				continue
+			}
+			if shown == suggestionExamples {
+				fmt.Println("      …")
+				break
+			}
+			fmt.Printf("      %s → %s\n", gitconverter.Printable(name), gitconverter.Printable(version))
+			shown++
+		}
+	}
+}
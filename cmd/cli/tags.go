@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"folder_to_git/pkg/gitconverter"
+)
+
+// runTags ставит недостающие теги версий в уже перенесенном репозитории
+func runTags(args []string) {
+	fs := flag.NewFlagSet("tags", flag.ExitOnError)
+	config := gitconverter.Config{}
+	fs.StringVar(&config.TargetDir, "target", ".", "директория Git-репозитория")
+	fs.BoolVar(&config.DryRun, "dry-run", false, "только показать теги, которые будут созданы")
+	fs.StringVar(&config.TagPrefix, "prefix", "", "префикс имен тегов, как при миграции")
+	fs.StringVar(&config.SignKeyPath, "sign-key", "", "файл закрытого ключа OpenPGP для подписи тегов (пароль берется из FOLDER_TO_GIT_SIGN_PASSPHRASE)")
+	fs.Parse(args)
+	config.SignKeyPassphrase = os.Getenv("FOLDER_TO_GIT_SIGN_PASSPHRASE")
+
+	report, err := gitconverter.RetrofitTags(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	created := "Создан тег"
+	if config.DryRun {
+		created = "Будет создан тег"
+	}
+	for _, tag := range report.Created {
+		fmt.Printf("%s %s -> %s\n", created, tag.Name, tag.Commit[:7])
+	}
+	for _, tag := range report.Present {
+		fmt.Printf("Тег %s уже есть\n", tag.Name)
+	}
+	fmt.Printf("Создано: %d, уже было: %d\n", len(report.Created), len(report.Present))
+}
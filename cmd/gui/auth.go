@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"folder_to_git/pkg/gitconverter"
+)
+
+// showCredentialsDialog сохраняет токен для отправки в системное хранилище.
+// В настройках приложения остается только имя записи
+func (g *GUI) showCredentialsDialog() {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("github")
+	secretEntry := widget.NewPasswordEntry()
+	secretEntry.SetPlaceHolder("токен или пользователь:токен")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Имя записи", nameEntry),
+		widget.NewFormItem("Секрет", secretEntry),
+	}
+	dialog.ShowForm("Учетные данные для отправки", "Сохранить", "Отмена", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		if nameEntry.Text == "" || secretEntry.Text == "" {
+			dialog.ShowError(fmt.Errorf("укажите имя записи и секрет"), g.window)
+			return
+		}
+		store := gitconverter.NewKeyringStore()
+		if err := store.Set(nameEntry.Text, secretEntry.Text); err != nil {
+			g.logError("Ошибка сохранения учетных данных:", err)
+			return
+		}
+		g.log(fmt.Sprintf("Учетные данные %s сохранены в системном хранилище", nameEntry.Text))
+	}, g.window)
+}
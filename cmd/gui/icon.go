@@ -0,0 +1,16 @@
+package main
+
+import (
+	_ "embed"
+
+	"fyne.io/fyne/v2"
+)
+
+// Иконка генерируется из cmd/icon, чтобы встроенный ресурс совпадал с генератором
+//go:generate go run ../icon -output appicon.png -formats png -size 512 -force
+
+//go:embed appicon.png
+var appIconPNG []byte
+
+// appIcon — иконка приложения для Dock, панели задач и окон
+var appIcon = fyne.NewStaticResource("appicon.png", appIconPNG)
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Fyne переводит фокус по Tab в порядке обхода дерева виджетов, поэтому
+// порядок дочерних элементов контейнеров должен совпадать с тем, как
+// элементы расположены на экране: слева направо и сверху вниз
+
+// entryWithButton размещает кнопку справа от поля ввода. В отличие от
+// container.NewBorder порядок обхода задан явно: сначала поле, затем кнопка
+func entryWithButton(entry *widget.Entry, button *widget.Button) *fyne.Container {
+	return container.New(layout.NewBorderLayout(nil, nil, nil, button), entry, button)
+}
+
+// setupKeyboard настраивает работу с формой без мыши: Enter в поле
+// переводит фокус на следующее поле, а в последнем поле запускает проверку
+// и конвертацию. Ctrl+Enter (Cmd+Enter на macOS) запускает конвертацию из
+// любого места окна. Флажки переключаются пробелом силами Fyne
+func (g *GUI) setupKeyboard(entries ...*widget.Entry) {
+	for i, entry := range entries {
+		if i == len(entries)-1 {
+			entry.OnSubmitted = func(string) { g.submitForm() }
+			continue
+		}
+		entry.OnSubmitted = func(string) { g.window.Canvas().FocusNext() }
+	}
+
+	g.window.Canvas().AddShortcut(&desktop.CustomShortcut{
+		KeyName:  fyne.KeyReturn,
+		Modifier: fyne.KeyModifierShortcutDefault,
+	}, func(fyne.Shortcut) { g.submitForm() })
+}
+
+// submitForm запускает конвертацию, как кнопка "Начать конвертацию", если
+// она сейчас доступна
+func (g *GUI) submitForm() {
+	if g.convertButton.Disabled() || !g.convertButton.Visible() {
+		return
+	}
+	g.startConversion()
+}
+
+// previewTable — таблица предпросмотра с управлением с клавиатуры: стрелки
+// переводят фокус между ячейками, пробел включает или исключает папку
+// строки с фокусом, Enter выполняет действие ячейки, как щелчок мышью
+type previewTable struct {
+	widget.Table
+
+	row      int           // Строка с фокусом
+	onToggle func(row int) // Переключение исключения папки строки
+}
+
+// newPreviewTable создает таблицу предпросмотра
+func newPreviewTable(length func() (int, int), create func() fyne.CanvasObject, update func(widget.TableCellID, fyne.CanvasObject)) *previewTable {
+	t := &previewTable{}
+	t.Length, t.CreateCell, t.UpdateCell = length, create, update
+	t.ExtendBaseWidget(t)
+	return t
+}
+
+// focusRow запоминает строку, выбранную мышью: Fyne переносит на нее фокус
+func (t *previewTable) focusRow(row int) {
+	t.row = row
+}
+
+// TypedKey обрабатывает клавиши, когда таблица в фокусе
+func (t *previewTable) TypedKey(event *fyne.KeyEvent) {
+	rows, _ := t.Length()
+	switch event.Name {
+	case fyne.KeySpace:
+		if t.row < rows && t.onToggle != nil {
+			t.onToggle(t.row)
+		}
+		return
+	case fyne.KeyReturn, fyne.KeyEnter:
+		// Для встроенной таблицы пробел выбирает ячейку с фокусом
+		event = &fyne.KeyEvent{Name: fyne.KeySpace}
+	case fyne.KeyDown:
+		if t.row < rows-1 {
+			t.row++
+		}
+	case fyne.KeyUp:
+		if t.row > 0 {
+			t.row--
+		}
+	}
+	t.Table.TypedKey(event)
+}
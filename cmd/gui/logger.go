@@ -0,0 +1,16 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// guiLogger выводит сообщения библиотеки в лог окна и, как прежде, в stderr
+type guiLogger struct {
+	g *GUI
+}
+
+func (l guiLogger) Printf(format string, args ...interface{}) {
+	log.Printf(format, args...)
+	l.g.log(fmt.Sprintf(format, args...))
+}
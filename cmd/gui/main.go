@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"image/color"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -12,6 +17,7 @@ import (
 	"fyne.io/fyne/v2/widget"
 	"github.com/ncruces/zenity"
 
+	"folder_to_git/internal/palette"
 	"folder_to_git/pkg/gitconverter"
 )
 
@@ -20,23 +26,44 @@ type GUI struct {
 	config gitconverter.Config
 
 	// Виджеты
-	sourceEntry   *widget.Entry
-	targetEntry   *widget.Entry
-	patternEntry  *widget.Entry
-	extractEntry  *widget.Entry
-	authorEntry   *widget.Entry
-	emailEntry    *widget.Entry
-	dryRunCheck   *widget.Check
-	verboseCheck  *widget.Check
-	appendCheck   *widget.Check
-	logText       *widget.Entry
-	convertButton *widget.Button
+	sourceEntry       *widget.Entry
+	targetEntry       *widget.Entry
+	patternEntry      *widget.Entry
+	extractEntry      *widget.Entry
+	suggestBox        *fyne.Container // Предложенные шаблоны версии под extractEntry
+	authorEntry       *widget.Entry
+	emailEntry        *widget.Entry
+	branchEntry       *widget.Entry
+	dryRunCheck       *widget.Check
+	verboseCheck      *widget.Check
+	appendCheck       *widget.Check
+	noGitCheck        *widget.Check
+	incrementalCheck  *widget.Check
+	anonymizeCheck    *widget.Check
+	tagsCheck         *widget.Check
+	versionOrderCheck *widget.Check
+	ignorePrevCheck   *widget.Check
+	skipSameCheck     *widget.Check
+	gitignoreCheck    *widget.Check
+	bareCheck         *widget.Check
+	logText           *widget.Entry
+	logMu             sync.Mutex // Сообщения библиотеки приходят из нескольких горутин
+	logScroll         *container.Scroll
+	convertButton     *widget.Button
+	progressBar       *widget.ProgressBar
+	stopButton        *widget.Button
+	stopConversion    context.CancelFunc
+
+	// Предпросмотр найденных папок
+	preview previewState
 }
 
 func main() {
 	a := app.NewWithID("com.foldertogit.app")
+	a.SetIcon(appIcon)
 	a.Settings().SetTheme(newNativeTheme())
 	window := a.NewWindow("Конвертер папок в Git")
+	window.SetIcon(appIcon)
 
 	gui := &GUI{
 		window: window,
@@ -48,8 +75,9 @@ func main() {
 		},
 	}
 
+	gui.config.Logger = guiLogger{g: gui}
 	gui.setupUI()
-	window.Resize(fyne.NewSize(700, 750))
+	restoreWindowSize(a, window)
 	window.ShowAndRun()
 }
 
@@ -76,6 +104,8 @@ func (g *GUI) setupUI() {
 	g.extractEntry.SetPlaceHolder("[0-9]+ или v([0-9]+)")
 	g.extractEntry.Resize(fyne.NewSize(300, g.extractEntry.MinSize().Height))
 	styleNativeEntry(g.extractEntry)
+	g.suggestBox = container.NewVBox()
+	g.suggestBox.Hide()
 
 	g.authorEntry = widget.NewEntry()
 	g.authorEntry.SetText(g.config.Author)
@@ -89,6 +119,12 @@ func (g *GUI) setupUI() {
 	g.emailEntry.Resize(fyne.NewSize(300, g.emailEntry.MinSize().Height))
 	styleNativeEntry(g.emailEntry)
 
+	g.branchEntry = widget.NewEntry()
+	g.branchEntry.SetText(g.config.Branch)
+	g.branchEntry.SetPlaceHolder("master")
+	g.branchEntry.Resize(fyne.NewSize(300, g.branchEntry.MinSize().Height))
+	styleNativeEntry(g.branchEntry)
+
 	// Кнопки выбора директорий с нативным стилем
 	sourceBrowse := widget.NewButtonWithIcon("Обзор", theme.FolderOpenIcon(), func() {
 		path, err := zenity.SelectFile(
@@ -116,6 +152,15 @@ func (g *GUI) setupUI() {
 	g.dryRunCheck = widget.NewCheck("Тестовый режим", nil)
 	g.verboseCheck = widget.NewCheck("Подробный вывод", nil)
 	g.appendCheck = widget.NewCheck("Добавить к существующему", nil)
+	g.noGitCheck = widget.NewCheck("Без Git", nil)
+	g.incrementalCheck = widget.NewCheck("Только изменения", nil)
+	g.anonymizeCheck = widget.NewCheck("Без авторов", nil)
+	g.tagsCheck = widget.NewCheck("Теги версий", nil)
+	g.versionOrderCheck = widget.NewCheck("Порядок по номерам", nil)
+	g.ignorePrevCheck = widget.NewCheck("Без прошлых настроек", nil)
+	g.skipSameCheck = widget.NewCheck("Пропускать без изменений", nil)
+	g.gitignoreCheck = widget.NewCheck("Учитывать .gitignore", nil)
+	g.bareCheck = widget.NewCheck("Голый репозиторий", nil)
 
 	// Лог
 	g.logText = widget.NewEntry()
@@ -128,41 +173,70 @@ func (g *GUI) setupUI() {
 	// Кнопка конвертации с нативным стилем
 	g.convertButton = widget.NewButtonWithIcon("Начать конвертацию", theme.MediaPlayIcon(), g.startConversion)
 	styleNativePrimaryButton(g.convertButton)
+	g.stopButton = widget.NewButtonWithIcon("Остановить", theme.MediaStopIcon(), func() {
+		g.stopButton.Disable()
+		g.log("Остановка конвертации...")
+		g.stopConversion()
+	})
+	g.stopButton.Hide()
+
+	previewSection := g.setupPreview()
 
 	// Компоновка интерфейса
 	form := &widget.Form{
 		Items: []*widget.FormItem{
-			{Text: "Исходная директория", Widget: container.NewBorder(nil, nil, nil, sourceBrowse, g.sourceEntry)},
-			{Text: "Целевой репозиторий", Widget: container.NewBorder(nil, nil, nil, targetBrowse, g.targetEntry)},
+			{Text: "Исходная директория", Widget: entryWithButton(g.sourceEntry, sourceBrowse)},
+			{Text: "Целевой репозиторий", Widget: entryWithButton(g.targetEntry, targetBrowse)},
 			{Text: "Шаблон поиска", Widget: g.patternEntry},
-			{Text: "Шаблон версии", Widget: g.extractEntry},
+			{Text: "Шаблон версии", Widget: container.NewVBox(g.extractEntry, g.suggestBox)},
 			{Text: "Имя автора", Widget: g.authorEntry},
 			{Text: "Email автора", Widget: g.emailEntry},
+			{Text: "Ветка", Widget: g.branchEntry},
 		},
 	}
 
-	options := container.NewHBox(
+	options := container.NewGridWithColumns(3,
 		g.dryRunCheck,
 		g.verboseCheck,
 		g.appendCheck,
+		g.noGitCheck,
+		g.incrementalCheck,
+		g.anonymizeCheck,
+		g.tagsCheck,
+		g.versionOrderCheck,
+		g.ignorePrevCheck,
+		g.skipSameCheck,
+		g.gitignoreCheck,
+		g.bareCheck,
 	)
 
+	g.progressBar = widget.NewProgressBar()
+	g.progressBar.Hide()
+
 	buttons := container.NewHBox(
 		g.convertButton,
+		g.stopButton,
+		g.preview.scanButton,
+		widget.NewButtonWithIcon("Импортировать одну папку…", theme.FolderNewIcon(), g.importSingleFolder),
 		widget.NewButtonWithIcon("Очистить лог", theme.ContentClearIcon(), func() {
 			g.logText.SetText("")
 		}),
+		widget.NewButtonWithIcon("Учетные данные", theme.AccountIcon(), g.showCredentialsDialog),
+		widget.NewButtonWithIcon("Забыть исключения", theme.ContentUndoIcon(), g.clearExclusions),
 	)
 
 	// Создаем заголовки
 	optionsLabel := widget.NewLabel("Дополнительные опции")
 	optionsLabel.TextStyle = fyne.TextStyle{Bold: true}
+	previewLabel := widget.NewLabel("Найденные папки")
+	previewLabel.TextStyle = fyne.TextStyle{Bold: true}
 	logLabel := widget.NewLabel("Лог операций")
 	logLabel.TextStyle = fyne.TextStyle{Bold: true}
 
 	// Создаем скроллируемый контейнер для лога с фиксированной высотой
 	logScroll := container.NewScroll(g.logText)
 	logScroll.SetMinSize(fyne.NewSize(500, 200))
+	g.logScroll = logScroll
 
 	// Основной контейнер с вертикальной прокруткой
 	mainContainer := container.NewVBox(
@@ -172,6 +246,11 @@ func (g *GUI) setupUI() {
 			widget.NewCard("", "", options),
 		),
 		buttons,
+		g.progressBar,
+		container.NewVBox(
+			previewLabel,
+			widget.NewCard("", "", previewSection),
+		),
 		container.NewVBox(
 			logLabel,
 			widget.NewCard("", "", logScroll),
@@ -184,6 +263,8 @@ func (g *GUI) setupUI() {
 	// Добавляем отступы и устанавливаем контент
 	content := container.NewPadded(scrollContainer)
 	g.window.SetContent(content)
+	g.setupKeyboard(g.sourceEntry, g.targetEntry, g.patternEntry, g.extractEntry,
+		g.authorEntry, g.emailEntry, g.branchEntry)
 }
 
 // Добавляем вспомогательные функции для стилизации
@@ -232,7 +313,7 @@ func (t *nativeTheme) Color(n fyne.ThemeColorName, v fyne.ThemeVariant) color.Co
 	case theme.ColorNameForeground:
 		return color.NRGBA{R: 0, G: 0, B: 0, A: 255}
 	case theme.ColorNamePrimary:
-		return color.NRGBA{R: 0, G: 122, B: 255, A: 255}
+		return palette.Primary
 	default:
 		return t.defaultTheme.Color(n, v)
 	}
@@ -272,58 +353,222 @@ func (g *GUI) startConversion() {
 		return
 	}
 
-	// Обновляем конфигурацию
-	g.config.SourceDir = g.sourceEntry.Text
-	g.config.TargetDir = g.targetEntry.Text
-	g.config.Pattern = g.patternEntry.Text
-	g.config.ExtractPattern = g.extractEntry.Text
-	g.config.Author = g.authorEntry.Text
-	g.config.Email = g.emailEntry.Text
-	g.config.DryRun = g.dryRunCheck.Checked
-	g.config.Verbose = g.verboseCheck.Checked
-	g.config.Append = g.appendCheck.Checked
+	g.updateConfig()
 
-	// Отключаем кнопку на время конвертации
-	g.convertButton.Disable()
-	g.convertButton.SetText("Выполняется...")
+	ctx := g.beginConversion()
 
 	// Запускаем конвертацию в отдельной горутине
 	go func() {
-		defer func() {
-			g.convertButton.Enable()
-			g.convertButton.SetText("Начать конвертацию")
-		}()
-
 		// Ищем папки с версиями
 		g.log("Начинаем поиск папок с версиями...")
-		folders, err := gitconverter.FindVersionedFolders(g.config)
+		var scan *gitconverter.ScanResult
+		converter, err := gitconverter.New(g.config)
+		if err == nil {
+			scan, err = converter.Scan()
+		}
+		if scan != nil && len(scan.Unmatched) > 0 {
+			g.log(fmt.Sprintf("Пропущено %d папок без версии в имени (проверьте шаблон версии):", len(scan.Unmatched)))
+			for _, path := range scan.Unmatched {
+				g.log("  " + filepath.Base(path))
+			}
+		}
 		if err != nil {
 			g.logError("Ошибка поиска папок:", err)
+			g.finishConversion()
 			return
 		}
+		folders := scan.Folders
 
 		if len(folders) == 0 {
 			g.logError("Не найдены папки с версиями", nil)
+			g.finishConversion()
 			return
 		}
 
 		g.log(fmt.Sprintf("Найдено %d папок с версиями", len(folders)))
+		g.confirmAndRun(ctx, folders)
+	}()
+}
 
-		// Выполняем миграцию
-		if err := gitconverter.MigrateToGit(g.config, folders); err != nil {
-			g.logError("Ошибка миграции:", err)
-			return
+// confirmAndRun запускает миграцию папок, предварительно спросив
+// подтверждение, если она удалит текущее содержимое целевой директории
+func (g *GUI) confirmAndRun(ctx context.Context, folders []gitconverter.FolderInfo) {
+	// Очистка целевой директории необратима, поэтому спрашиваем явно
+	if msg := g.clearWarning(folders); msg != "" {
+		dialog.ShowConfirm("Удалить содержимое?", msg, func(confirmed bool) {
+			if !confirmed {
+				g.log("Конвертация отменена")
+				g.finishConversion()
+				return
+			}
+			go g.runMigration(ctx, folders)
+		}, g.window)
+		return
+	}
+	g.runMigration(ctx, folders)
+}
+
+// beginConversion отключает кнопку конвертации, показывает кнопку остановки
+// и возвращает контекст, который она отменяет
+func (g *GUI) beginConversion() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	g.stopConversion = cancel
+
+	g.convertButton.Disable()
+	g.convertButton.SetText("Выполняется...")
+	g.stopButton.Enable()
+	g.stopButton.Show()
+	g.progressBar.SetValue(0)
+	g.progressBar.Show()
+	return ctx
+}
+
+// finishConversion возвращает кнопку конвертации в исходное состояние
+func (g *GUI) finishConversion() {
+	if g.stopConversion != nil {
+		g.stopConversion()
+	}
+	g.stopButton.Hide()
+	g.progressBar.Hide()
+	g.convertButton.Enable()
+	g.convertButton.SetText("Начать конвертацию")
+}
+
+// clearWarning возвращает текст предупреждения, если миграция удалит
+// существующие файлы в целевой директории, иначе пустую строку
+func (g *GUI) clearWarning(folders []gitconverter.FolderInfo) string {
+	if !gitconverter.ClearsTarget(g.config) {
+		return ""
+	}
+	files, size, err := gitconverter.TargetContents(g.config)
+	if err != nil {
+		return fmt.Sprintf("Не удалось проверить содержимое %s: %v.\n"+
+			"Все файлы в ней, кроме .git, будут удалены. Продолжить?", g.config.TargetDir, err)
+	}
+	if files == 0 {
+		return ""
+	}
+
+	msg := fmt.Sprintf("Текущее содержимое %s (%d файлов, %s) будет удалено,\nбудет создано %d коммитов",
+		g.config.TargetDir, files, gitconverter.FormatSize(size), len(folders))
+	if converter, err := gitconverter.New(g.config); err == nil {
+		if estimate, err := converter.Estimate(folders); err == nil {
+			msg += fmt.Sprintf(" (около %s данных)", gitconverter.FormatSize(estimate.Bytes))
 		}
+	}
+	return msg + ".\nПродолжить?"
+}
+
+// runMigration переносит найденные версии и выводит итоги в лог
+func (g *GUI) runMigration(ctx context.Context, folders []gitconverter.FolderInfo) {
+	defer g.finishConversion()
+
+	// Выполняем миграцию; обратный вызов не бывает одновременным,
+	// поэтому индикатор обновляется без блокировки
+	config := g.config
+	config.OnProgress = func(done, total int, folder gitconverter.FolderInfo) {
+		g.progressBar.SetValue(float64(done-1) / float64(total))
+		g.convertButton.SetText(fmt.Sprintf("Выполняется %d/%d...", done, total))
+	}
+	converter, err := gitconverter.New(config)
+	if err != nil {
+		g.logError("Ошибка настроек:", err)
+		return
+	}
+	result, err := converter.MigrateContext(ctx, folders)
+	if errors.Is(err, context.Canceled) {
+		g.log("Конвертация остановлена, созданные коммиты сохранены")
+		return
+	}
+	if err != nil {
+		// Итоги заполнены и при ошибке: по ним видно, где миграция остановилась
+		if n := len(result.Commits); n > 0 && !g.config.DryRun {
+			g.log(fmt.Sprintf("Перенесено до ошибки: %s. Последняя перенесенная версия: %s",
+				migrationTotals(result), gitconverter.Printable(result.Commits[n-1].Version)))
+		}
+		g.logError("Ошибка миграции:", err)
+		return
+	}
+
+	for _, failure := range result.Failed {
+		g.log(fmt.Sprintf("Пропущена версия %s: %s", gitconverter.Printable(failure.Version), gitconverter.Printable(failure.Err)))
+	}
+	if len(result.Unchanged) > 0 {
+		g.log(fmt.Sprintf("Версии без изменений: %s", gitconverter.Printable(strings.Join(result.Unchanged, ", "))))
+	}
+	for _, vanished := range result.Vanished {
+		g.log(fmt.Sprintf("Папка версии %s исчезла после сканирования, версия пропущена. Нажмите 'Сканировать', чтобы обновить список",
+			gitconverter.Printable(vanished.Version)))
+	}
+	firstWarning := g.showWarnings(folders, result.Warnings)
 
+	if g.config.NoGit {
+		for _, entry := range result.Layout {
+			g.log(fmt.Sprintf("%s -> %s", entry.Version, entry.Target))
+		}
 		if !g.config.DryRun {
-			g.logSuccess(fmt.Sprintf("Git-репозиторий успешно создан в: %s", g.config.TargetDir))
+			g.logSummary(fmt.Sprintf("Разложено %d папок в: %s", len(result.Layout), g.config.TargetDir),
+				len(result.Warnings), firstWarning)
 		} else {
 			g.log("Тестовый режим завершен")
 		}
-	}()
+	} else if !g.config.DryRun {
+		g.logSummary(fmt.Sprintf("Git-репозиторий успешно создан в: %s\n%s", g.config.TargetDir, migrationTotals(result)),
+			len(result.Warnings), firstWarning)
+	} else {
+		g.log("Тестовый режим завершен")
+		if len(result.Plan) > 0 {
+			g.showPlan(result.Plan, folders)
+		}
+	}
+}
+
+// migrationTotals описывает итоги миграции одной строкой: сколько создано
+// коммитов и сколько версий пропущено и по какой причине
+func migrationTotals(result *gitconverter.MigrationResult) string {
+	parts := []string{fmt.Sprintf("создано коммитов: %d", result.CommitsCreated)}
+	for _, count := range []struct {
+		label string
+		n     int
+	}{
+		{"уже были в репозитории", len(result.SkippedVersions)},
+		{"без изменений", len(result.Unchanged)},
+		{"с ошибкой", len(result.Failed)},
+		{"исчезли", len(result.Vanished)},
+	} {
+		if count.n > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %d", count.label, count.n))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// updateConfig переносит значения полей формы в конфигурацию
+func (g *GUI) updateConfig() {
+	g.config.SourceDir = g.sourceEntry.Text
+	g.config.TargetDir = g.targetEntry.Text
+	g.config.Pattern = g.patternEntry.Text
+	g.config.ExtractPattern = g.extractEntry.Text
+	g.config.Author = g.authorEntry.Text
+	g.config.Email = g.emailEntry.Text
+	g.config.Branch = strings.TrimSpace(g.branchEntry.Text)
+	g.config.DryRun = g.dryRunCheck.Checked
+	g.config.Verbose = g.verboseCheck.Checked
+	g.config.Append = g.appendCheck.Checked
+	g.config.NoGit = g.noGitCheck.Checked
+	g.config.Incremental = g.incrementalCheck.Checked
+	g.config.Anonymize = g.anonymizeCheck.Checked
+	g.config.AnnotatedTags = g.tagsCheck.Checked
+	g.config.PreferVersionOrder = g.versionOrderCheck.Checked
+	g.config.IgnorePreviousConfig = g.ignorePrevCheck.Checked
+	g.config.SkipUnchanged = g.skipSameCheck.Checked
+	g.config.RespectGitignore = g.gitignoreCheck.Checked
+	g.config.Bare = g.bareCheck.Checked
 }
 
 func (g *GUI) log(msg string) {
+	g.logMu.Lock()
+	defer g.logMu.Unlock()
 	g.logText.SetText(g.logText.Text + "\n" + msg)
 }
 
@@ -331,7 +576,7 @@ func (g *GUI) logError(msg string, err error) {
 	if err != nil {
 		msg = fmt.Sprintf("%s %v", msg, err)
 	}
-	dialog.ShowError(fmt.Errorf(msg), g.window)
+	dialog.ShowError(errors.New(msg), g.window)
 	g.log("ОШИБКА: " + msg)
 }
 
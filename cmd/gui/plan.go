@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"folder_to_git/pkg/gitconverter"
+)
+
+// showPlan показывает план тестового запуска деревом: каждая версия
+// раскрывается в подробности будущего коммита. Кнопка "Выполнить этот план"
+// запускает настоящую миграцию тех же папок без повторного сканирования
+func (g *GUI) showPlan(plan []gitconverter.PlannedCommit, folders []gitconverter.FolderInfo) {
+	commits := 0
+	for _, step := range plan {
+		if !step.Skipped {
+			commits++
+		}
+	}
+
+	tree := widget.NewTree(
+		func(id widget.TreeNodeID) []widget.TreeNodeID {
+			if id == "" {
+				ids := make([]widget.TreeNodeID, len(plan))
+				for i := range plan {
+					ids[i] = strconv.Itoa(i)
+				}
+				return ids
+			}
+			step := plan[planIndex(id)]
+			ids := make([]widget.TreeNodeID, len(planDetails(step)))
+			for i := range ids {
+				ids[i] = fmt.Sprintf("%s/%d", id, i)
+			}
+			return ids
+		},
+		func(id widget.TreeNodeID) bool {
+			return id == "" || !strings.Contains(id, "/")
+		},
+		func(branch bool) fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.TreeNodeID, branch bool, obj fyne.CanvasObject) {
+			label := obj.(*widget.Label)
+			step := plan[planIndex(id)]
+
+			// Пропущенные версии приглушены
+			label.Importance = widget.MediumImportance
+			if step.Skipped {
+				label.Importance = widget.LowImportance
+			}
+
+			if branch {
+				label.SetText(planTitle(planIndex(id), step))
+				return
+			}
+			_, detail, _ := strings.Cut(id, "/")
+			n, _ := strconv.Atoi(detail)
+			label.SetText(planDetails(step)[n])
+		},
+	)
+
+	summary := widget.NewLabel(fmt.Sprintf("Будет создано коммитов: %d, пропущено версий: %d", commits, len(plan)-commits))
+	content := container.NewBorder(summary, nil, nil, nil, tree)
+
+	planDialog := dialog.NewCustomConfirm("План миграции", "Выполнить этот план", "Закрыть", content, func(run bool) {
+		if !run {
+			return
+		}
+		g.runPlan(folders)
+	}, g.window)
+	planDialog.Resize(fyne.NewSize(650, 500))
+	planDialog.Show()
+}
+
+// runPlan выполняет миграцию ровно тех папок, что были показаны в плане
+func (g *GUI) runPlan(folders []gitconverter.FolderInfo) {
+	g.dryRunCheck.SetChecked(false)
+	g.updateConfig()
+
+	ctx := g.beginConversion()
+	g.log("Выполняем план миграции без повторного сканирования...")
+	go g.confirmAndRun(ctx, folders)
+}
+
+// planIndex возвращает номер версии в плане по идентификатору узла дерева
+func planIndex(id widget.TreeNodeID) int {
+	head, _, _ := strings.Cut(id, "/")
+	n, _ := strconv.Atoi(head)
+	return n
+}
+
+// planTitle возвращает заголовок версии в дереве плана
+func planTitle(i int, step gitconverter.PlannedCommit) string {
+	if step.Skipped {
+		return fmt.Sprintf("%d. %s — пропуск: %s", i+1, step.Version, step.Reason)
+	}
+	return fmt.Sprintf("%d. %s — %s", i+1, step.Version, step.Subject)
+}
+
+// planDetails возвращает строки с подробностями версии в плане
+func planDetails(step gitconverter.PlannedCommit) []string {
+	folder := "Папка: " + filepath.Base(step.Path)
+	if step.Skipped {
+		return []string{"Причина: " + step.Reason, folder}
+	}
+	details := []string{
+		"Сообщение: " + step.Subject,
+		fmt.Sprintf("Автор: %s <%s>", step.Author, step.Email),
+		"Дата: " + step.Date.Format("2006-01-02 15:04:05"),
+		fmt.Sprintf("Файлы: всего %d, добавлено %d, изменено %d, удалено %d (%s)",
+			step.Files, step.Added, step.Modified, step.Deleted, gitconverter.FormatSize(step.Bytes)),
+		folder,
+	}
+	if step.Warning != "" {
+		details = append(details, "Предупреждение: "+step.Warning)
+	}
+	if len(step.LFS) > 0 {
+		details = append(details, fmt.Sprintf("Git LFS (%d): %s", len(step.LFS), strings.Join(step.LFS, ", ")))
+	}
+	return details
+}
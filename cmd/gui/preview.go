@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"folder_to_git/pkg/gitconverter"
+)
+
+// Колонки таблицы предпросмотра
+const (
+	colIndex = iota
+	colFolder
+	colVersion
+	colDate
+	colSize
+	colWarnings
+	colCount
+)
+
+var previewHeaders = []string{"№", "Папка", "Версия", "Дата", "Размер", "Предупр."}
+
+// previewState хранит результаты сканирования и виджеты предпросмотра
+type previewState struct {
+	folders    []gitconverter.FolderInfo
+	rows       []int // Порядок отображения строк (индексы в folders)
+	sortBySize bool
+	remembered []gitconverter.ExcludedFolder // Исключения, сохраненные прошлыми запусками
+	warnings   map[string][]string           // Предупреждения последнего запуска по пути папки
+
+	table        *previewTable
+	summaryLabel *widget.Label
+	progress     *widget.ProgressBar
+	cancelButton *widget.Button
+	scanButton   *widget.Button
+	cancel       context.CancelFunc
+}
+
+// setupPreview создает таблицу найденных папок со сводкой и индикатором сканирования
+func (g *GUI) setupPreview() fyne.CanvasObject {
+	p := &g.preview
+
+	p.summaryLabel = widget.NewLabel("Нажмите 'Сканировать', чтобы увидеть найденные папки")
+
+	p.progress = widget.NewProgressBar()
+	p.cancelButton = widget.NewButtonWithIcon("Отмена", theme.CancelIcon(), func() {
+		if p.cancel != nil {
+			p.cancel()
+		}
+	})
+	p.progress.Hide()
+	p.cancelButton.Hide()
+
+	p.scanButton = widget.NewButtonWithIcon("Сканировать", theme.SearchIcon(), g.startScan)
+	styleNativeButton(p.scanButton)
+
+	p.table = newPreviewTable(
+		func() (int, int) { return len(p.rows), colCount },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(g.previewCell(id))
+		},
+	)
+	p.table.ShowHeaderRow = true
+	p.table.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewButton("", nil)
+	}
+	p.table.UpdateHeader = func(id widget.TableCellID, obj fyne.CanvasObject) {
+		button := obj.(*widget.Button)
+		if id.Col < 0 {
+			return
+		}
+		text := previewHeaders[id.Col]
+		if id.Col == colSize && p.sortBySize {
+			text += " ▼"
+		}
+		button.SetText(text)
+		button.OnTapped = func() { g.sortPreview(id.Col == colSize) }
+	}
+	p.table.OnSelected = func(id widget.TableCellID) {
+		p.table.Unselect(id)
+		p.table.focusRow(id.Row)
+		if id.Row >= len(p.rows) {
+			return
+		}
+		switch id.Col {
+		case colVersion:
+			g.editVersion(p.rows[id.Row])
+		case colFolder:
+			g.toggleExclusion(p.rows[id.Row])
+		case colWarnings:
+			g.showFolderWarnings(p.rows[id.Row])
+		}
+	}
+	p.table.onToggle = func(row int) {
+		if row < len(p.rows) {
+			g.toggleExclusion(p.rows[row])
+		}
+	}
+	p.table.SetColumnWidth(colIndex, 50)
+	p.table.SetColumnWidth(colFolder, 220)
+	p.table.SetColumnWidth(colVersion, 90)
+	p.table.SetColumnWidth(colDate, 150)
+	p.table.SetColumnWidth(colSize, 110)
+	p.table.SetColumnWidth(colWarnings, 90)
+
+	return container.NewBorder(
+		container.NewVBox(
+			p.summaryLabel,
+			container.NewBorder(nil, nil, nil, p.cancelButton, p.progress),
+		),
+		nil, nil, nil,
+		container.NewGridWrap(fyne.NewSize(710, 200), p.table),
+	)
+}
+
+// previewCell возвращает текст ячейки таблицы предпросмотра
+func (g *GUI) previewCell(id widget.TableCellID) string {
+	p := &g.preview
+	if id.Row >= len(p.rows) {
+		return ""
+	}
+	index := p.rows[id.Row]
+	folder := p.folders[index]
+
+	switch id.Col {
+	case colIndex:
+		return strconv.Itoa(index + 1)
+	case colFolder:
+		if g.excluded(folder) {
+			return "✗ " + filepath.Base(folder.Path)
+		}
+		return filepath.Base(folder.Path)
+	case colVersion:
+		return folder.Version
+	case colDate:
+		date := time.Unix(folder.CreationTime, 0).Format("2006-01-02 15:04")
+		if folder.TimeFallback {
+			date += " (?)" // Дата определена запасным способом
+		}
+		return date
+	case colSize:
+		return gitconverter.FormatSize(folder.Size)
+	case colWarnings:
+		if n := len(p.warnings[folder.Path]); n > 0 {
+			return fmt.Sprintf("⚠ %d", n)
+		}
+	}
+	return ""
+}
+
+// sortPreview переключает порядок строк: по убыванию размера или в порядке миграции
+func (g *GUI) sortPreview(bySize bool) {
+	p := &g.preview
+	p.sortBySize = bySize
+
+	p.rows = make([]int, len(p.folders))
+	for i := range p.rows {
+		p.rows[i] = i
+	}
+	if bySize {
+		sort.SliceStable(p.rows, func(i, j int) bool {
+			return p.folders[p.rows[i]].Size > p.folders[p.rows[j]].Size
+		})
+	}
+	p.table.Refresh()
+}
+
+// editVersion позволяет вручную исправить версию, извлеченную из имени папки.
+// Исправление запоминается в конфигурации и применяется при конвертации
+func (g *GUI) editVersion(index int) {
+	p := &g.preview
+	folder := &p.folders[index]
+	name := filepath.Base(folder.Path)
+
+	entry := widget.NewEntry()
+	entry.SetText(folder.Version)
+	items := []*widget.FormItem{widget.NewFormItem("Версия", entry)}
+	dialog.ShowForm("Версия папки "+name, "Сохранить", "Отмена", items, func(ok bool) {
+		version := strings.TrimSpace(entry.Text)
+		if !ok || version == "" || version == folder.Version {
+			return
+		}
+		if g.config.VersionOverrides == nil {
+			g.config.VersionOverrides = make(map[string]string)
+		}
+		g.config.VersionOverrides[name] = version
+		folder.Version = version
+		g.log(fmt.Sprintf("Версия папки %s изменена вручную: %s", name, version))
+
+		// Новая версия может поменять порядок папок с противоречивыми датами
+		gitconverter.SortFolders(g.config, p.folders)
+		g.sortPreview(p.sortBySize)
+	}, g.window)
+}
+
+// excluded проверяет, исключена ли папка пользователем сейчас или в прошлых запусках
+func (g *GUI) excluded(folder gitconverter.FolderInfo) bool {
+	for _, entry := range g.config.ExcludeFolders {
+		if entry == folder.Path {
+			return true
+		}
+	}
+	for _, e := range g.preview.remembered {
+		if e.Matches(folder) {
+			return true
+		}
+	}
+	return false
+}
+
+// toggleExclusion исключает папку из конвертации или возвращает ее обратно.
+// Исключение запоминается в репозитории и действует при следующих запусках
+func (g *GUI) toggleExclusion(index int) {
+	p := &g.preview
+	folder := p.folders[index]
+	name := filepath.Base(folder.Path)
+
+	for i, entry := range g.config.ExcludeFolders {
+		if entry == folder.Path {
+			g.config.ExcludeFolders = append(g.config.ExcludeFolders[:i], g.config.ExcludeFolders[i+1:]...)
+			g.log(fmt.Sprintf("Папка %s снова будет перенесена", name))
+			p.table.Refresh()
+			return
+		}
+	}
+	for _, e := range p.remembered {
+		if e.Matches(folder) {
+			g.log(fmt.Sprintf("Папка %s исключена в прошлом запуске, верните ее кнопкой 'Забыть исключения'", name))
+			return
+		}
+	}
+
+	g.config.ExcludeFolders = append(g.config.ExcludeFolders, folder.Path)
+	g.log(fmt.Sprintf("Папка %s исключена из конвертации", name))
+	p.table.Refresh()
+}
+
+// clearExclusions забывает исключения, сохраненные в целевом репозитории и выбранные сейчас
+func (g *GUI) clearExclusions() {
+	g.config.ExcludeFolders = nil
+	g.preview.remembered = nil
+	if target := g.targetEntry.Text; target != "" {
+		if err := gitconverter.ClearExcludedFolders(target); err != nil {
+			g.logError("Ошибка очистки исключений:", err)
+			return
+		}
+	}
+	g.preview.table.Refresh()
+	g.log("Исключенные папки забыты")
+}
+
+// startScan ищет папки с версиями и подсчитывает их размер в фоне
+func (g *GUI) startScan() {
+	if g.sourceEntry.Text == "" {
+		dialog.ShowError(fmt.Errorf("укажите исходную директорию"), g.window)
+		return
+	}
+
+	g.updateConfig()
+	config := g.config
+
+	p := &g.preview
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	// Первая половина индикатора — поиск папок, вторая — подсчет размера
+	config.OnScanProgress = func(done, total int) {
+		if total > 0 {
+			p.progress.SetValue(float64(done) / float64(total) / 2)
+			p.summaryLabel.SetText(fmt.Sprintf("Сканирование %d/%d", done, total))
+		}
+	}
+
+	p.scanButton.Disable()
+	p.progress.SetValue(0)
+	p.progress.Show()
+	p.cancelButton.Show()
+	p.summaryLabel.SetText("Сканирование...")
+
+	go func() {
+		defer func() {
+			cancel()
+			p.progress.Hide()
+			p.cancelButton.Hide()
+			p.scanButton.Enable()
+		}()
+
+		var scan *gitconverter.ScanResult
+		converter, err := gitconverter.New(config)
+		if err == nil {
+			scan, err = converter.Scan()
+		}
+		if scan != nil {
+			g.showPatternSuggestions(scan)
+		}
+		if err != nil {
+			p.summaryLabel.SetText("Папки не найдены")
+			g.logError("Ошибка поиска папок:", err)
+			return
+		}
+		folders := scan.Folders
+
+		err = gitconverter.EnrichFolders(ctx, config, folders, func(done, total int) {
+			p.progress.SetValue(0.5 + float64(done)/float64(total)/2)
+		})
+		if err == context.Canceled {
+			p.summaryLabel.SetText("Сканирование отменено")
+			return
+		}
+		if err != nil {
+			p.summaryLabel.SetText("Ошибка сканирования")
+			g.logError("Ошибка подсчета размера:", err)
+			return
+		}
+
+		p.folders = folders
+		p.remembered = nil
+		if config.TargetDir != "" {
+			p.remembered, _ = gitconverter.ExcludedFolders(config.TargetDir)
+		}
+		g.sortPreview(p.sortBySize)
+
+		summary := gitconverter.SummarizeFolders(folders)
+		text := fmt.Sprintf("%d папок, %s, примерно %s файлов",
+			summary.Folders, gitconverter.FormatSize(summary.Size), formatApproxCount(summary.FileCount))
+		if n := len(scan.Unreadable); n > 0 {
+			text += fmt.Sprintf("\n⚠ Недоступны для чтения (нет прав): %d папок", n)
+		}
+		if n := len(scan.Inversions); n > 0 {
+			first := scan.Inversions[0]
+			text += fmt.Sprintf("\n⚠ Даты противоречат номерам версий (%d пар), например %s раньше %s",
+				n, first.Earlier, first.Later)
+		}
+		p.summaryLabel.SetText(text)
+	}()
+}
+
+// formatApproxCount округляет большое число и разделяет разряды запятыми (96,000)
+func formatApproxCount(n int) string {
+	if n >= 10000 {
+		n = (n + 500) / 1000 * 1000
+	}
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/ncruces/zenity"
+
+	"folder_to_git/pkg/gitconverter"
+)
+
+// importSingleFolder переносит одну выбранную папку следующим коммитом в
+// целевой репозиторий. Версия предлагается по имени папки и может быть изменена
+func (g *GUI) importSingleFolder() {
+	if g.targetEntry.Text == "" {
+		dialog.ShowError(fmt.Errorf("укажите целевую директорию"), g.window)
+		return
+	}
+	path, err := zenity.SelectFile(
+		zenity.Title("Выберите папку для импорта"),
+		zenity.Directory(),
+	)
+	if err != nil || path == "" {
+		return
+	}
+
+	entry := widget.NewEntry()
+	entry.SetText(filepath.Base(path))
+	items := []*widget.FormItem{widget.NewFormItem("Версия", entry)}
+	dialog.ShowForm("Импорт папки "+gitconverter.Printable(filepath.Base(path)), "Импортировать", "Отмена", items, func(ok bool) {
+		version := strings.TrimSpace(entry.Text)
+		if !ok || version == "" {
+			return
+		}
+		g.updateConfig()
+		ctx := g.beginConversion()
+		go g.runSingleMigration(ctx, path, version)
+	}, g.window)
+}
+
+// runSingleMigration переносит папку path с версией version и выводит итоги в лог
+func (g *GUI) runSingleMigration(ctx context.Context, path, version string) {
+	defer g.finishConversion()
+
+	g.log(fmt.Sprintf("Импорт папки %s как версии %s...", gitconverter.Printable(path), gitconverter.Printable(version)))
+	result, err := gitconverter.MigrateSingleContext(ctx, g.config, path, version)
+	if errors.Is(err, context.Canceled) {
+		g.log("Импорт остановлен, рабочая директория возвращена к последнему коммиту")
+		return
+	}
+	if err != nil {
+		g.logError("Ошибка импорта:", err)
+		return
+	}
+
+	folders := []gitconverter.FolderInfo{{Path: path, Version: version}}
+	firstWarning := g.showWarnings(folders, result.Warnings)
+	switch {
+	case g.config.DryRun:
+		g.log("Тестовый режим завершен")
+		if len(result.Plan) > 0 {
+			g.showPlan(result.Plan, folders)
+		}
+	case len(result.Unchanged) > 0:
+		g.log(fmt.Sprintf("Версия %s не отличается от последнего коммита, коммит не создан", gitconverter.Printable(version)))
+	default:
+		g.logSummary(fmt.Sprintf("Папка импортирована как версия %s в: %s", gitconverter.Printable(version), g.config.TargetDir),
+			len(result.Warnings), firstWarning)
+	}
+}
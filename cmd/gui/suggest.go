@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"folder_to_git/pkg/gitconverter"
+)
+
+// Сколько шаблонов и версий каждого показывать под полем шаблона версии
+const (
+	maxSuggestions     = 3
+	suggestionExamples = 3
+)
+
+// showPatternSuggestions показывает под полем шаблона версии шаблоны,
+// которые различают версии найденных папок лучше текущего. Кнопка рядом с
+// шаблоном подставляет его в поле
+func (g *GUI) showPatternSuggestions(scan *gitconverter.ScanResult) {
+	g.suggestBox.RemoveAll()
+	suggestions := scan.SuggestPatterns()
+	if len(suggestions) == 0 {
+		g.suggestBox.Hide()
+		return
+	}
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+
+	names := scan.SampleNames()
+	g.suggestBox.Add(widget.NewLabel(fmt.Sprintf("Своя версия найдена у %d папок из %d, попробуйте шаблон:", len(scan.Folders), len(names))))
+	for _, s := range suggestions {
+		pattern := s.Pattern
+		var examples []string
+		for _, name := range names {
+			if version, ok := s.Versions[name]; ok && len(examples) < suggestionExamples {
+				examples = append(examples, gitconverter.Printable(version))
+			}
+		}
+		label := widget.NewLabel(fmt.Sprintf("%s — %s (%d из %d: %s)",
+			pattern, s.Description, s.Matched, len(names), strings.Join(examples, ", ")))
+		label.Wrapping = fyne.TextWrapWord
+		apply := widget.NewButton("Применить", func() {
+			g.extractEntry.SetText(pattern)
+			g.suggestBox.Hide()
+			g.log("Шаблон версии заменен на " + pattern + ", нажмите 'Сканировать', чтобы проверить")
+		})
+		g.suggestBox.Add(container.NewBorder(nil, nil, nil, apply, label))
+	}
+	g.suggestBox.Show()
+}
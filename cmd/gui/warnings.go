@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"folder_to_git/pkg/gitconverter"
+)
+
+// showWarnings выводит предупреждения запуска в лог и показывает их число
+// в таблице папок. Возвращает номер строки лога с первым предупреждением
+// или -1, если предупреждений нет
+func (g *GUI) showWarnings(folders []gitconverter.FolderInfo, warnings []gitconverter.FolderWarning) int {
+	p := &g.preview
+	p.warnings = make(map[string][]string)
+
+	first := -1
+	for _, w := range warnings {
+		if first < 0 {
+			first = strings.Count(g.logText.Text, "\n") + 1
+		}
+		g.log(fmt.Sprintf("ПРЕДУПРЕЖДЕНИЕ [%s]: %s", gitconverter.Printable(w.Version), w.Message))
+		p.warnings[w.Path] = append(p.warnings[w.Path], w.Message)
+	}
+
+	// Таблица показывает папки именно этого запуска
+	p.folders = folders
+	g.sortPreview(p.sortBySize)
+	return first
+}
+
+// showFolderWarnings открывает список предупреждений папки
+func (g *GUI) showFolderWarnings(index int) {
+	folder := g.preview.folders[index]
+	warnings := g.preview.warnings[folder.Path]
+	if len(warnings) == 0 {
+		return
+	}
+
+	text := widget.NewLabel(strings.Join(warnings, "\n"))
+	text.Wrapping = fyne.TextWrapWord
+	scroll := container.NewVScroll(text)
+	scroll.SetMinSize(fyne.NewSize(560, 240))
+
+	title := fmt.Sprintf("Предупреждения: %s (версия %s)", filepath.Base(folder.Path), folder.Version)
+	dialog.ShowCustom(title, "Закрыть", scroll, g.window)
+}
+
+// logSummary сообщает об успешном завершении. Если были предупреждения,
+// итог показывает их число и позволяет перейти к первому из них в логе
+func (g *GUI) logSummary(msg string, warnings, firstLine int) {
+	if warnings == 0 {
+		g.logSuccess(msg)
+		return
+	}
+
+	g.log("УСПЕХ: " + msg)
+	text := fmt.Sprintf("%s\nПредупреждений: %d", msg, warnings)
+	dialog.ShowCustomConfirm("Успех", "Перейти к первому предупреждению", "Закрыть",
+		widget.NewLabel(text), func(jump bool) {
+			if jump {
+				g.scrollLogTo(firstLine)
+			}
+		}, g.window)
+}
+
+// scrollLogTo прокручивает лог к строке line. Высота строки оценивается
+// по общей высоте текста, поэтому при переносе длинных строк позиция приблизительна
+func (g *GUI) scrollLogTo(line int) {
+	if line < 0 {
+		return
+	}
+	total := strings.Count(g.logText.Text, "\n") + 1
+	height := g.logText.MinSize().Height
+	g.logText.CursorRow = line
+	g.logScroll.Offset = fyne.NewPos(0, height*float32(line)/float32(total))
+	g.logScroll.Refresh()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"math"
+
+	"fyne.io/fyne/v2"
+)
+
+// Ключи настроек для геометрии окна
+const (
+	prefWindowWidth  = "window.width"
+	prefWindowHeight = "window.height"
+)
+
+// Размер окна по умолчанию при первом запуске
+var defaultWindowSize = fyne.NewSize(700, 750)
+
+// maxWindowSide — грубый верхний предел сохраненного размера. Размер экрана
+// Fyne 2.5 не сообщает, поэтому это не размер монитора: окно, растянутое на
+// отключенном теперь мониторе, может оказаться больше экрана, и уменьшать его
+// приходится оконному менеджеру
+const maxWindowSide = 4096
+
+// restoreWindowSize восстанавливает сохраненный размер окна и сохраняет
+// текущий размер при закрытии окна и при выходе из приложения. Положение окна
+// не запоминается: Fyne 2.5 не позволяет ни узнать, ни задать его, и окно
+// открывается там, где его разместит система
+func restoreWindowSize(a fyne.App, window fyne.Window) {
+	prefs := a.Preferences()
+	width := prefs.FloatWithFallback(prefWindowWidth, float64(defaultWindowSize.Width))
+	height := prefs.FloatWithFallback(prefWindowHeight, float64(defaultWindowSize.Height))
+	window.Resize(clampWindowSize(fyne.NewSize(float32(width), float32(height)), window.Content().MinSize()))
+
+	// На macOS выход через меню не вызывает перехват закрытия окна
+	a.Lifecycle().SetOnStopped(func() { saveWindowSize(window, prefs) })
+	window.SetCloseIntercept(func() {
+		saveWindowSize(window, prefs)
+		window.Close()
+	})
+}
+
+// saveWindowSize запоминает текущий размер окна
+func saveWindowSize(window fyne.Window, prefs fyne.Preferences) {
+	size := window.Canvas().Size()
+	if size.Width > 0 && size.Height > 0 {
+		prefs.SetFloat(prefWindowWidth, float64(size.Width))
+		prefs.SetFloat(prefWindowHeight, float64(size.Height))
+	}
+}
+
+// clampWindowSize приводит сохраненный размер к пределам от минимального
+// размера содержимого до maxWindowSide. Поврежденное значение заменяется
+// размером по умолчанию
+func clampWindowSize(size, min fyne.Size) fyne.Size {
+	clamp := func(v, lo, fallback float32) float32 {
+		if math.IsNaN(float64(v)) || v <= 0 {
+			v = fallback
+		}
+		return float32(math.Max(float64(lo), math.Min(float64(v), maxWindowSide)))
+	}
+	return fyne.NewSize(
+		clamp(size.Width, min.Width, defaultWindowSize.Width),
+		clamp(size.Height, min.Height, defaultWindowSize.Height),
+	)
+}
@@ -0,0 +1,68 @@
+package main
+
+import "math"
+
+// Пиктограмма описывается в координатах холста 1024x1024 и масштабируется
+// под любой размер, поэтому все экспортируемые размеры остаются четкими
+
+// shape — фигура, заданная функцией знакового расстояния (отрицательно внутри)
+type shape func(x, y float64) float64
+
+// circle возвращает круг с центром (cx, cy) и радиусом r
+func circle(cx, cy, r float64) shape {
+	return func(x, y float64) float64 {
+		return math.Hypot(x-cx, y-cy) - r
+	}
+}
+
+// roundedRect возвращает прямоугольник со скругленными углами радиуса r
+func roundedRect(x0, y0, x1, y1, r float64) shape {
+	cx, cy := (x0+x1)/2, (y0+y1)/2
+	hw, hh := (x1-x0)/2-r, (y1-y0)/2-r
+	return func(x, y float64) float64 {
+		dx := math.Abs(x-cx) - hw
+		dy := math.Abs(y-cy) - hh
+		outside := math.Hypot(math.Max(dx, 0), math.Max(dy, 0))
+		inside := math.Min(math.Max(dx, dy), 0)
+		return outside + inside - r
+	}
+}
+
+// line возвращает отрезок толщины width со скругленными концами
+func line(x0, y0, x1, y1, width float64) shape {
+	return func(x, y float64) float64 {
+		vx, vy := x1-x0, y1-y0
+		t := clamp(((x-x0)*vx+(y-y0)*vy)/(vx*vx+vy*vy), 0, 1)
+		return math.Hypot(x-(x0+vx*t), y-(y0+vy*t)) - width/2
+	}
+}
+
+// union объединяет фигуры
+func union(shapes ...shape) shape {
+	return func(x, y float64) float64 {
+		d := math.Inf(1)
+		for _, s := range shapes {
+			d = math.Min(d, s(x, y))
+		}
+		return d
+	}
+}
+
+// folderToGitGlyph — папка, стрелка и граф коммитов Git из трех узлов
+var folderToGitGlyph = union(
+	// Папка: вкладка и корпус
+	roundedRect(240, 392, 350, 450, 16),
+	roundedRect(240, 420, 460, 620, 22),
+
+	// Стрелка из папки в граф
+	line(490, 520, 590, 520, 24),
+	line(592, 520, 555, 483, 24),
+	line(592, 520, 555, 557, 24),
+
+	// Граф коммитов: ствол, ветка и три узла
+	line(680, 395, 680, 645, 22),
+	line(680, 455, 780, 520, 22),
+	circle(680, 395, 36),
+	circle(680, 645, 36),
+	circle(780, 520, 36),
+)
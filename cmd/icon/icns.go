@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// icnsEntry — тип элемента ICNS и размер PNG-изображения в нем
+type icnsEntry struct {
+	Type string
+	Size int
+}
+
+// icnsEntries перечисляет элементы с PNG-данными, которые понимает macOS
+var icnsEntries = []icnsEntry{
+	{"icp4", 16},
+	{"icp5", 32},
+	{"icp6", 64},
+	{"ic07", 128},
+	{"ic08", 256},
+	{"ic09", 512},
+	{"ic10", 1024}, // 512@2x
+	{"ic11", 32},   // 16@2x
+	{"ic12", 64},   // 32@2x
+	{"ic13", 256},  // 128@2x
+	{"ic14", 512},  // 256@2x
+}
+
+// encodeICNS собирает контейнер ICNS из PNG-изображений нужных размеров
+func encodeICNS(pngs map[int][]byte) ([]byte, error) {
+	var body bytes.Buffer
+	for _, entry := range icnsEntries {
+		data, ok := pngs[entry.Size]
+		if !ok {
+			return nil, fmt.Errorf("нет изображения %dx%d для %s", entry.Size, entry.Size, entry.Type)
+		}
+		body.WriteString(entry.Type)
+		binary.Write(&body, binary.BigEndian, uint32(8+len(data)))
+		body.Write(data)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("icns")
+	binary.Write(&out, binary.BigEndian, uint32(8+body.Len()))
+	out.Write(body.Bytes())
+	return out.Bytes(), nil
+}
+
+// parseICNS разбирает контейнер ICNS и возвращает данные элементов по типам
+func parseICNS(data []byte) (map[string][]byte, error) {
+	if len(data) < 8 || string(data[:4]) != "icns" {
+		return nil, fmt.Errorf("неверная сигнатура ICNS")
+	}
+	if total := binary.BigEndian.Uint32(data[4:8]); int(total) != len(data) {
+		return nil, fmt.Errorf("длина ICNS %d не совпадает с размером данных %d", total, len(data))
+	}
+
+	entries := make(map[string][]byte)
+	for offset := 8; offset < len(data); {
+		if offset+8 > len(data) {
+			return nil, fmt.Errorf("обрезанный заголовок элемента ICNS")
+		}
+		kind := string(data[offset : offset+4])
+		length := int(binary.BigEndian.Uint32(data[offset+4 : offset+8]))
+		if length < 8 || offset+length > len(data) {
+			return nil, fmt.Errorf("неверная длина элемента ICNS %s", kind)
+		}
+		entries[kind] = data[offset+8 : offset+length]
+		offset += length
+	}
+	return entries, nil
+}
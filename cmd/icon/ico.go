@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// icoSizes — размеры изображений в ICO (256 — максимум формата)
+var icoSizes = []int{16, 32, 48, 64, 128, 256}
+
+// icoDirEntry — запись каталога изображений ICO
+type icoDirEntry struct {
+	Width, Height uint8 // 0 означает 256
+	ColorCount    uint8
+	Reserved      uint8
+	Planes        uint16
+	BitCount      uint16
+	BytesInRes    uint32
+	ImageOffset   uint32
+}
+
+// encodeICO собирает многоразмерный ICO с PNG-изображениями внутри
+func encodeICO(pngs map[int][]byte) ([]byte, error) {
+	var out bytes.Buffer
+	binary.Write(&out, binary.LittleEndian, [3]uint16{0, 1, uint16(len(icoSizes))})
+
+	offset := 6 + 16*len(icoSizes)
+	for _, size := range icoSizes {
+		data, ok := pngs[size]
+		if !ok {
+			return nil, fmt.Errorf("нет изображения %dx%d для ICO", size, size)
+		}
+		binary.Write(&out, binary.LittleEndian, icoDirEntry{
+			Width:       uint8(size % 256),
+			Height:      uint8(size % 256),
+			Planes:      1,
+			BitCount:    32,
+			BytesInRes:  uint32(len(data)),
+			ImageOffset: uint32(offset),
+		})
+		offset += len(data)
+	}
+	for _, size := range icoSizes {
+		out.Write(pngs[size])
+	}
+	return out.Bytes(), nil
+}
+
+// parseICO разбирает ICO и возвращает данные изображений по размерам
+func parseICO(data []byte) (map[int][]byte, error) {
+	reader := bytes.NewReader(data)
+	var header [3]uint16
+	if err := binary.Read(reader, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("обрезанный заголовок ICO: %v", err)
+	}
+	if header[0] != 0 || header[1] != 1 {
+		return nil, fmt.Errorf("неверная сигнатура ICO")
+	}
+
+	images := make(map[int][]byte)
+	for i := 0; i < int(header[2]); i++ {
+		var entry icoDirEntry
+		if err := binary.Read(reader, binary.LittleEndian, &entry); err != nil {
+			return nil, fmt.Errorf("обрезанный каталог ICO: %v", err)
+		}
+		end := int(entry.ImageOffset) + int(entry.BytesInRes)
+		if end > len(data) {
+			return nil, fmt.Errorf("изображение %d выходит за пределы файла ICO", i)
+		}
+		size := int(entry.Width)
+		if size == 0 {
+			size = 256
+		}
+		images[size] = data[entry.ImageOffset:end]
+	}
+	return images, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"folder_to_git/internal/palette"
+)
+
+// testImages рисует иконку и уменьшает ее до размеров sizes
+func testImages(t *testing.T, sizes []int) map[int][]byte {
+	t.Helper()
+	master := renderIcon(iconOptions{Size: masterSize, Color: palette.Primary, Gradient: true, Shadow: true, Glyph: true})
+	return renderSizes(master, sizes)
+}
+
+// decodeSize декодирует PNG и возвращает его размеры
+func decodeSize(t *testing.T, data []byte) image.Point {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("PNG не декодируется: %v", err)
+	}
+	return img.Bounds().Size()
+}
+
+func TestICNSRoundTrip(t *testing.T) {
+	pngs := testImages(t, icnsSizes())
+	data, err := encodeICNS(pngs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := parseICNS(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(icnsEntries) {
+		t.Errorf("элементов ICNS %d, ожидалось %d", len(entries), len(icnsEntries))
+	}
+	for _, entry := range icnsEntries {
+		got, ok := entries[entry.Type]
+		if !ok {
+			t.Errorf("нет элемента %s", entry.Type)
+			continue
+		}
+		if !bytes.Equal(got, pngs[entry.Size]) {
+			t.Errorf("%s: данные не совпадают с исходным PNG %dx%d", entry.Type, entry.Size, entry.Size)
+		}
+		if size := decodeSize(t, got); size != image.Pt(entry.Size, entry.Size) {
+			t.Errorf("%s: размер %v, ожидался %dx%d", entry.Type, size, entry.Size, entry.Size)
+		}
+	}
+	if err := verifyICNS(data); err != nil {
+		t.Errorf("verifyICNS: %v", err)
+	}
+}
+
+func TestICORoundTrip(t *testing.T) {
+	pngs := testImages(t, icoSizes)
+	data, err := encodeICO(pngs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	images, err := parseICO(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != len(icoSizes) {
+		t.Errorf("изображений ICO %d, ожидалось %d", len(images), len(icoSizes))
+	}
+	for _, size := range icoSizes {
+		got, ok := images[size]
+		if !ok {
+			t.Errorf("нет изображения %dx%d", size, size)
+			continue
+		}
+		if !bytes.Equal(got, pngs[size]) {
+			t.Errorf("%dx%d: данные не совпадают с исходным PNG", size, size)
+		}
+		if s := decodeSize(t, got); s != image.Pt(size, size) {
+			t.Errorf("%dx%d: размер %v", size, size, s)
+		}
+	}
+	if err := verifyICO(data); err != nil {
+		t.Errorf("verifyICO: %v", err)
+	}
+}
+
+func TestEncodeMissingSize(t *testing.T) {
+	pngs := testImages(t, []int{16, 32})
+	if _, err := encodeICNS(pngs); err == nil {
+		t.Error("ICNS собран без части размеров")
+	}
+	if _, err := encodeICO(pngs); err == nil {
+		t.Error("ICO собран без части размеров")
+	}
+}
+
+func TestParseCorrupted(t *testing.T) {
+	pngs := testImages(t, append(icnsSizes(), icoSizes...))
+	icns, err := encodeICNS(pngs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ico, err := encodeICO(pngs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string][]byte{
+		"пусто":            nil,
+		"сигнатура":        append([]byte("icnX"), icns[4:]...),
+		"обрезан":          icns[:len(icns)-10],
+		"только заголовок": icns[:8],
+	} {
+		if _, err := parseICNS(data); err == nil {
+			t.Errorf("ICNS %s: ошибка не обнаружена", name)
+		}
+	}
+	for name, data := range map[string][]byte{
+		"пусто":     nil,
+		"сигнатура": append([]byte{0, 0, 2, 0}, ico[4:]...),
+		"обрезан":   ico[:len(ico)-10],
+		"каталог":   ico[:10],
+	} {
+		if _, err := parseICO(data); err == nil {
+			t.Errorf("ICO %s: ошибка не обнаружена", name)
+		}
+	}
+}
@@ -1,40 +1,214 @@
 package main
 
 import (
+	"bytes"
+	"flag"
+	"fmt"
 	"image"
 	"image/color"
-	"image/draw"
 	"image/png"
+	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"folder_to_git/internal/palette"
 )
 
+// setSizes — стандартный набор размеров иконки
+var setSizes = []int{16, 32, 64, 128, 256, 512, 1024}
+
+// masterSize — размер эталонного изображения, из которого уменьшаются остальные
+const masterSize = 1024
+
 func main() {
-	// Создаем новое изображение 1024x1024 (для Retina дисплеев)
-	img := image.NewRGBA(image.Rect(0, 0, 1024, 1024))
-
-	// Заполняем фон белым цветом
-	draw.Draw(img, img.Bounds(), &image.Uniform{color.White}, image.Point{}, draw.Src)
-
-	// Создаем синий круг
-	center := image.Point{512, 512}
-	radius := 400
-	blue := color.RGBA{0, 122, 255, 255} // iOS-style blue
-
-	for y := 0; y < 1024; y++ {
-		for x := 0; x < 1024; x++ {
-			dx := float64(x - center.X)
-			dy := float64(y - center.Y)
-			d := dx*dx + dy*dy
-			if d < float64(radius*radius) {
-				img.Set(x, y, blue)
+	size := flag.Int("size", 1024, "размер PNG-иконки в пикселях")
+	colorHex := flag.String("color", hexColor(palette.Primary), "основной цвет в формате #RRGGBB")
+	output := flag.String("output", "Icon.png", "путь к выходному PNG-файлу; остальные форматы пишутся рядом")
+	setDir := flag.String("set-dir", "", "директория для набора размеров (по умолчанию icons рядом с -output)")
+	formats := flag.String("formats", "png,set,icns,ico", "форматы через запятую: png, set, icns, ico")
+	force := flag.Bool("force", false, "перезаписывать существующие файлы")
+	gradient := flag.Bool("gradient", true, "рисовать радиальный градиент")
+	shadow := flag.Bool("shadow", true, "рисовать тень под кругом")
+	glyph := flag.Bool("glyph", true, "рисовать пиктограмму папки и графа коммитов")
+	flag.Parse()
+
+	if *size <= 0 {
+		log.Fatalf("некорректный размер: %d", *size)
+	}
+
+	c, err := parseHexColor(*colorHex)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	opts := iconOptions{
+		Size:     masterSize,
+		Color:    c,
+		Gradient: *gradient,
+		Shadow:   *shadow,
+		Glyph:    *glyph,
+	}
+	if *size > masterSize {
+		opts.Size = *size
+	}
+	master := renderIcon(opts)
+
+	dir := filepath.Dir(*output)
+	if *setDir == "" {
+		*setDir = filepath.Join(dir, "icons")
+	}
+	w := &writer{force: *force}
+
+	for _, format := range strings.Split(*formats, ",") {
+		switch strings.TrimSpace(format) {
+		case "png":
+			data, err := encodePNG(scaleIcon(master, *size))
+			if err != nil {
+				log.Fatal(err)
+			}
+			w.write(*output, data)
+		case "set":
+			if err := os.MkdirAll(*setDir, 0755); err != nil {
+				log.Fatalf("ошибка создания директории %s: %v", *setDir, err)
 			}
+			pngs := renderSizes(master, setSizes)
+			for _, s := range setSizes {
+				w.write(filepath.Join(*setDir, fmt.Sprintf("icon_%d.png", s)), pngs[s])
+			}
+		case "icns":
+			data, err := encodeICNS(renderSizes(master, icnsSizes()))
+			if err != nil {
+				log.Fatalf("ошибка сборки ICNS: %v", err)
+			}
+			if err := verifyICNS(data); err != nil {
+				log.Fatalf("проверка ICNS не пройдена: %v", err)
+			}
+			w.write(filepath.Join(dir, "Icon.icns"), data)
+		case "ico":
+			data, err := encodeICO(renderSizes(master, icoSizes))
+			if err != nil {
+				log.Fatalf("ошибка сборки ICO: %v", err)
+			}
+			if err := verifyICO(data); err != nil {
+				log.Fatalf("проверка ICO не пройдена: %v", err)
+			}
+			w.write(filepath.Join(dir, "icon.ico"), data)
+		case "":
+		default:
+			log.Fatalf("неизвестный формат: %s", format)
+		}
+	}
+}
+
+// encodePNG кодирует изображение в PNG
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		b := img.Bounds()
+		return nil, fmt.Errorf("ошибка кодирования PNG %dx%d: %v", b.Dx(), b.Dy(), err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderSizes уменьшает эталонное изображение до всех перечисленных размеров
+func renderSizes(master image.Image, sizes []int) map[int][]byte {
+	pngs := make(map[int][]byte)
+	for _, size := range sizes {
+		if _, ok := pngs[size]; ok {
+			continue
+		}
+		data, err := encodePNG(scaleIcon(master, size))
+		if err != nil {
+			log.Fatal(err)
 		}
+		pngs[size] = data
 	}
+	return pngs
+}
 
-	// Создаем файл для сохранения
-	f, _ := os.Create("Icon.png")
-	defer f.Close()
+// icnsSizes возвращает размеры изображений, нужные для ICNS
+func icnsSizes() []int {
+	var sizes []int
+	for _, entry := range icnsEntries {
+		sizes = append(sizes, entry.Size)
+	}
+	return sizes
+}
+
+// verifyICNS разбирает собранный ICNS и проверяет размеры всех изображений
+func verifyICNS(data []byte) error {
+	entries, err := parseICNS(data)
+	if err != nil {
+		return err
+	}
+	for _, entry := range icnsEntries {
+		if err := checkPNGSize(entries[entry.Type], entry.Size); err != nil {
+			return fmt.Errorf("%s: %v", entry.Type, err)
+		}
+	}
+	return nil
+}
+
+// verifyICO разбирает собранный ICO и проверяет размеры всех изображений
+func verifyICO(data []byte) error {
+	images, err := parseICO(data)
+	if err != nil {
+		return err
+	}
+	for _, size := range icoSizes {
+		if err := checkPNGSize(images[size], size); err != nil {
+			return fmt.Errorf("%dx%d: %v", size, size, err)
+		}
+	}
+	return nil
+}
+
+// checkPNGSize проверяет, что данные — PNG заданного размера
+func checkPNGSize(data []byte, size int) error {
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if cfg.Width != size || cfg.Height != size {
+		return fmt.Errorf("размер %dx%d, ожидался %dx%d", cfg.Width, cfg.Height, size, size)
+	}
+	return nil
+}
+
+// writer записывает выходные файлы, не перезаписывая существующие без force
+type writer struct {
+	force bool
+}
+
+// write записывает данные в файл, завершая программу при ошибке
+func (w *writer) write(path string, data []byte) {
+	if !w.force {
+		if _, err := os.Stat(path); err == nil {
+			log.Fatalf("файл %s уже существует, используйте -force для перезаписи", path)
+		}
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Fatalf("ошибка записи %s: %v", path, err)
+	}
+	log.Printf("Записан %s", path)
+}
+
+// parseHexColor разбирает цвет в формате #RRGGBB
+func parseHexColor(s string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) != 6 {
+		return color.NRGBA{}, fmt.Errorf("некорректный цвет %q: ожидается #RRGGBB", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("некорректный цвет %q: %v", s, err)
+	}
+	return color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
+}
 
-	// Сохраняем изображение в PNG
-	png.Encode(f, img)
+// hexColor форматирует цвет в виде #RRGGBB
+func hexColor(c color.NRGBA) string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
 }
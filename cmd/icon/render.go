@@ -0,0 +1,107 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"folder_to_git/internal/palette"
+)
+
+// iconOptions описывает параметры отрисовки иконки
+type iconOptions struct {
+	Size     int
+	Color    color.NRGBA
+	Gradient bool // Радиальный градиент от светлого центра к краю
+	Shadow   bool // Мягкая тень под кругом
+	Glyph    bool // Пиктограмма "папка → git" поверх круга
+}
+
+// renderIcon рисует круг с гладкими краями и пиктограммой на белом фоне.
+// Покрытие пикселя считается аналитически по расстоянию до границы,
+// поэтому результат детерминирован и не зависит от размера
+func renderIcon(opts iconOptions) *image.NRGBA {
+	size := opts.Size
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+
+	s := float64(size)
+	cx, cy := s/2, s/2
+	radius := s * 400 / 1024
+
+	// Параметры тени: смещение вниз и ширина размытия
+	shadowOffset := s * 12 / 1024
+	shadowBlur := s * 24 / 1024
+
+	// Центр градиента смещен вверх-влево, как будто свет падает сверху
+	gx, gy := cx-radius*0.35, cy-radius*0.35
+
+	// Масштаб от координат пиктограммы (1024x1024) к пикселям
+	scale := s / 1024
+	glyphR, glyphG, glyphB := float64(palette.Glyph.R)/255, float64(palette.Glyph.G)/255, float64(palette.Glyph.B)/255
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+
+			// Начинаем с белого фона
+			r, g, b := 1.0, 1.0, 1.0
+
+			if opts.Shadow {
+				d := math.Hypot(px-cx, py-cy-shadowOffset) - radius
+				alpha := 0.25 * (1 - smoothstep(-shadowBlur, shadowBlur, d))
+				r, g, b = blend(r, g, b, 0, 0, 0, alpha)
+			}
+
+			d := math.Hypot(px-cx, py-cy)
+			coverage := clamp(radius-d+0.5, 0, 1)
+			if coverage > 0 {
+				cr, cg, cb := float64(opts.Color.R)/255, float64(opts.Color.G)/255, float64(opts.Color.B)/255
+				if opts.Gradient {
+					t := clamp(math.Hypot(px-gx, py-gy)/(radius*1.6), 0, 1)
+					// Светлее в центре градиента, темнее к краю
+					light := 0.25 * (1 - t)
+					dark := 0.15 * t
+					cr = cr + (1-cr)*light - cr*dark
+					cg = cg + (1-cg)*light - cg*dark
+					cb = cb + (1-cb)*light - cb*dark
+				}
+				r, g, b = blend(r, g, b, cr, cg, cb, coverage)
+			}
+
+			if opts.Glyph {
+				d := folderToGitGlyph(px/scale, py/scale) * scale
+				if coverage := clamp(0.5-d, 0, 1); coverage > 0 {
+					r, g, b = blend(r, g, b, glyphR, glyphG, glyphB, coverage)
+				}
+			}
+
+			img.SetNRGBA(x, y, color.NRGBA{
+				R: toByte(r),
+				G: toByte(g),
+				B: toByte(b),
+				A: 255,
+			})
+		}
+	}
+
+	return img
+}
+
+// blend накладывает цвет (r2, g2, b2) с прозрачностью alpha поверх (r1, g1, b1)
+func blend(r1, g1, b1, r2, g2, b2, alpha float64) (float64, float64, float64) {
+	return r1 + (r2-r1)*alpha, g1 + (g2-g1)*alpha, b1 + (b2-b1)*alpha
+}
+
+// smoothstep плавно интерполирует от 0 до 1 между edge0 и edge1
+func smoothstep(edge0, edge1, x float64) float64 {
+	t := clamp((x-edge0)/(edge1-edge0), 0, 1)
+	return t * t * (3 - 2*t)
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
+
+func toByte(v float64) uint8 {
+	return uint8(math.Round(clamp(v, 0, 1) * 255))
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// scaleIcon уменьшает изображение до size x size фильтром Catmull-Rom,
+// который в отличие от ближайшего соседа сохраняет гладкие края
+func scaleIcon(src image.Image, size int) image.Image {
+	if src.Bounds().Dx() == size && src.Bounds().Dy() == size {
+		return src
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+	return dst
+}
@@ -0,0 +1,2 @@
+// Package diskspace определяет свободное место на диске средствами ОС
+package diskspace
@@ -0,0 +1,10 @@
+//go:build !darwin && !linux && !windows
+
+package diskspace
+
+import "errors"
+
+// Free не поддерживается на этой платформе
+func Free(path string) (uint64, error) {
+	return 0, errors.New("определение свободного места не поддерживается")
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package diskspace
+
+import "golang.org/x/sys/windows"
+
+// Free возвращает количество байт, доступных текущему пользователю
+func Free(path string) (uint64, error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var free uint64
+	if err := windows.GetDiskFreeSpaceEx(pathPtr, &free, nil, nil); err != nil {
+		return 0, err
+	}
+	return free, nil
+}
@@ -0,0 +1,11 @@
+// Package palette содержит фирменные цвета приложения, общие для GUI и генератора иконки
+package palette
+
+import "image/color"
+
+var (
+	// Primary — основной синий цвет (iOS-style blue)
+	Primary = color.NRGBA{R: 0, G: 122, B: 255, A: 255}
+	// Glyph — цвет пиктограммы поверх основного цвета
+	Glyph = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+)
@@ -0,0 +1,47 @@
+// Package ratelimit ограничивает скорость расхода по алгоритму маркерной корзины
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Bucket ограничивает скорость расхода: rate единиц в секунду с запасом
+// не больше burst. Безопасен для одновременного использования из нескольких горутин
+type Bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// New возвращает полный запас на rate единиц в секунду
+func New(rate float64) *Bucket {
+	return &Bucket{rate: rate, burst: rate, tokens: rate, last: time.Now()}
+}
+
+// Wait расходует n единиц и ждет, если запас исчерпан. Долг допускается,
+// поэтому крупный запрос не блокируется навсегда, а отрабатывается паузой
+func (b *Bucket) Wait(ctx context.Context, n float64) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens -= n
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,149 @@
+// Package versionorder сравнивает строки версий: по правилам SemVer и в
+// естественном порядке, где числовые части сравниваются как числа
+package versionorder
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Semver — разобранная семантическая версия
+type Semver struct {
+	core       [3]uint64
+	prerelease []string
+}
+
+// ParseSemver разбирает версию вида v1.2.3-rc.1+build. Префикс v и
+// недостающие младшие компоненты допускаются (v1.4 — это 1.4.0),
+// метаданные сборки после + не учитываются
+func ParseSemver(version string) (Semver, bool) {
+	var v Semver
+	s := strings.TrimPrefix(strings.TrimPrefix(version, "v"), "V")
+	s, _, _ = strings.Cut(s, "+")
+	core, pre, hasPre := strings.Cut(s, "-")
+
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return v, false
+	}
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil || part == "" || part[0] == '+' {
+			return v, false
+		}
+		v.core[i] = n
+	}
+
+	if hasPre {
+		if pre == "" {
+			return v, false
+		}
+		v.prerelease = strings.Split(pre, ".")
+		for _, id := range v.prerelease {
+			if id == "" {
+				return v, false
+			}
+		}
+	}
+	return v, true
+}
+
+// CompareSemver сравнивает версии по правилам SemVer: сначала основные
+// компоненты, затем пререлиз, который меньше версии без него
+func CompareSemver(a, b Semver) int {
+	for i := range a.core {
+		if a.core[i] != b.core[i] {
+			if a.core[i] < b.core[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case len(a.prerelease) == 0 && len(b.prerelease) == 0:
+		return 0
+	case len(a.prerelease) == 0:
+		return 1
+	case len(b.prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a.prerelease) && i < len(b.prerelease); i++ {
+		if c := comparePrereleaseID(a.prerelease[i], b.prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a.prerelease) - len(b.prerelease)
+}
+
+// comparePrereleaseID сравнивает части пререлиза: числовые сравниваются
+// как числа и меньше буквенных, буквенные — как строки
+func comparePrereleaseID(a, b string) int {
+	na, errA := strconv.ParseUint(a, 10, 64)
+	nb, errB := strconv.ParseUint(b, 10, 64)
+	switch {
+	case errA == nil && errB == nil:
+		if na == nb {
+			return 0
+		}
+		if na < nb {
+			return -1
+		}
+		return 1
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// Natural сравнивает версии в естественном порядке: числовые части
+// сравниваются как числа, остальные — как строки
+func Natural(a, b string) int {
+	for a != "" && b != "" {
+		ta, restA := versionToken(a)
+		tb, restB := versionToken(b)
+		if c := compareVersionTokens(ta, tb); c != 0 {
+			return c
+		}
+		a, b = restA, restB
+	}
+	return strings.Compare(a, b)
+}
+
+// versionToken отделяет от строки первую группу цифр или не-цифр
+func versionToken(s string) (string, string) {
+	digit := isDigit(s[0])
+	i := 1
+	for i < len(s) && isDigit(s[i]) == digit {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// compareVersionTokens сравнивает части версий; числа меньше любых букв
+func compareVersionTokens(a, b string) int {
+	da, db := isDigit(a[0]), isDigit(b[0])
+	switch {
+	case da && db:
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	case da:
+		return -1
+	case db:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,104 @@
+package versionorder
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseSemver(t *testing.T) {
+	tests := []struct {
+		version string
+		want    Semver
+		ok      bool
+	}{
+		{"1.2.3", Semver{core: [3]uint64{1, 2, 3}}, true},
+		{"v1.4", Semver{core: [3]uint64{1, 4, 0}}, true},
+		{"V2", Semver{core: [3]uint64{2, 0, 0}}, true},
+		{"v1.0-rc1", Semver{core: [3]uint64{1, 0, 0}, prerelease: []string{"rc1"}}, true},
+		{"1.0.0-rc.1+build.5", Semver{core: [3]uint64{1, 0, 0}, prerelease: []string{"rc", "1"}}, true},
+		{"1.2.3.4", Semver{}, false},
+		{"1..2", Semver{}, false},
+		{"1.0-", Semver{}, false},
+		{"1.0-rc..1", Semver{}, false},
+		{"1.+2", Semver{}, false},
+		{"nightly", Semver{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseSemver(tt.version)
+		if ok != tt.ok || (ok && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("ParseSemver(%q) = %+v, %v; ожидалось %+v, %v", tt.version, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCompareSemverOrder(t *testing.T) {
+	// Порядок из спецификации SemVer и версии без младших компонентов
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "v1.0-rc1", "1.0.0",
+		"v1.2", "1.2.1", "v1.10", "2",
+	}
+	for i := 0; i < len(ordered); i++ {
+		for j := 0; j < len(ordered); j++ {
+			a, _ := ParseSemver(ordered[i])
+			b, _ := ParseSemver(ordered[j])
+			got := sign(CompareSemver(a, b))
+			if want := sign(i - j); got != want {
+				t.Errorf("CompareSemver(%s, %s) = %d, ожидалось %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+}
+
+func TestCompareSemverIgnoresBuild(t *testing.T) {
+	a, _ := ParseSemver("v1.0")
+	b, _ := ParseSemver("1.0.0+build.7")
+	if c := CompareSemver(a, b); c != 0 {
+		t.Errorf("версии с метаданными сборки различаются: %d", c)
+	}
+}
+
+func TestNatural(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2", "v1.10", -1},
+		{"v1.10", "v1.9", 1},
+		{"1.02", "1.2", 0},
+		{"v1.0", "v1.0-rc1", -1},
+		{"v1.0-rc1", "v1.0-rc2", -1},
+		{"v1.0-rc10", "v1.0-rc9", 1},
+		{"2", "beta", -1},
+		{"release", "release", 0},
+		{"", "1", -1},
+	}
+	for _, tt := range tests {
+		if got := sign(Natural(tt.a, tt.b)); got != tt.want {
+			t.Errorf("Natural(%q, %q) = %d, ожидалось %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := sign(Natural(tt.b, tt.a)); got != -tt.want {
+			t.Errorf("Natural(%q, %q) = %d, ожидалось %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestNaturalSort(t *testing.T) {
+	versions := []string{"v1.10", "v1.2", "v1.0-rc1", "v10", "v1.0", "v2"}
+	sort.Slice(versions, func(i, j int) bool { return Natural(versions[i], versions[j]) < 0 })
+	want := []string{"v1.0", "v1.0-rc1", "v1.2", "v1.10", "v2", "v10"}
+	if !reflect.DeepEqual(versions, want) {
+		t.Errorf("порядок %v, ожидался %v", versions, want)
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,132 @@
+package gitconverter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// APIVersion — версия поддерживаемого API пакета (SemVer)
+const APIVersion = "1.0.0"
+
+// Ошибки, по которым вызывающий код может отличить причину отказа через errors.Is
+var (
+	ErrInvalidConfig      = errors.New("некорректные настройки")
+	ErrForeignRepository  = errors.New("репозиторий создан не конвертером")
+	ErrInsufficientSpace  = errors.New("недостаточно места")
+	ErrVerificationFailed = errors.New("коммит не совпадает с папкой версии")
+	ErrDirtyWorktree      = errors.New("в рабочей директории есть незакоммиченные изменения")
+	ErrPushFailed         = errors.New("миграция завершена, но репозиторий не отправлен")
+	ErrRepoUnhealthy      = errors.New("репозиторий поврежден")
+)
+
+// Converter выполняет сканирование и миграцию с заданными настройками.
+// Настройки копируются при создании и дальше не меняются
+type Converter struct {
+	config Config
+}
+
+// New проверяет настройки и создает Converter. Ошибка оборачивает ErrInvalidConfig.
+// TargetDir нужен только для миграции, для сканирования его можно не задавать.
+// В режиме добавления незаданные поля берутся из настроек прошлого запуска
+func New(config Config) (*Converter, error) {
+	config, err := applyPreviousSettings(config)
+	if err != nil {
+		return nil, err
+	}
+	if config.SourceDir == "" {
+		return nil, fmt.Errorf("%w: не указана директория с версиями", ErrInvalidConfig)
+	}
+	if err := validateSource(config); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if _, err := regexp.Compile(config.ExtractPattern); err != nil {
+		return nil, fmt.Errorf("%w: ошибка в регулярном выражении: %v", ErrInvalidConfig, err)
+	}
+	if err := validateTrailers(config.CommitTrailers); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if err := validateCommitter(config); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if config.ErrorPolicy != "" && config.ErrorPolicy != ErrorPolicyStop && config.ErrorPolicy != ErrorPolicySkip {
+		return nil, fmt.Errorf("%w: неизвестная политика ошибок %q", ErrInvalidConfig, config.ErrorPolicy)
+	}
+	if err := validateSortMode(config.SortMode); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if err := validateBranch(config.Branch); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if err := validateRefReplacement(config.RefReplacement); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if _, err := newIgnoreRules(config); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if _, err := newLFSRules(config); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if _, err := loadSignKey(config); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if err := validateGroupBy(config.GroupBy); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if err := validateEOLMode(config.NormalizeEOL); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if config.WriteEOLAttributes && !normalizesEOL(config.NormalizeEOL) {
+		return nil, fmt.Errorf("%w: правило концов строк в .gitattributes требует режима NormalizeEOL lf или crlf", ErrInvalidConfig)
+	}
+	if config.MaxDepth < 0 {
+		return nil, fmt.Errorf("%w: глубина поиска не может быть отрицательной", ErrInvalidConfig)
+	}
+	if config.Bare && config.NoGit {
+		return nil, fmt.Errorf("%w: голый репозиторий нельзя создать в режиме без Git", ErrInvalidConfig)
+	}
+	if config.TagConflictPolicy != "" && config.TagConflictPolicy != TagConflictSkip && config.TagConflictPolicy != TagConflictFail {
+		return nil, fmt.Errorf("%w: неизвестная политика конфликта тегов %q", ErrInvalidConfig, config.TagConflictPolicy)
+	}
+	return &Converter{config: config}, nil
+}
+
+// Config возвращает копию настроек конвертера
+func (c *Converter) Config() Config {
+	return c.config
+}
+
+// Scan ищет папки с версиями в SourceDir
+func (c *Converter) Scan() (*ScanResult, error) {
+	return ScanVersionedFolders(c.config)
+}
+
+// Estimate оценивает объем миграции папок folders
+func (c *Converter) Estimate(folders []FolderInfo) (*MigrationEstimate, error) {
+	return EstimateMigration(c.config, folders)
+}
+
+// Migrate переносит папки folders в репозиторий TargetDir и возвращает итоги.
+// Итоги возвращаются и вместе с ошибкой, если миграция прервана
+func (c *Converter) Migrate(folders []FolderInfo) (*MigrationResult, error) {
+	return c.MigrateContext(context.Background(), folders)
+}
+
+// MigrateContext выполняет Migrate, которую можно прервать через ctx.
+// Уже созданные коммиты остаются, а незавершенная версия откатывается
+func (c *Converter) MigrateContext(ctx context.Context, folders []FolderInfo) (*MigrationResult, error) {
+	if c.config.TargetDir == "" {
+		return &MigrationResult{}, fmt.Errorf("%w: не указана директория репозитория", ErrInvalidConfig)
+	}
+	return migrate(ctx, c.config, folders)
+}
+
+// Run сканирует SourceDir и переносит все найденные версии
+func (c *Converter) Run() (*MigrationResult, error) {
+	scan, err := c.Scan()
+	if err != nil {
+		return nil, err
+	}
+	return c.Migrate(scan.Folders)
+}
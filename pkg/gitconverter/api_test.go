@@ -0,0 +1,240 @@
+package gitconverter_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"folder_to_git/pkg/gitconverter"
+)
+
+// apiSource создает папки v1 и v2 с разными файлами и датами
+func apiSource(t *testing.T) string {
+	t.Helper()
+	src := t.TempDir()
+	when := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, content := range []string{"1\n", "2\n"} {
+		dir := filepath.Join(src, "v"+content[:1])
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		file := filepath.Join(dir, "a.txt")
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		day := when.AddDate(0, 0, i)
+		for _, path := range []string{file, dir} {
+			if err := os.Chtimes(path, day, day); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return src
+}
+
+// apiConfig возвращает настройки переноса из src в dst
+func apiConfig(src, dst string) gitconverter.Config {
+	return gitconverter.Config{
+		SourceDir:      src,
+		TargetDir:      dst,
+		Pattern:        "v*",
+		ExtractPattern: "[0-9]+",
+		Author:         "Test",
+		Email:          "test@example.com",
+		Logger:         log.New(io.Discard, "", 0),
+	}
+}
+
+// newConverter создает Converter и завершает тест при ошибке
+func newConverter(t *testing.T, config gitconverter.Config) *gitconverter.Converter {
+	t.Helper()
+	c, err := gitconverter.New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	src := t.TempDir()
+	tests := []struct {
+		name   string
+		modify func(*gitconverter.Config)
+	}{
+		{"без источника", func(c *gitconverter.Config) { c.SourceDir = "" }},
+		{"регулярное выражение", func(c *gitconverter.Config) { c.ExtractPattern = "(" }},
+		{"политика ошибок", func(c *gitconverter.Config) { c.ErrorPolicy = "retry" }},
+		{"порядок сортировки", func(c *gitconverter.Config) { c.SortMode = "random" }},
+		{"отрицательная глубина", func(c *gitconverter.Config) { c.MaxDepth = -1 }},
+		{"голый репозиторий без Git", func(c *gitconverter.Config) { c.Bare, c.NoGit = true, true }},
+		{"политика конфликта тегов", func(c *gitconverter.Config) { c.TagConflictPolicy = "overwrite" }},
+		{"ключ трейлера", func(c *gitconverter.Config) { c.CommitTrailers = map[string]string{"Bad Key": "x"} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := apiConfig(src, "")
+			tt.modify(&config)
+			c, err := gitconverter.New(config)
+			if c != nil || !errors.Is(err, gitconverter.ErrInvalidConfig) {
+				t.Errorf("New вернул %v, %v; ожидалась ErrInvalidConfig", c, err)
+			}
+		})
+	}
+}
+
+func TestConverterScanEstimateMigrate(t *testing.T) {
+	dst := t.TempDir()
+	c := newConverter(t, apiConfig(apiSource(t), dst))
+
+	scan, err := c.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scan.Folders) != 2 || scan.Folders[0].Version != "1" || scan.Folders[1].Version != "2" {
+		t.Fatalf("найдены папки %+v", scan.Folders)
+	}
+
+	estimate, err := c.Estimate(scan.Folders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.Folders != 2 || estimate.Files != 2 || estimate.Bytes != 4 {
+		t.Errorf("оценка %+v, ожидались 2 папки, 2 файла и 4 байта", estimate)
+	}
+
+	result, err := c.Migrate(scan.Folders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.CommitsCreated != 2 || !result.RepoCreated {
+		t.Errorf("итоги %+v", result)
+	}
+	if got := commitCount(t, dst); got != 2 {
+		t.Errorf("коммитов в репозитории %d, ожидалось 2", got)
+	}
+}
+
+func TestConverterRun(t *testing.T) {
+	dst := t.TempDir()
+	result, err := newConverter(t, apiConfig(apiSource(t), dst)).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.CommitsCreated != 2 || len(result.Commits) != 2 {
+		t.Errorf("создано коммитов %d, записей %d, ожидалось 2", result.CommitsCreated, len(result.Commits))
+	}
+}
+
+func TestMigrateWithoutTarget(t *testing.T) {
+	c := newConverter(t, apiConfig(apiSource(t), ""))
+	scan, err := c.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := c.Migrate(scan.Folders)
+	if !errors.Is(err, gitconverter.ErrInvalidConfig) {
+		t.Errorf("ошибка %v, ожидалась ErrInvalidConfig", err)
+	}
+	if result == nil {
+		t.Error("итоги не возвращены вместе с ошибкой")
+	}
+}
+
+func TestMigrateContextCanceled(t *testing.T) {
+	dst := t.TempDir()
+	c := newConverter(t, apiConfig(apiSource(t), dst))
+	scan, err := c.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := c.MigrateContext(ctx, scan.Folders)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ошибка %v, ожидалась context.Canceled", err)
+	}
+	if result == nil || result.CommitsCreated != 0 {
+		t.Errorf("итоги прерванной миграции: %+v", result)
+	}
+}
+
+func TestMigrateRefusesForeignRepository(t *testing.T) {
+	dst := t.TempDir()
+	repo, err := git.PlainInit(dst, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dst, "README"), []byte("чужой проект\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Add("README"); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "Someone", Email: "someone@example.com", When: time.Now()}
+	if _, err := wt.Commit("Initial commit", &git.CommitOptions{Author: sig}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, appendMode := range []bool{false, true} {
+		config := apiConfig(apiSource(t), dst)
+		config.Append = appendMode
+		_, err := newConverter(t, config).Run()
+		if !errors.Is(err, gitconverter.ErrForeignRepository) {
+			t.Errorf("добавление %v: ошибка %v, ожидалась ErrForeignRepository", appendMode, err)
+		}
+	}
+}
+
+func TestAppendRefusesDirtyWorktree(t *testing.T) {
+	dst := t.TempDir()
+	src := apiSource(t)
+	if _, err := newConverter(t, apiConfig(src, dst)).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dst, "a.txt"), []byte("правка\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := apiConfig(src, dst)
+	config.Append = true
+	_, err := newConverter(t, config).Run()
+	if !errors.Is(err, gitconverter.ErrDirtyWorktree) {
+		t.Errorf("ошибка %v, ожидалась ErrDirtyWorktree", err)
+	}
+}
+
+// commitCount возвращает число коммитов ветки HEAD репозитория dir
+func commitCount(t *testing.T, dir string) int {
+	t.Helper()
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	iter, err := repo.Log(&git.LogOptions{From: head.Hash()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	iter.ForEach(func(*object.Commit) error {
+		count++
+		return nil
+	})
+	return count
+}
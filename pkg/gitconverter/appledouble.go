@@ -0,0 +1,33 @@
+package gitconverter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// appleDoublePrefix — префикс файлов AppleDouble, в которых macOS хранит
+// ресурсную ветвь и расширенные атрибуты при копировании на чужие файловые системы
+const appleDoublePrefix = "._"
+
+// isAppleDouble проверяет, что файл — спутник AppleDouble: имя начинается
+// с "._", а рядом лежит файл или папка с тем же именем без префикса.
+// Файлы вроде "._config" без пары считаются обычными
+func isAppleDouble(path string) bool {
+	name := filepath.Base(path)
+	if !strings.HasPrefix(name, appleDoublePrefix) || len(name) == len(appleDoublePrefix) {
+		return false
+	}
+	_, err := os.Lstat(filepath.Join(filepath.Dir(path), strings.TrimPrefix(name, appleDoublePrefix)))
+	return err == nil
+}
+
+// logAppleDoubleSkips сообщает, сколько файлов AppleDouble пропущено в папке версии
+func logAppleDoubleSkips(folder FolderInfo, filter *contentFilter, logger Logger) {
+	if n, _ := filter.takeSkips(); n > 0 {
+		logger.Printf(appleDoubleMessage, Printable(filepath.Base(folder.Path)), n)
+	}
+}
+
+// appleDoubleMessage — сообщение о пропущенных файлах AppleDouble в папке
+const appleDoubleMessage = "В папке %s пропущено файлов AppleDouble (._*): %d"
@@ -0,0 +1,168 @@
+package gitconverter
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// archiveDirPrefix — префикс временных папок с распакованными архивами
+const archiveDirPrefix = "folder-to-git-archive-"
+
+// isTarArchive проверяет, что путь указывает на архив .tar.gz или .tgz
+func isTarArchive(path string) bool {
+	lower := strings.ToLower(path)
+	if !strings.HasSuffix(lower, ".tar.gz") && !strings.HasSuffix(lower, ".tgz") {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// archiveExtractDir возвращает папку распаковки архива. Имя зависит от пути,
+// размера и времени изменения архива, поэтому повторное сканирование того же
+// архива использует уже распакованные файлы
+func archiveExtractDir(config Config, archive string) (string, error) {
+	abs, err := filepath.Abs(archive)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d", abs, info.Size(), info.ModTime().UnixNano())))
+	return filepath.Join(tempRoot(config), archiveDirPrefix+hex.EncodeToString(sum[:8])), nil
+}
+
+// resolveArchiveSource распаковывает архив из SourceDir во временную папку
+// и возвращает настройки, в которых SourceDir указывает на нее. Время
+// изменения файлов сохраняется, поэтому определение дат версий не страдает
+func resolveArchiveSource(config Config) (Config, error) {
+	if !isTarArchive(config.SourceDir) {
+		return config, nil
+	}
+
+	dir, err := archiveExtractDir(config, config.SourceDir)
+	if err != nil {
+		return config, fmt.Errorf("ошибка чтения архива: %v", err)
+	}
+	complete := dir + ".complete"
+
+	if _, err := os.Stat(complete); err != nil {
+		config.logger().Printf("Распаковка архива %s в %s", config.SourceDir, dir)
+		if err := os.RemoveAll(dir); err != nil {
+			return config, err
+		}
+		if err := extractTarGz(config.SourceDir, dir, config.logger()); err != nil {
+			os.RemoveAll(dir)
+			return config, fmt.Errorf("ошибка распаковки архива: %v", err)
+		}
+		if err := os.WriteFile(complete, nil, 0644); err != nil {
+			return config, err
+		}
+	}
+
+	config.SourceDir = dir
+	return config, nil
+}
+
+// removeArchiveSource удаляет распакованный архив после миграции
+func removeArchiveSource(config Config) {
+	if !isTarArchive(config.SourceDir) {
+		return
+	}
+	dir, err := archiveExtractDir(config, config.SourceDir)
+	if err != nil {
+		return
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		config.logger().Printf("Предупреждение: не удалось удалить распакованный архив %s: %v", dir, err)
+		return
+	}
+	os.Remove(dir + ".complete")
+}
+
+// extractTarGz распаковывает архив в dst с правами и временем изменения
+// записей. Записи с путями за пределами dst и ссылки пропускаются
+func extractTarGz(archive, dst string, logger Logger) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	// Время директорий выставляется в конце: запись файлов его меняет
+	dirTimes := make(map[string]time.Time)
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		if name == "." || filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			logger.Printf("Предупреждение: пропущена запись архива с недопустимым путем %q", header.Name)
+			continue
+		}
+		target := filepath.Join(dst, name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			dirTimes[target] = header.ModTime
+
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := writeTarFile(tr, target, header); err != nil {
+				return err
+			}
+
+		default:
+			logger.Printf("Предупреждение: пропущена запись архива %s (тип %c)", header.Name, header.Typeflag)
+		}
+	}
+
+	for dir, modTime := range dirTimes {
+		os.Chtimes(dir, modTime, modTime)
+	}
+	return nil
+}
+
+// writeTarFile записывает файл из архива
+func writeTarFile(r io.Reader, target string, header *tar.Header) error {
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm()|0200)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(target, header.ModTime, header.ModTime)
+}
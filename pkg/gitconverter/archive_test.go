@@ -0,0 +1,152 @@
+package gitconverter
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// tarEntry — запись тестового архива
+type tarEntry struct {
+	Name     string
+	Type     byte
+	Content  string
+	Linkname string
+	ModTime  time.Time
+}
+
+// writeTarGz создает архив .tar.gz из записей entries
+func writeTarGz(t *testing.T, path string, entries []tarEntry) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		header := &tar.Header{
+			Name:     e.Name,
+			Typeflag: e.Type,
+			Mode:     0644,
+			Linkname: e.Linkname,
+			ModTime:  e.ModTime,
+		}
+		if e.Type == tar.TypeDir {
+			header.Mode = 0755
+		}
+		if e.Type == tar.TypeReg {
+			header.Size = int64(len(e.Content))
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if e.Type == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.Content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractTarGzRejectsUnsafeEntries(t *testing.T) {
+	root := t.TempDir()
+	archive := filepath.Join(root, "versions.tar.gz")
+	v1 := testEpoch
+	writeTarGz(t, archive, []tarEntry{
+		{Name: "v1/", Type: tar.TypeDir, ModTime: v1},
+		{Name: "v1/a.txt", Type: tar.TypeReg, Content: "a", ModTime: v1},
+		{Name: "../evil.txt", Type: tar.TypeReg, Content: "evil", ModTime: v1},
+		{Name: "v1/../../escape.txt", Type: tar.TypeReg, Content: "evil", ModTime: v1},
+		{Name: "/abs.txt", Type: tar.TypeReg, Content: "evil", ModTime: v1},
+		{Name: "v1/link", Type: tar.TypeSymlink, Linkname: "../../outside", ModTime: v1},
+		{Name: "v1/hard", Type: tar.TypeLink, Linkname: "v1/a.txt", ModTime: v1},
+		{Name: "v1/sub/b.txt", Type: tar.TypeReg, Content: "b", ModTime: v1},
+	})
+
+	dst := filepath.Join(root, "out")
+	logger := &testLogger{}
+	if err := extractTarGz(archive, dst, logger); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"v1/a.txt", "v1/sub/b.txt"}
+	if got := listTree(t, dst); !reflect.DeepEqual(got, want) {
+		t.Errorf("распаковано %v, ожидалось %v", got, want)
+	}
+	for _, name := range []string{"evil.txt", "escape.txt", "abs.txt", "outside"} {
+		if _, err := os.Lstat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Errorf("запись %s создана за пределами папки распаковки", name)
+		}
+	}
+	if _, err := os.Lstat("/abs.txt"); err == nil {
+		t.Error("создан файл /abs.txt")
+	}
+	if !logger.contains("недопустимым путем") {
+		t.Error("о записи с недопустимым путем не сообщено")
+	}
+	if !logger.contains("v1/link") || !logger.contains("v1/hard") {
+		t.Error("о пропущенных ссылках не сообщено")
+	}
+
+	info, err := os.Stat(filepath.Join(dst, "v1", "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(v1) {
+		t.Errorf("время файла %v, ожидалось %v", info.ModTime(), v1)
+	}
+}
+
+func TestMigrateFromArchive(t *testing.T) {
+	root := t.TempDir()
+	archive := filepath.Join(root, "versions.tgz")
+	v1, v2 := testEpoch, testEpoch.AddDate(0, 0, 1)
+	writeTarGz(t, archive, []tarEntry{
+		{Name: "v1/", Type: tar.TypeDir, ModTime: v1},
+		{Name: "v1/a.txt", Type: tar.TypeReg, Content: "1", ModTime: v1},
+		{Name: "v2/", Type: tar.TypeDir, ModTime: v2},
+		{Name: "v2/a.txt", Type: tar.TypeReg, Content: "2", ModTime: v2},
+		{Name: "v2/../../escape.txt", Type: tar.TypeReg, Content: "evil", ModTime: v2},
+		{Name: "v2/link", Type: tar.TypeSymlink, Linkname: "a.txt", ModTime: v2},
+	})
+
+	tmp := filepath.Join(root, "tmp")
+	if err := os.Mkdir(tmp, 0755); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(root, "repo")
+	config := testConfig(archive, dst)
+	config.TempDir = tmp
+	mustRun(t, config)
+
+	commits := history(t, dst)
+	if len(commits) != 2 {
+		t.Fatalf("коммитов %d, ожидалось 2", len(commits))
+	}
+	for i, commit := range commits {
+		files := treeFiles(t, commit)
+		if len(files) != 1 || files["a.txt"].Content != string(rune('1'+i)) {
+			t.Errorf("версия %d: дерево %v", i+1, files)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "escape.txt")); !os.IsNotExist(err) {
+		t.Error("запись с путем за пределами архива распакована")
+	}
+
+	// Распакованная копия удаляется после успешного переноса
+	if left := listTree(t, tmp); len(left) != 0 {
+		t.Errorf("во временной папке остались файлы: %v", left)
+	}
+}
@@ -0,0 +1,213 @@
+package gitconverter
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// bareRemoteName — имя, под которым временный клон видит голый репозиторий
+const bareRemoteName = "folder-to-git-bare"
+
+// isBareRepository проверяет, что dir — голый репозиторий: служебные файлы
+// Git лежат прямо в нем, а папки .git нет
+func isBareRepository(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		return false
+	}
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// migrateBare переносит версии в голый репозиторий TargetDir. go-git не
+// создает коммиты без рабочей директории, поэтому история собирается в
+// клоне во временной директории и затем отправляется в TargetDir вместе со
+// служебной папкой конвертера. Существующая история клонируется, так что
+// режим добавления находит уже перенесенные версии по ссылкам. Коммиты,
+// созданные до ошибки или отмены, тоже отправляются
+func migrateBare(ctx context.Context, src sourceFS, config Config, folders []FolderInfo, result *MigrationResult, hook *webhook) error {
+	target := config.TargetDir
+	exists := isBareRepository(target)
+	if !exists {
+		if entries, err := os.ReadDir(target); err == nil && len(entries) > 0 {
+			return fmt.Errorf("%w: %s не пуста и не является голым репозиторием", ErrInvalidConfig, target)
+		}
+		if config.Append {
+			return fmt.Errorf("указан режим --append, но голый репозиторий не существует в %s", target)
+		}
+	}
+
+	staging, err := makeTempDir(config, "folder-to-git-bare-")
+	if err != nil {
+		return fmt.Errorf("ошибка создания временной директории: %v", err)
+	}
+	defer os.RemoveAll(staging)
+
+	work := config
+	work.TargetDir = staging
+	if exists {
+		cloned, err := cloneBare(config, target, staging)
+		if err != nil {
+			return err
+		}
+		// В пустом голом репозитории добавлять не к чему, клон создаст migrateToGit
+		if !cloned {
+			work.Append = false
+		}
+	}
+	config.logger().Printf("История собирается во временной директории %s и будет отправлена в голый репозиторий %s", staging, target)
+
+	migrateErr := migrateToGit(ctx, src, work, folders, result, hook)
+	if err := publishBare(config, staging, target); err != nil {
+		if migrateErr != nil {
+			return migrateErr
+		}
+		return err
+	}
+	if !exists && result.RepoCreated {
+		config.logger().Printf("Голый репозиторий создан в %s", target)
+	}
+	return migrateErr
+}
+
+// cloneBare клонирует голый репозиторий target в staging со всеми ветками,
+// тегами, меткой конвертера и служебной папкой. Возвращает false, если в
+// target еще нет коммитов и клонировать нечего
+func cloneBare(config Config, target, staging string) (bool, error) {
+	bare, err := openRepository(target)
+	if err != nil {
+		return false, fmt.Errorf("ошибка открытия репозитория: %v", err)
+	}
+	if _, err := bare.Head(); err == plumbing.ErrReferenceNotFound {
+		return false, nil
+	}
+
+	repo, err := git.PlainClone(staging, false, &git.CloneOptions{
+		URL:        target,
+		RemoteName: bareRemoteName,
+		Tags:       git.AllTags,
+	})
+	if err != nil {
+		return false, fmt.Errorf("ошибка клонирования голого репозитория: %v", err)
+	}
+
+	// Клон получает локально только ветку HEAD, остальные нужны для -branch
+	refs, err := repo.References()
+	if err != nil {
+		return false, fmt.Errorf("ошибка получения ссылок: %v", err)
+	}
+	remotePrefix := "refs/remotes/" + bareRemoteName + "/"
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().String()
+		if ref.Type() != plumbing.HashReference || !strings.HasPrefix(name, remotePrefix) {
+			return nil
+		}
+		branch := plumbing.NewBranchReferenceName(strings.TrimPrefix(name, remotePrefix))
+		if _, err := repo.Reference(branch, false); err == nil {
+			return nil
+		}
+		return repo.Storer.SetReference(plumbing.NewHashReference(branch, ref.Hash()))
+	})
+	if err != nil {
+		return false, fmt.Errorf("ошибка создания веток клона: %v", err)
+	}
+
+	if owned, err := producedByTool(bare, config); err == nil && owned {
+		if err := markRepository(repo); err != nil {
+			return false, err
+		}
+	}
+	if err := copyToolDir(filepath.Join(target, manifestDir), filepath.Join(gitDirPath(staging), manifestDir)); err != nil {
+		return false, fmt.Errorf("ошибка копирования служебной папки конвертера: %v", err)
+	}
+	return true, nil
+}
+
+// publishBare отправляет ветки и теги временного клона staging в голый
+// репозиторий target, создавая его при необходимости, переводит HEAD на
+// ветку миграции и переносит служебную папку конвертера. Если в клоне нет
+// ни одного коммита, target не создается
+func publishBare(config Config, staging, target string) error {
+	repo, err := openRepository(staging)
+	if err != nil {
+		// migrateToGit не дошла до создания репозитория
+		return nil
+	}
+	head, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return nil
+	}
+	if _, err := repo.Reference(head.Target(), false); err != nil {
+		return nil
+	}
+
+	bare, err := openRepository(target)
+	if err != nil {
+		if bare, err = git.PlainInit(target, true); err != nil {
+			return fmt.Errorf("ошибка создания голого репозитория: %v", err)
+		}
+	}
+
+	if _, err := repo.Remote(bareRemoteName); err == git.ErrRemoteNotFound {
+		_, err = repo.CreateRemote(&gitconfig.RemoteConfig{Name: bareRemoteName, URLs: []string{target}})
+		if err != nil {
+			return fmt.Errorf("ошибка настройки отправки в голый репозиторий: %v", err)
+		}
+	}
+	err = repo.Push(&git.PushOptions{RemoteName: bareRemoteName, RefSpecs: pushRefSpecs})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return fmt.Errorf("ошибка отправки истории в голый репозиторий %s: %v", target, err)
+	}
+
+	if err := bare.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, head.Target())); err != nil {
+		return fmt.Errorf("ошибка обновления HEAD голого репозитория: %v", err)
+	}
+	if err := markRepository(bare); err != nil {
+		config.logger().Printf("Предупреждение: не удалось пометить репозиторий: %v", err)
+	}
+	if err := copyToolDir(filepath.Join(gitDirPath(staging), manifestDir), filepath.Join(target, manifestDir)); err != nil {
+		return fmt.Errorf("ошибка копирования служебной папки конвертера: %v", err)
+	}
+	// Объекты Git LFS не передаются вместе с историей
+	if err := copyLFSObjects(gitDirPath(staging), target); err != nil {
+		return fmt.Errorf("ошибка копирования объектов Git LFS: %v", err)
+	}
+	return nil
+}
+
+// copyToolDir копирует служебную папку конвертера (манифест, кэш хешей и
+// исключения) из src в dst. Отсутствующая src не считается ошибкой
+func copyToolDir(src, dst string) error {
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), data, 0644)
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
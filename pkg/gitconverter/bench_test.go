@@ -0,0 +1,234 @@
+package gitconverter
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Бенчмарки строят синтетические деревья версий. По умолчанию версии
+// содержат 1000 и 10000 файлов, чтобы набор укладывался в время CI; большие
+// деревья задаются флагами, например:
+//
+//	go test -run '^$' -bench . -benchtime 1x ./pkg/gitconverter -args -bench-files 100000
+var (
+	benchFiles    = flag.Int("bench-files", 0, "число файлов в версии для бенчмарков (0 — 1000 и 10000)")
+	benchFileSize = flag.Int("bench-size", 512, "размер файла в байтах для бенчмарков")
+)
+
+// benchVersions — сколько версий в синтетическом источнике
+const benchVersions = 3
+
+// benchFileCounts возвращает размеры версий для бенчмарков
+func benchFileCounts() []int {
+	if *benchFiles > 0 {
+		return []int{*benchFiles}
+	}
+	return []int{1000, 10000}
+}
+
+// syntheticTree создает в dir count файлов по size байт: по 100 файлов в
+// папке на двух уровнях вложенности. Каждый десятый файл зависит от seed,
+// поэтому соседние версии отличаются на 10% файлов. Возвращает пути файлов
+// относительно dir
+func syntheticTree(tb testing.TB, dir string, count, size, seed int) []string {
+	tb.Helper()
+	paths := make([]string, 0, count)
+	var content bytes.Buffer
+	for i := 0; i < count; i++ {
+		rel := filepath.Join(fmt.Sprintf("d%02d", i/1000%100), fmt.Sprintf("s%d", i/100%10), "f"+strconv.Itoa(i)+".txt")
+		if i%100 == 0 {
+			if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(rel)), 0755); err != nil {
+				tb.Fatal(err)
+			}
+		}
+
+		version := 0
+		if i%10 == 0 {
+			version = seed
+		}
+		line := fmt.Sprintf("file %d version %d\n", i, version)
+		content.Reset()
+		for content.Len() < size {
+			content.WriteString(line)
+		}
+		if err := os.WriteFile(filepath.Join(dir, rel), content.Bytes()[:size], 0644); err != nil {
+			tb.Fatal(err)
+		}
+		paths = append(paths, rel)
+	}
+	return paths
+}
+
+// syntheticSource создает источник из benchVersions версий по count файлов
+func syntheticSource(tb testing.TB, count int) string {
+	tb.Helper()
+	src := tb.TempDir()
+	for v := 1; v <= benchVersions; v++ {
+		dir := filepath.Join(src, fmt.Sprintf("v%d", v))
+		syntheticTree(tb, dir, count, *benchFileSize, v)
+		setTreeTime(tb, dir, testEpoch.AddDate(0, 0, v))
+	}
+	return src
+}
+
+// benchEach запускает подбенчмарк для каждого размера версии
+func benchEach(b *testing.B, fn func(b *testing.B, count int)) {
+	for _, count := range benchFileCounts() {
+		b.Run(fmt.Sprintf("files=%d", count), func(b *testing.B) { fn(b, count) })
+	}
+}
+
+func BenchmarkFindVersionedFolders(b *testing.B) {
+	benchEach(b, func(b *testing.B, count int) {
+		config := testConfig(syntheticSource(b, count), "")
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			folders, err := FindVersionedFolders(config)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(folders) != benchVersions {
+				b.Fatalf("найдено версий: %d", len(folders))
+			}
+		}
+	})
+}
+
+func BenchmarkCopyFilesAndTrack(b *testing.B) {
+	benchEach(b, func(b *testing.B, count int) {
+		src := b.TempDir()
+		syntheticTree(b, src, count, *benchFileSize, 1)
+		config := testConfig(src, "")
+		names, err := newNameDecoder("", config.logger())
+		if err != nil {
+			b.Fatal(err)
+		}
+		filter, err := newContentFilter(config)
+		if err != nil {
+			b.Fatal(err)
+		}
+		dst := filepath.Join(b.TempDir(), "dst")
+
+		b.SetBytes(int64(count * *benchFileSize))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			if err := os.RemoveAll(dst); err != nil {
+				b.Fatal(err)
+			}
+			b.StartTimer()
+			copied, _, err := copyFilesAndTrack(context.Background(), src, dst, false, false, names, filter, make(fileModes), config.logger())
+			if err != nil {
+				b.Fatal(err)
+			}
+			if copied != count {
+				b.Fatalf("скопировано файлов: %d", copied)
+			}
+		}
+	})
+}
+
+func BenchmarkStaging(b *testing.B) {
+	benchEach(b, func(b *testing.B, count int) {
+		dir := b.TempDir()
+		paths := syntheticTree(b, dir, count, *benchFileSize, 1)
+
+		b.SetBytes(int64(count * *benchFileSize))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			if err := os.RemoveAll(filepath.Join(dir, ".git")); err != nil {
+				b.Fatal(err)
+			}
+			repo, err := git.PlainInit(dir, false)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.StartTimer()
+
+			stager, err := newIndexStager(repo, dir, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, rel := range paths {
+				if err := stager.add(rel); err != nil {
+					b.Fatal(err)
+				}
+			}
+			if err := stager.flush(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkCommit(b *testing.B) {
+	benchEach(b, func(b *testing.B, count int) {
+		dir := b.TempDir()
+		paths := syntheticTree(b, dir, count, *benchFileSize, 1)
+		repo, err := git.PlainInit(dir, false)
+		if err != nil {
+			b.Fatal(err)
+		}
+		stager, err := newIndexStager(repo, dir, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, rel := range paths {
+			if err := stager.add(rel); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := stager.flush(); err != nil {
+			b.Fatal(err)
+		}
+		worktree, err := repo.Worktree()
+		if err != nil {
+			b.Fatal(err)
+		}
+		options := &git.CommitOptions{
+			Author:            &object.Signature{Name: "Test", Email: "test@example.com", When: testEpoch},
+			AllowEmptyCommits: true,
+		}
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := worktree.Commit("Version 1", options); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkMigrate измеряет перенос всего источника: поиск версий,
+// копирование, добавление в индекс и коммиты
+func BenchmarkMigrate(b *testing.B) {
+	benchEach(b, func(b *testing.B, count int) {
+		src := syntheticSource(b, count)
+		b.SetBytes(int64(benchVersions * count * *benchFileSize))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			dst := filepath.Join(b.TempDir(), "repo")
+			b.StartTimer()
+
+			result := mustRun(b, testConfig(src, dst))
+			if len(result.Commits) != benchVersions {
+				b.Fatalf("создано коммитов: %d", len(result.Commits))
+			}
+
+			b.StopTimer()
+			os.RemoveAll(dst)
+			b.StartTimer()
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package gitconverter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+)
+
+// budgetExamples — сколько самых больших файлов называть при превышении WarnCommitSize
+const budgetExamples = 5
+
+// budgetFile — новый или измененный файл версии и его размер
+type budgetFile struct {
+	path string
+	size int64
+}
+
+// addedFiles возвращает файлы из paths (пути относительно root), которые
+// новы для индекса репозитория или отличаются от него по содержимому
+func addedFiles(repo *git.Repository, root string, paths []string) ([]budgetFile, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[string]*index.Entry, len(idx.Entries))
+	for _, e := range idx.Entries {
+		entries[e.Name] = e
+	}
+
+	var files []budgetFile
+	for _, rel := range paths {
+		path := filepath.Join(root, rel)
+		info, err := os.Lstat(path)
+		if err != nil {
+			// Файл, который не удастся прочитать, не попадет и в индекс
+			continue
+		}
+		name := filepath.ToSlash(rel)
+		if e, ok := entries[name]; ok && e.Size == uint32(info.Size()) {
+			// Размер совпал, сравниваем содержимое
+			hash, err := blobHash(path)
+			if err != nil {
+				return nil, err
+			}
+			if hash == e.Hash {
+				continue
+			}
+		}
+		files = append(files, budgetFile{path: name, size: info.Size()})
+	}
+	return files, nil
+}
+
+// checkBudget сравнивает объем новых и измененных файлов версии с limit и
+// возвращает описание нарушения с самыми большими файлами или пустую строку
+func checkBudget(version string, files []budgetFile, limit int64) string {
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	if limit <= 0 || total <= limit {
+		return ""
+	}
+
+	sorted := append([]budgetFile(nil), files...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].size > sorted[j].size })
+	var largest []string
+	for _, f := range sorted {
+		largest = append(largest, fmt.Sprintf("%s (%s)", Printable(f.path), FormatSize(f.size)))
+	}
+	largest = firstN(largest, budgetExamples)
+	return fmt.Sprintf("версия %s добавляет %s при допустимых %s, крупнейшие файлы: %s",
+		Printable(version), FormatSize(total), FormatSize(limit), strings.Join(largest, ", "))
+}
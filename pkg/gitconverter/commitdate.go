@@ -0,0 +1,66 @@
+package gitconverter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// CommitDateSource определяет дату коммиттера в создаваемых коммитах
+type CommitDateSource string
+
+const (
+	CommitDateAuthor CommitDateSource = "author" // Дата версии, как у автора (по умолчанию)
+	CommitDateNow    CommitDateSource = "now"    // Время переноса в репозиторий, как при git rebase
+)
+
+// validateCommitDateSource проверяет, что источник даты известен
+func validateCommitDateSource(source CommitDateSource) error {
+	switch source {
+	case "", CommitDateAuthor, CommitDateNow:
+		return nil
+	}
+	return fmt.Errorf("неизвестный источник даты коммита %q", source)
+}
+
+// committerName возвращает имя коммиттера из CommitterName или его синонима
+// Committer
+func committerName(config Config) string {
+	if config.CommitterName != "" {
+		return config.CommitterName
+	}
+	return config.Committer
+}
+
+// validateCommitter проверяет, что синонимы имени коммиттера не противоречат
+// друг другу, а имя и email заданы вместе
+func validateCommitter(config Config) error {
+	if config.Committer != "" && config.CommitterName != "" && config.Committer != config.CommitterName {
+		return fmt.Errorf("имя коммиттера задано по-разному в Committer и CommitterName")
+	}
+	if (committerName(config) == "") != (config.CommitterEmail == "") {
+		return fmt.Errorf("имя и email коммиттера задаются вместе")
+	}
+	return nil
+}
+
+// committerDate возвращает дату коммиттера для версии с датой authored. По
+// умолчанию это дата версии и для отдельного коммиттера, чтобы история не
+// зависела от того, когда ее перенесли
+func committerDate(config Config, authored time.Time) time.Time {
+	if config.CommitDateSource == CommitDateNow {
+		return time.Now()
+	}
+	return authored
+}
+
+// committerSignature возвращает коммиттера версии: CommitterName (Committer),
+// если он задан, иначе автора версии
+func committerSignature(config Config, authorName, authorEmail string, authored time.Time) *object.Signature {
+	name, email := authorName, authorEmail
+	if committer := committerName(config); committer != "" {
+		name, email = committer, config.CommitterEmail
+	}
+	return &object.Signature{Name: name, Email: email, When: committerDate(config, authored)}
+}
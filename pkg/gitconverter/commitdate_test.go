@@ -0,0 +1,89 @@
+package gitconverter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCommitDates(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    CommitDateSource
+		committer string
+		alias     bool
+		wantNow   bool
+	}{
+		{"по умолчанию", "", "", false, false},
+		{"author", CommitDateAuthor, "", false, false},
+		{"now", CommitDateNow, "", false, true},
+		{"коммиттер по умолчанию", "", "Migration Bot", false, false},
+		{"коммиттер с now", CommitDateNow, "Migration Bot", false, true},
+		{"синоним Committer", "", "Migration Bot", true, false},
+	}
+	src := versionSource(t, map[string]string{"a.txt": "1"}, map[string]string{"a.txt": "2"})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := t.TempDir()
+			config := testConfig(src, dst)
+			config.CommitDateSource = tt.source
+			if tt.committer != "" {
+				if tt.alias {
+					config.Committer = tt.committer
+				} else {
+					config.CommitterName = tt.committer
+				}
+				config.CommitterEmail = "bot@example.com"
+			}
+
+			start := time.Now().Truncate(time.Second)
+			mustRun(t, config)
+			end := time.Now()
+
+			commits := history(t, dst)
+			if len(commits) != 2 {
+				t.Fatalf("коммитов %d, ожидалось 2", len(commits))
+			}
+			for i, commit := range commits {
+				authored := testEpoch.AddDate(0, 0, i)
+				if !commit.Author.When.Equal(authored) {
+					t.Errorf("версия %d: дата автора %v, ожидалась %v", i+1, commit.Author.When, authored)
+				}
+				when := commit.Committer.When
+				if tt.wantNow {
+					if when.Before(start) || when.After(end) {
+						t.Errorf("версия %d: дата коммиттера %v вне времени переноса [%v, %v]", i+1, when, start, end)
+					}
+				} else if !when.Equal(authored) {
+					t.Errorf("версия %d: дата коммиттера %v, ожидалась дата версии %v", i+1, when, authored)
+				}
+
+				wantName := config.Author
+				if tt.committer != "" {
+					wantName = tt.committer
+				}
+				if commit.Committer.Name != wantName {
+					t.Errorf("версия %d: коммиттер %q, ожидался %q", i+1, commit.Committer.Name, wantName)
+				}
+			}
+		})
+	}
+}
+
+func TestCommitDateValidation(t *testing.T) {
+	if err := validateCommitDateSource("yesterday"); err == nil {
+		t.Error("неизвестный источник даты принят")
+	}
+	if err := validateCommitter(Config{CommitterName: "Bot"}); err == nil {
+		t.Error("коммиттер без email принят")
+	}
+	if err := validateCommitter(Config{CommitterName: "Bot", CommitterEmail: "bot@example.com"}); err != nil {
+		t.Errorf("коммиттер отклонен: %v", err)
+	}
+	if err := validateCommitter(Config{Committer: "Bot"}); err == nil {
+		t.Error("коммиттер Committer без email принят")
+	}
+	if err := validateCommitter(Config{Committer: "Bot", CommitterName: "Other", CommitterEmail: "bot@example.com"}); err == nil {
+		t.Error("разные имена в Committer и CommitterName приняты")
+	}
+}
@@ -0,0 +1,296 @@
+package gitconverter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// Категории содержимого для SkipContentTypes
+const (
+	ContentArchive  = "archive"
+	ContentImage    = "image"
+	ContentVideo    = "video"
+	ContentCoreDump = "core-dump"
+)
+
+// defaultContentSniffMinSize — файлы меньше этого размера не проверяются
+const defaultContentSniffMinSize = 1 << 20
+
+// sniffSize — сколько байт начала файла читается для определения типа.
+// Сигнатура tar лежит по смещению 257, поэтому меньше 512 брать нельзя
+const sniffSize = 512
+
+// contentMagic описывает сигнатуру формата в начале файла
+type contentMagic struct {
+	offset   int
+	magic    []byte
+	category string
+	format   string
+}
+
+var contentMagics = []contentMagic{
+	{0, []byte("PK\x03\x04"), ContentArchive, "zip"},
+	{0, []byte("PK\x05\x06"), ContentArchive, "zip"},
+	{0, []byte("\x1f\x8b"), ContentArchive, "gzip"},
+	{0, []byte("BZh"), ContentArchive, "bzip2"},
+	{0, []byte("\xfd7zXZ\x00"), ContentArchive, "xz"},
+	{0, []byte("\x28\xb5\x2f\xfd"), ContentArchive, "zstd"},
+	{0, []byte("7z\xbc\xaf\x27\x1c"), ContentArchive, "7z"},
+	{0, []byte("Rar!\x1a\x07"), ContentArchive, "rar"},
+	{0, []byte("MSCF"), ContentArchive, "cab"},
+	{257, []byte("ustar"), ContentArchive, "tar"},
+
+	{0, []byte("\x89PNG\r\n\x1a\n"), ContentImage, "png"},
+	{0, []byte("\xff\xd8\xff"), ContentImage, "jpeg"},
+	{0, []byte("GIF8"), ContentImage, "gif"},
+	{0, []byte("II*\x00"), ContentImage, "tiff"},
+	{0, []byte("MM\x00*"), ContentImage, "tiff"},
+	{0, []byte("8BPS"), ContentImage, "psd"},
+	{0, []byte("BM"), ContentImage, "bmp"},
+
+	{0, []byte("\x1a\x45\xdf\xa3"), ContentVideo, "matroska"},
+	{0, []byte("FLV\x01"), ContentVideo, "flv"},
+	{0, []byte("\x00\x00\x01\xba"), ContentVideo, "mpeg"},
+	{0, []byte("\x00\x00\x01\xb3"), ContentVideo, "mpeg"},
+	{0, []byte("\x30\x26\xb2\x75\x8e\x66\xcf\x11"), ContentVideo, "asf"},
+
+	{0, []byte("MDMP"), ContentCoreDump, "minidump"},
+}
+
+// sniffContentType определяет категорию и формат по первым байтам файла.
+// Для неизвестного содержимого возвращает пустые строки
+func sniffContentType(head []byte) (string, string) {
+	for _, m := range contentMagics {
+		end := m.offset + len(m.magic)
+		if len(head) >= end && bytes.Equal(head[m.offset:end], m.magic) {
+			return m.category, m.format
+		}
+	}
+
+	// Контейнеры RIFF и ISO BMFF хранят тип во вложенном поле
+	if len(head) >= 12 && bytes.Equal(head[:4], []byte("RIFF")) {
+		switch string(head[8:12]) {
+		case "WEBP":
+			return ContentImage, "webp"
+		case "AVI ":
+			return ContentVideo, "avi"
+		}
+	}
+	if len(head) >= 12 && bytes.Equal(head[4:8], []byte("ftyp")) {
+		switch brand := string(head[8:12]); brand {
+		case "heic", "heix", "mif1", "avif":
+			return ContentImage, brand
+		default:
+			return ContentVideo, "mp4"
+		}
+	}
+
+	// Дамп памяти Linux — ELF с типом ET_CORE
+	if len(head) >= 18 && bytes.Equal(head[:4], []byte("\x7fELF")) {
+		order := binary.ByteOrder(binary.LittleEndian)
+		if head[5] == 2 {
+			order = binary.BigEndian
+		}
+		if order.Uint16(head[16:18]) == 4 {
+			return ContentCoreDump, "elf core"
+		}
+	}
+
+	return "", ""
+}
+
+// contentFilter пропускает файлы из списков игнорирования, файлы выбранных
+// категорий содержимого, спутники AppleDouble и, по желанию, файлы из
+// .gitignore исходной папки. Решения запоминаются, чтобы не читать файл и
+// не сообщать о нем повторно
+type contentFilter struct {
+	rules       ignoreRules
+	skip        map[string]bool
+	minSize     int64
+	appleDouble bool // Пропускать файлы AppleDouble (._имя)
+	gitignore   bool // Пропускать файлы, исключенные .gitignore исходной папки
+	logger      Logger
+
+	mu           sync.Mutex
+	decided      map[string]string // Путь -> формат пропущенного файла, "" — файл не пропускается
+	appleSkips   int               // Пропущено файлов AppleDouble с последнего сброса
+	skippedFiles []string          // Пропущенные по содержимому файлы с последнего сброса
+
+	// matchers — правила .gitignore по корню исходной папки, читаются один раз
+	matchers map[string]gitignore.Matcher
+}
+
+// newContentFilter создает фильтр по настройкам. Если пропускать нечего,
+// кроме списков игнорирования по умолчанию, возвращает nil — такой фильтр
+// пропускает только их
+func newContentFilter(config Config) (*contentFilter, error) {
+	rules, err := newIgnoreRules(config)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.SkipContentTypes) == 0 && config.KeepAppleDouble && len(config.IgnoreDirs) == 0 && len(config.IgnoreFiles) == 0 &&
+		!config.RespectGitignore && !config.KeepGitignore {
+		return nil, nil
+	}
+
+	f := &contentFilter{
+		rules:       rules,
+		skip:        make(map[string]bool),
+		minSize:     config.ContentSniffMinSize,
+		appleDouble: !config.KeepAppleDouble,
+		gitignore:   config.RespectGitignore,
+		decided:     make(map[string]string),
+		matchers:    make(map[string]gitignore.Matcher),
+		logger:      config.logger(),
+	}
+	if f.minSize <= 0 {
+		f.minSize = defaultContentSniffMinSize
+	}
+	for _, category := range config.SkipContentTypes {
+		category = strings.ToLower(strings.TrimSpace(category))
+		switch category {
+		case ContentArchive, ContentImage, ContentVideo, ContentCoreDump:
+			f.skip[category] = true
+		default:
+			return nil, fmt.Errorf("неизвестная категория содержимого: %q", category)
+		}
+	}
+	return f, nil
+}
+
+// ignore возвращает действующие списки игнорирования
+func (f *contentFilter) ignore() ignoreRules {
+	if f == nil {
+		return defaultIgnoreRules()
+	}
+	return f.rules
+}
+
+// gitignored проверяет, что путь rel внутри исходной папки root исключен
+// ее .gitignore. Правила папки читаются при первом обращении к ней
+func (f *contentFilter) gitignored(root, rel string, isDir bool) (bool, error) {
+	if f == nil || !f.gitignore {
+		return false, nil
+	}
+	f.mu.Lock()
+	matcher, ok := f.matchers[root]
+	f.mu.Unlock()
+	if !ok {
+		var err error
+		matcher, err = readSourceGitignore(root, f.rules)
+		if err != nil {
+			return false, fmt.Errorf("ошибка чтения .gitignore папки %s: %v", filepath.Base(root), err)
+		}
+		f.mu.Lock()
+		f.matchers[root] = matcher
+		f.mu.Unlock()
+	}
+	return matcher.Match(strings.Split(filepath.ToSlash(rel), "/"), isDir), nil
+}
+
+// skipped проверяет, нужно ли пропустить файл, и сообщает о пропуске в лог
+func (f *contentFilter) skipped(path string, size int64) (bool, error) {
+	if f == nil {
+		return false, nil
+	}
+	if f.appleDouble && isAppleDouble(path) {
+		f.mu.Lock()
+		f.appleSkips++
+		f.mu.Unlock()
+		return true, nil
+	}
+	if len(f.skip) == 0 || size < f.minSize {
+		return false, nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if format, ok := f.decided[path]; ok {
+		if format != "" {
+			f.skippedFiles = append(f.skippedFiles, skipMessage(path, size, format))
+		}
+		return format != "", nil
+	}
+
+	category, format, err := sniffFile(path)
+	if err != nil {
+		return false, err
+	}
+	if !f.skip[category] {
+		f.decided[path] = ""
+		return false, nil
+	}
+	f.decided[path] = format
+	msg := skipMessage(path, size, format)
+	f.skippedFiles = append(f.skippedFiles, msg)
+	f.logger.Printf("Пропущен %s", msg)
+	return true, nil
+}
+
+// excludes проверяет, пропустит ли копирование файл, как skipped, но ничего
+// не записывает в лог и в итоги. Так считается объем версии до переноса
+func (f *contentFilter) excludes(path string, size int64) (bool, error) {
+	if f == nil {
+		return false, nil
+	}
+	if f.appleDouble && isAppleDouble(path) {
+		return true, nil
+	}
+	if len(f.skip) == 0 || size < f.minSize {
+		return false, nil
+	}
+	f.mu.Lock()
+	format, ok := f.decided[path]
+	f.mu.Unlock()
+	if ok {
+		return format != "", nil
+	}
+	category, _, err := sniffFile(path)
+	if err != nil {
+		return false, err
+	}
+	return f.skip[category], nil
+}
+
+// sniffFile определяет категорию и формат содержимого файла по его началу
+func sniffFile(path string) (string, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", "", err
+	}
+	category, format := sniffContentType(head[:n])
+	return category, format, nil
+}
+
+// skipMessage описывает файл, пропущенный по содержимому
+func skipMessage(path string, size int64, format string) string {
+	return fmt.Sprintf("файл %s (%s): содержимое похоже на %s", path, FormatSize(size), format)
+}
+
+// takeSkips возвращает число пропущенных файлов AppleDouble и описания
+// файлов, пропущенных по содержимому, и сбрасывает их
+func (f *contentFilter) takeSkips() (int, []string) {
+	if f == nil {
+		return 0, nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, skipped := f.appleSkips, f.skippedFiles
+	f.appleSkips, f.skippedFiles = 0, nil
+	return n, skipped
+}
@@ -1,18 +1,23 @@
 package gitconverter
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
 	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
@@ -20,30 +25,168 @@ import (
 type FolderInfo struct {
 	Path         string
 	Version      string
-	CreationTime int64 // Unix timestamp времени создания
+	CreationTime int64        // Unix timestamp времени создания
+	Size         int64        // Размер файлов в байтах, заполняется EnrichFolders
+	FileCount    int          // Количество файлов, заполняется EnrichFolders
+	TimeFallback bool         // Время создания определено запасным способом и может быть неточным
+	TimeFromFile bool         // Время создания взято из TimeSourceFile
+	MergedPaths  []string     // Все папки версии, объединенные MergeSameVersion, по времени создания
+	Collapsed    []FolderInfo // Более ранние снимки того же периода, свернутые GroupBy в эту папку
 }
 
 // Config содержит настройки для конвертации
 type Config struct {
-	SourceDir       string
-	TargetDir       string
-	Pattern         string
-	ExtractPattern  string
-	DryRun          bool
-	Author          string
-	Email           string
-	Verbose         bool
-	Append          bool
-	AuthorsFile     string // Файл с сопоставлением версий и авторов
-	MessageTemplate string // Шаблон сообщения коммита
+	SourceDir            string
+	TargetDir            string
+	Pattern              string
+	ExtractPattern       string
+	Recursive            bool              // Искать папки с версиями во вложенных директориях, а не только в SourceDir
+	MaxDepth             int               // Наибольшая глубина папки с версией относительно SourceDir при Recursive (0 — без ограничения)
+	VersionOverrides     map[string]string // Версии, заданные вручную по имени папки, вместо извлеченных из имени
+	ExcludeFolders       []string          // Папки (путь или имя), исключенные пользователем; запоминаются в манифесте репозитория
+	MergeSameVersion     bool              // Объединять папки с одинаковой версией (v1.4_src и v1.4_assets) в один коммит
+	GroupBy              GroupPeriod       // Оставлять один коммит на период (day, week, month) — последний снимок периода; по умолчанию none
+	SkipUnchanged        bool              // Не создавать коммит для версии, совпадающей с предыдущей; такие версии перечисляются в MigrationResult.Unchanged
+	SkipEmptyCommits     bool              // Синоним SkipUnchanged: версия без изменений относительно HEAD пропускается
+	IgnoreDirs           []string          // Имена директорий, которые не копируются (по умолчанию DefaultIgnoreDirs)
+	IgnoreFiles          []string          // Шаблоны имен файлов, которые не копируются (по умолчанию DefaultIgnoreFiles)
+	RespectGitignore     bool              // Не копировать файлы, исключенные .gitignore исходной папки и ее поддиректорий
+	KeepGitignore        bool              // Копировать сами файлы .gitignore из исходных папок (по умолчанию пропускаются)
+	DryRun               bool
+	Author               string
+	Email                string
+	Verbose              bool
+	Append               bool
+	AdoptExisting        bool                  // Разрешить режиму добавления продолжить репозиторий, созданный не конвертером
+	IgnoreHealthCheck    bool                  // Добавлять версии, даже если проверка репозитория (Check) нашла повреждения
+	IgnorePreviousConfig bool                  // Не подставлять в режиме добавления настройки прошлого запуска из манифеста
+	AuthorsFile          string                // Файл с сопоставлением версий и авторов
+	CommitterName        string                // Имя коммиттера, например бота миграции (по умолчанию автор версии)
+	Committer            string                // Синоним CommitterName: задается одно из двух полей или оба с одним значением
+	CommitterEmail       string                // Email коммиттера, задается вместе с CommitterName
+	MessageTemplate      string                // Шаблон сообщения коммита
+	SubjectLimit         int                   // Максимальная длина первой строки сообщения, остаток переносится в тело (по умолчанию 72, -1 — без ограничения)
+	NoGit                bool                  // Только разложить версии по папкам в TargetDir без операций Git
+	Bare                 bool                  // Создать TargetDir голым репозиторием: только объекты и ссылки Git, без рабочей директории
+	RefLowercase         bool                  // Приводить версию к нижнему регистру в именах тегов и веток
+	RefReplacement       string                // Замена недопустимых в именах ссылок символов (по умолчанию "-")
+	Incremental          bool                  // Применять к рабочей директории только изменения вместо полной перезаписи
+	IgnoreDiskSpace      bool                  // Только предупреждать о нехватке места на диске вместо отказа
+	MessageEncoding      string                // Кодировка сообщений коммитов (по умолчанию UTF-8)
+	UnmatchedReportPath  string                // Файл для списка папок, из имени которых не удалось извлечь версию
+	SourceEncoding       string                // Кодировка имен папок и файлов не в UTF-8 (по умолчанию windows-1251)
+	SFTPKeyPath          string                // Закрытый SSH-ключ для источника sftp:// (по умолчанию SSH-агент и ~/.ssh/id_*)
+	SFTPKeyPassphrase    string                // Пароль SSH-ключа SFTPKeyPath, если он зашифрован
+	SFTPKnownHosts       string                // Файл известных серверов для проверки ключа сервера SFTP (по умолчанию ~/.ssh/known_hosts)
+	SFTPIgnoreHostKey    bool                  // Не проверять ключ сервера SFTP — только для доверенных сетей
+	SFTPConcurrency      int                   // Наибольшее число одновременных запросов к серверу SFTP (по умолчанию 4)
+	SFTPRetries          int                   // Число повторов запроса SFTP при обрыве соединения (по умолчанию 3)
+	SFTPRetryDelay       time.Duration         // Пауза перед первым повтором запроса SFTP, далее удваивается (по умолчанию 2 с)
+	Anonymize            bool                  // Подписывать все коммиты одним обезличенным автором
+	AnonymousAuthor      string                // Имя обезличенного автора (по умолчанию "Anonymous")
+	AnonymousEmail       string                // Email обезличенного автора (по умолчанию "anon@example.com")
+	AnnotatedTags        bool                  // Создавать для каждой версии аннотированный тег со списком файлов
+	CreateTags           bool                  // Ставить тег каждой версии: аннотированный, если автор найден в AuthorsFile, иначе легковесный
+	TagFileListLimit     int                   // Максимум файлов в сообщении тега (по умолчанию 100)
+	TagPrefix            string                // Префикс имен тегов версий, например "v"
+	TagConflictPolicy    TagConflictPolicy     // Что делать, если тег версии уже существует (по умолчанию пропустить)
+	SignKeyPath          string                // Файл закрытого ключа OpenPGP (ASCII armor) для подписи коммитов и тегов
+	SignKeyPassphrase    string                // Пароль ключа SignKeyPath, в лог и манифест не попадает
+	FolderTimeout        time.Duration         // Ограничение времени обработки одной папки (0 — без ограничения)
+	ErrorPolicy          ErrorPolicy           // Что делать при ошибке обработки папки (по умолчанию остановиться)
+	HookCommand          string                // Команда, выполняемая в рабочей директории перед каждым коммитом
+	HookTimeout          time.Duration         // Ограничение времени команды HookCommand (по умолчанию 5 мин)
+	TimestampStrategy    TimestampStrategy     // Откуда брать время создания версии (по умолчанию file-mtime)
+	OnScanProgress       func(done, total int) // Вызывается после проверки каждой папки при сканировании
+	OnProgress           ProgressFunc          // Вызывается перед обработкой каждой папки при миграции (done — ее номер), никогда не одновременно
+	Logger               Logger                // Куда писать сообщения о ходе работы (по умолчанию стандартный log)
+	Concurrency          int                   // Сколько папок проверять одновременно при сканировании (по умолчанию число процессоров)
+	ThrottleMBps         float64               // Ограничение скорости копирования, МБ/с (0 — без ограничения)
+	ThrottleFilesPerSec  float64               // Ограничение числа копируемых файлов в секунду (0 — без ограничения)
+	IdlePriority         bool                  // Делать паузу между папками, чтобы не мешать другим программам
+	PushAfterMigrate     bool                  // Отправить репозиторий в удаленный после миграции
+	RemoteName           string                // Имя удаленного репозитория (по умолчанию "origin")
+	RemoteURL            string                // Адрес удаленного репозитория, если он еще не настроен
+	PushRetries          int                   // Число повторов отправки при сбое (по умолчанию 3)
+	PushRetryDelay       time.Duration         // Пауза перед первым повтором, далее удваивается (по умолчанию 5 с)
+	PushCredential       string                // Имя учетных данных для отправки в хранилище CredentialStore
+	PushSSHKey           string                // Файл закрытого SSH-ключа для отправки по ssh:// или user@host:путь
+	PushSSHKeyPassphrase string                // Пароль SSH-ключа PushSSHKey, если он зашифрован
+	CredentialStore      CredentialStore       // Хранилище учетных данных (по умолчанию системное)
+	KeepVersionDir       bool                  // Класть каждую версию в свою папку, не удаляя предыдущие
+	WorkspaceMode        bool                  // Собирать версию во временной папке и переносить в рабочую директорию одним шагом
+	CommitTrailers       map[string]string     // Трейлеры "Ключ: значение" для каждого коммита, значения поддерживают шаблоны {version} и др.
+	CommitDateSource     CommitDateSource      // Дата коммиттера: author — дата версии, now — время переноса (по умолчанию author, в том числе с CommitterName)
+	TimeSampleExtensions []string              // Расширения файлов, по которым определяется время создания (по умолчанию все)
+	TimeSourceFile       string                // Файл со временем создания версий "версия=время" (ГГГГ-ММ-ДД или RFC3339); версии без записи — по TimestampStrategy
+	WriteMetadataFile    bool                  // Записывать в репозиторий файл с метаданными каждой версии
+	MetadataFilePath     string                // Путь файла метаданных в репозитории (по умолчанию .folder-to-git.yaml)
+	Branch               string                // Ветка для коммитов (по умолчанию master для нового репозитория и текущая для существующего)
+	Strict               bool                  // Прерывать миграцию, если целевая файловая система потеряет права, ссылки или регистр имен или папка версии исчезнет после сканирования, и не коммитить версии больше WarnCommitSize
+	MaxCommitSize        int64                 // Максимальный размер файлов в одном коммите; большая версия делится на части (0 — без ограничения)
+	WarnCommitSize       int64                 // Предупреждать, если версия добавляет больше байт (в режиме Strict — не создавать коммит), 0 — без проверки
+	LFSPatterns          []string              // Шаблоны файлов, хранящихся в Git LFS, как в .gitattributes (*.iso, /assets/*.bin)
+	LFSThresholdBytes    int64                 // Файлы больше этого размера хранятся в Git LFS (0 — только по LFSPatterns)
+	VerifyTolerance      int                   // Сколько файлов папки может не совпасть с деревом коммита без ошибки (по умолчанию 0)
+	HashCache            bool                  // Хранить хеши файлов между запусками, чтобы быстрее сравнивать неизменившиеся папки
+	Paranoid             bool                  // Не доверять кэшу хешей: заново считать хеши всех файлов, например для проверочного запуска
+	WriteGitignore       bool                  // Добавить в первый коммит .gitignore с правилами пропуска файлов
+	NormalizeEOL         EOLMode               // Концы строк текстовых файлов в коммитах: lf, crlf или none (по умолчанию, без изменений)
+	WriteEOLAttributes   bool                  // Добавить в первый коммит .gitattributes с правилом концов строк NormalizeEOL
+	TempDir              string                // Директория для промежуточных файлов (по умолчанию системная временная)
+	WatchDebounce        time.Duration         // Пауза после изменения в источнике перед проходом наблюдения (по умолчанию 2 с)
+	WatchSettle          time.Duration         // Сколько папка не должна меняться, чтобы считаться дописанной (по умолчанию 10 с)
+	PreferVersionOrder   bool                  // Упорядочивать по номерам версий папки, даты которых противоречат номерам
+	SortMode             SortMode              // Порядок папок: time (по умолчанию), semver или natural
+	SkipContentTypes     []string              // Категории содержимого, которые не копируются: archive, image, video, core-dump
+	ContentSniffMinSize  int64                 // Файлы меньше этого размера не проверяются по содержимому (по умолчанию 1 МБ)
+	KeepAppleDouble      bool                  // Копировать спутники AppleDouble (._имя рядом с файлом имя), по умолчанию они пропускаются
+	IgnoreModeChanges    bool                  // В режиме добавления не переносить смену исполняемого бита у существующих файлов
+	WebhookURL           string                // Адрес для POST-уведомлений о ходе миграции
+	WebhookToken         string                // Bearer-токен для webhook, в лог и манифест не попадает
+	WebhookEvents        []string              // Отправляемые события: folder, failed, done (по умолчанию только done)
+	WebhookTimeout       time.Duration         // Ограничение времени одного запроса (по умолчанию 10 с)
+	WebhookRetries       int                   // Число повторов запроса при сбое (по умолчанию 2)
 }
 
+// Обезличенный автор по умолчанию для режима Anonymize
+const (
+	defaultAnonymousAuthor = "Anonymous"
+	defaultAnonymousEmail  = "anon@example.com"
+)
+
 // FindVersionedFolders ищет папки с версиями проекта
+//
+// Deprecated: используйте Converter.Scan.
 func FindVersionedFolders(config Config) ([]FolderInfo, error) {
+	scan, err := ScanVersionedFolders(config)
+	if err != nil {
+		return nil, err
+	}
+	return scan.Folders, nil
+}
+
+// ScanVersionedFolders ищет папки с версиями и возвращает также папки,
+// из имени которых не удалось извлечь версию
+//
+// Deprecated: используйте Converter.Scan.
+func ScanVersionedFolders(config Config) (*ScanResult, error) {
 	var folders []FolderInfo
+	scan := &ScanResult{}
+
+	// Удаленный источник читается по SFTP, локальный — напрямую
+	src, err := openSource(config)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
 
-	// Создаем полный путь для поиска
-	searchPattern := filepath.Join(config.SourceDir, config.Pattern)
+	// Версии из архива читаются из его распакованной копии
+	config, err = resolveArchiveSource(config)
+	if err != nil {
+		return nil, err
+	}
+	root := sourceRoot(config)
 
 	// Компилируем регулярное выражение для извлечения версии
 	re, err := regexp.Compile(config.ExtractPattern)
@@ -51,16 +194,45 @@ func FindVersionedFolders(config Config) ([]FolderInfo, error) {
 		return nil, fmt.Errorf("ошибка в регулярном выражении: %v", err)
 	}
 
+	names, err := newNameDecoder(config.SourceEncoding, config.logger())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateTimestampStrategy(config.TimestampStrategy); err != nil {
+		return nil, err
+	}
+	if err := validateSortMode(config.SortMode); err != nil {
+		return nil, err
+	}
+	times, err := loadVersionTimes(config)
+	if err != nil {
+		return nil, err
+	}
+
 	// Ищем папки, соответствующие шаблону
-	matches, err := filepath.Glob(searchPattern)
+	matches, unreadable, err := findVersionPaths(src, root, config, re)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при поиске папок: %v", err)
 	}
+	scan.Unreadable = append(scan.Unreadable, unreadable...)
+
+	// Glob молча пропускает директории, которые не удалось прочитать
+	if len(matches) == 0 {
+		if err := src.ProbeDir(root); os.IsPermission(err) {
+			return nil, fmt.Errorf("нет доступа к исходной директории %s: %v", config.SourceDir, err)
+		}
+	}
 
-	// Обрабатываем каждую найденную папку
+	// Сначала отбираем папки с версиями: это быстрые проверки имени и прав
+	var candidates []scanCandidate
 	for _, path := range matches {
 		// Проверяем, что это директория
-		info, err := os.Stat(path)
+		info, err := src.Stat(path)
+		if os.IsPermission(err) {
+			scan.Unreadable = append(scan.Unreadable, path)
+			continue
+		}
 		if err != nil || !info.IsDir() {
 			continue
 		}
@@ -68,58 +240,302 @@ func FindVersionedFolders(config Config) ([]FolderInfo, error) {
 		// Получаем имя папки
 		name := filepath.Base(path)
 
-		// Извлекаем версию из имени папки
+		// Извлекаем версию из имени папки, если она не задана вручную
 		version := ""
-		if match := re.FindString(name); match != "" {
+		if override, ok := config.VersionOverrides[name]; ok {
+			version = override
+			config.logger().Printf("Версия папки %s задана вручную: %s", Printable(name), Printable(version))
+		} else if match := re.FindString(name); match != "" {
 			version = match
 		} else {
 			if config.Verbose {
-				log.Printf("Не удалось извлечь версию из папки: %s", name)
+				config.logger().Printf("Не удалось извлечь версию из папки: %s", Printable(name))
 			}
+			scan.Unmatched = append(scan.Unmatched, path)
 			continue
 		}
 
-		// Получаем время создания папки
-		creationTime := getFolderCreationTime(path)
+		// Папку без права чтения не удастся ни оценить, ни скопировать
+		if err := src.ProbeDir(path); err != nil {
+			if os.IsPermission(err) {
+				scan.Unreadable = append(scan.Unreadable, path)
+			}
+			continue
+		}
 
-		folders = append(folders, FolderInfo{
-			Path:         path,
-			Version:      version,
-			CreationTime: creationTime,
-		})
+		// Версия попадает в сообщения коммитов и имена ссылок, поэтому приводим ее к UTF-8
+		if decoded, changed := names.decode(version); changed {
+			config.logger().Printf("Предупреждение: имя папки %q не в UTF-8, версия сохранена как %s", name, Printable(decoded))
+			version = decoded
+		}
+
+		candidates = append(candidates, scanCandidate{folder: FolderInfo{Path: path, Version: version}, info: info})
+	}
 
-		if config.Verbose {
-			log.Printf("Найдена папка: %s (версия: %s, создана: %s)",
-				name, version, time.Unix(creationTime, 0).Format("2006-01-02 15:04:05"))
+	// Время создания требует обхода файлов, поэтому определяется параллельно
+	folders = scanCreationTimes(src, config, times, candidates)
+	if config.Verbose {
+		for _, folder := range folders {
+			config.logger().Printf("Найдена папка: %s (версия: %s, создана: %s)", Printable(filepath.Base(folder.Path)),
+				Printable(folder.Version), time.Unix(folder.CreationTime, 0).Format("2006-01-02 15:04:05"))
 		}
 	}
 
 	// Сортируем папки по времени создания
-	sort.Slice(folders, func(i, j int) bool {
-		return folders[i].CreationTime < folders[j].CreationTime
-	})
+	scan.Inversions, scan.Order = sortFolders(config, folders)
+
+	if len(scan.Unmatched) > 0 {
+		config.logger().Printf("Пропущено папок без версии в имени: %d", len(scan.Unmatched))
+		if config.UnmatchedReportPath != "" {
+			if err := writeUnmatchedReport(config.UnmatchedReportPath, scan.Unmatched); err != nil {
+				return scan, fmt.Errorf("ошибка записи списка пропущенных папок: %v", err)
+			}
+		}
+	}
+
+	if len(scan.Unreadable) > 0 {
+		config.logger().Printf("Предупреждение: %d папок недоступны для чтения (нет прав доступа) и пропущены:", len(scan.Unreadable))
+		for _, path := range scan.Unreadable {
+			config.logger().Printf("  %s", path)
+		}
+	}
 
 	if len(folders) == 0 {
-		return nil, fmt.Errorf("не найдены папки с версиями в %s", config.SourceDir)
+		return scan, fmt.Errorf("не найдены папки с версиями в %s", config.SourceDir)
 	}
+	scan.Folders = folders
 
-	log.Printf("Найдено %d папок с версиями:", len(folders))
+	config.logger().Printf("Найдено %d папок с версиями:", len(folders))
+	fallbacks := 0
 	for i, folder := range folders {
-		log.Printf("  %d. %s (версия: %s, создана: %s)",
+		note := ""
+		if folder.TimeFallback {
+			note = ", дата ненадежна"
+			fallbacks++
+		}
+		config.logger().Printf("  %d. %s (версия: %s, создана: %s%s)",
 			i+1,
-			filepath.Base(folder.Path),
-			folder.Version,
-			time.Unix(folder.CreationTime, 0).Format("2006-01-02 15:04:05"))
+			Printable(filepath.Base(folder.Path)),
+			Printable(folder.Version),
+			time.Unix(folder.CreationTime, 0).Format("2006-01-02 15:04:05"),
+			note)
+	}
+	if fallbacks > 0 {
+		config.logger().Printf("Предупреждение: для %d папок время создания определено запасным способом, "+
+			"их место в истории может быть неверным", fallbacks)
 	}
+	logInversions(scan.Inversions, config.PreferVersionOrder, config.logger())
+
+	return scan, nil
+}
 
-	return folders, nil
+// writeUnmatchedReport записывает пути пропущенных папок, по одному на строку
+func writeUnmatchedReport(path string, unmatched []string) error {
+	return os.WriteFile(path, []byte(strings.Join(unmatched, "\n")+"\n"), 0644)
 }
 
 // MigrateToGit выполняет миграцию папок в Git-репозиторий
+//
+// Deprecated: используйте Converter.Migrate.
 func MigrateToGit(config Config, folders []FolderInfo) error {
+	return MigrateToGitContext(context.Background(), config, folders)
+}
+
+// MigrateToGitContext выполняет миграцию, которую можно прервать через ctx.
+// После отмены текущая папка откатывается к последнему коммиту, а функция
+// возвращает ctx.Err(). Встраивающему коду удобнее Converter.MigrateContext
+func MigrateToGitContext(ctx context.Context, config Config, folders []FolderInfo) error {
+	_, err := migrate(ctx, config, folders)
+	return err
+}
+
+// MigrateToGitResult выполняет миграцию и возвращает её итоги
+//
+// Deprecated: используйте Converter.Migrate.
+func MigrateToGitResult(config Config, folders []FolderInfo) (*MigrationResult, error) {
+	return migrate(context.Background(), config, folders)
+}
+
+// migrate выполняет миграцию, сообщает о ней в webhook и возвращает итоги
+func migrate(ctx context.Context, config Config, folders []FolderInfo) (*MigrationResult, error) {
+	hook, err := newWebhook(config)
+	if err != nil {
+		return &MigrationResult{}, err
+	}
+	src, err := openSource(config)
+	if err != nil {
+		return &MigrationResult{}, err
+	}
+	defer src.Close()
+
+	result, err := migrateWithResult(ctx, src, config, folders, hook)
+	if err == nil && !config.DryRun {
+		removeArchiveSource(config)
+	}
+
+	event := ProgressEvent{Type: EventDone, Result: result}
+	if err != nil {
+		event.Err = err.Error()
+	}
+	hook.send(event)
+	hook.close(hook.closeTimeout())
+
+	return result, err
+}
+
+// migrateWithResult выполняет миграцию, о ходе которой сообщает в hook
+func migrateWithResult(ctx context.Context, src sourceFS, config Config, folders []FolderInfo, hook *webhook) (*MigrationResult, error) {
+	// Исключенные пользователем папки пропускаются и в следующих запусках
+	all := folders
+	folders, skipped, excluded := applyExclusions(config, folders)
+
+	// Версии могли быть исправлены вызывающим кодом после сканирования
+	if !config.MergeSameVersion {
+		logDuplicateVersions(folders, config.logger())
+	}
+
+	if !config.DryRun {
+		if err := checkDiskSpace(src, config, folders); err != nil {
+			return &MigrationResult{}, err
+		}
+		if err := checkTempDir(src, config, folders); err != nil {
+			return &MigrationResult{}, err
+		}
+	}
+
+	// Раскладка без Git объединяет и группирует версии так же, как история
+	if config.NoGit {
+		folders = combineFolders(config, folders)
+		result, err := organizeFolders(ctx, src, config, folders)
+		if result != nil {
+			result.Excluded = folderVersions(skipped)
+			result.Collapsed = collapsedVersions(folders)
+		}
+		return result, err
+	}
+
+	result := &MigrationResult{Excluded: folderVersions(skipped)}
 	if config.DryRun {
-		log.Println("Запущен тестовый режим (dry-run), Git-репозиторий не будет создан")
-		return nil
+		result.Order = auditOrder(config, folders)
+		config.logger().Printf("Запущен тестовый режим (dry-run), Git-репозиторий не будет создан")
+		growth, err := EstimateGrowth(config, folders)
+		if err != nil {
+			return result, fmt.Errorf("ошибка оценки роста репозитория: %v", err)
+		}
+		result.Growth = growth
+		logGrowth(growth, config.logger())
+
+		if config.MergeSameVersion {
+			exclude := make(map[string]bool, len(skipped))
+			for _, folder := range skipped {
+				exclude[folder.Path] = true
+			}
+			all = mergeSameVersion(all, exclude, config.logger())
+		}
+		all = groupByPeriod(all, groupExclusions(config, all, skipped), config.GroupBy, config.logger())
+		result.Collapsed = collapsedVersions(all)
+		result.Plan, err = planMigration(config, all, skipped)
+		if err != nil {
+			return result, fmt.Errorf("ошибка составления плана миграции: %v", err)
+		}
+		for _, commit := range result.Plan {
+			if commit.Warning != "" {
+				config.logger().Printf("Предупреждение: %s", commit.Warning)
+			}
+			if len(commit.LFS) > 0 {
+				config.logger().Printf("Версия %s: в Git LFS попадут файлы (%d): %s",
+					Printable(commit.Version), len(commit.LFS), Printable(strings.Join(commit.LFS, ", ")))
+			}
+		}
+		if config.HookCommand != "" {
+			for _, commit := range result.Plan {
+				if commit.Reason == "" {
+					config.logger().Printf("Перед коммитом версии %s будет выполнена команда: %s", commit.Version, config.HookCommand)
+				}
+			}
+		}
+		return result, nil
+	}
+	folders = combineFolders(config, folders)
+	result.Collapsed = collapsedVersions(folders)
+	result.Order = auditOrder(config, folders)
+	migrateRepo := migrateToGit
+	if config.Bare || isBareRepository(config.TargetDir) {
+		migrateRepo = migrateBare
+	}
+	if err := migrateRepo(ctx, src, config, folders, result, hook); err != nil {
+		return result, err
+	}
+	if err := saveExclusions(config.TargetDir, excluded); err != nil {
+		config.logger().Printf("Предупреждение: не удалось сохранить исключенные папки: %v", err)
+	}
+
+	if config.PushAfterMigrate {
+		// Коммиты уже созданы: ошибка отправки отличается от ошибок миграции
+		if err := PushRepository(config); err != nil {
+			return result, fmt.Errorf("%w: %v", ErrPushFailed, err)
+		}
+		result.Pushed = true
+	}
+	return result, nil
+}
+
+// combineFolders объединяет папки одной версии (MergeSameVersion) и оставляет
+// по одному снимку на период (GroupBy)
+func combineFolders(config Config, folders []FolderInfo) []FolderInfo {
+	if config.MergeSameVersion {
+		folders = mergeSameVersion(folders, nil, config.logger())
+	}
+	return groupByPeriod(folders, groupExclusions(config, folders, nil), config.GroupBy, config.logger())
+}
+
+// migrateToGit создаёт коммиты для каждой папки с версией.
+// Папки, обработка которых не удалась, записываются в result.Failed,
+// события по каждой папке отправляются в hook
+func migrateToGit(ctx context.Context, src sourceFS, config Config, folders []FolderInfo, result *MigrationResult, hook *webhook) error {
+	// Проверяем кодировки до того, как будут затронуты файлы
+	if !isUTF8Encoding(config.MessageEncoding) {
+		if _, err := lookupEncoding(config.MessageEncoding); err != nil {
+			return err
+		}
+	}
+	names, err := newNameDecoder(config.SourceEncoding, config.logger())
+	if err != nil {
+		return err
+	}
+	filter, err := newContentFilter(config)
+	if err != nil {
+		return err
+	}
+	lfs, err := newLFSRules(config)
+	if err != nil {
+		return err
+	}
+	if err := validateTrailers(config.CommitTrailers); err != nil {
+		return err
+	}
+	if err := validateCommitDateSource(config.CommitDateSource); err != nil {
+		return err
+	}
+	if err := validateCommitter(config); err != nil {
+		return err
+	}
+	if err := validateGroupBy(config.GroupBy); err != nil {
+		return err
+	}
+	if err := validateEOLMode(config.NormalizeEOL); err != nil {
+		return err
+	}
+	if err := validateBranch(config.Branch); err != nil {
+		return err
+	}
+	if err := validateRefReplacement(config.RefReplacement); err != nil {
+		return err
+	}
+	// Ключ проверяется до первой папки, чтобы не прерывать миграцию на середине
+	signKey, err := loadSignKey(config)
+	if err != nil {
+		return err
 	}
 
 	// Создаем директорию для репозитория, если её нет
@@ -127,6 +543,12 @@ func MigrateToGit(config Config, folders []FolderInfo) error {
 		return fmt.Errorf("ошибка создания директории: %v", err)
 	}
 
+	// Узнаем заранее, что целевая файловая система не сможет сохранить
+	result.Filesystem, err = checkTargetFilesystem(config)
+	if err != nil {
+		return err
+	}
+
 	// Проверяем существование репозитория
 	gitDir := filepath.Join(config.TargetDir, ".git")
 	repoExists := false
@@ -135,51 +557,69 @@ func MigrateToGit(config Config, folders []FolderInfo) error {
 	}
 
 	var repo *git.Repository
-	var err error
 
 	// Инициализируем или открываем репозиторий
 	if !repoExists && !config.Append {
-		repo, err = git.PlainInit(config.TargetDir, false)
+		// Новый репозиторий сразу создается с нужной веткой вместо master
+		initOptions := &git.PlainInitOptions{}
+		if config.Branch != "" {
+			initOptions.InitOptions.DefaultBranch = plumbing.NewBranchReferenceName(config.Branch)
+		}
+		repo, err = git.PlainInitWithOptions(config.TargetDir, initOptions)
 		if err != nil {
 			return fmt.Errorf("ошибка инициализации репозитория: %v", err)
 		}
-		log.Printf("Инициализирован новый репозиторий в %s", config.TargetDir)
+		result.RepoCreated = true
+		config.logger().Printf("Инициализирован новый репозиторий в %s", config.TargetDir)
 	} else if config.Append && !repoExists {
 		return fmt.Errorf("указан режим --append, но репозиторий не существует в %s", config.TargetDir)
 	} else {
-		repo, err = git.PlainOpen(config.TargetDir)
+		repo, err = openRepository(config.TargetDir)
 		if err != nil {
 			return fmt.Errorf("ошибка открытия репозитория: %v", err)
 		}
-		log.Printf("Открыт существующий репозиторий в %s", config.TargetDir)
+		if isLinkedWorktree(config.TargetDir) {
+			config.logger().Printf("Открыт связанный рабочий каталог (git worktree) в %s", config.TargetDir)
+		} else {
+			config.logger().Printf("Открыт существующий репозиторий в %s", config.TargetDir)
+		}
+
+		// Не смешиваем версии с чужим проектом без явного согласия
+		owned, err := producedByTool(repo, config)
+		if err != nil {
+			return fmt.Errorf("ошибка проверки истории репозитория: %v", err)
+		}
+		if !owned {
+			if !config.Append {
+				return fmt.Errorf("%w: %s содержит чужую историю; укажите пустую директорию", ErrForeignRepository, config.TargetDir)
+			}
+			if !config.AdoptExisting {
+				return fmt.Errorf("%w: %s; чтобы добавить версии поверх его истории, включите AdoptExisting", ErrForeignRepository, config.TargetDir)
+			}
+			config.logger().Printf("Внимание: репозиторий %s создан не конвертером, версии будут добавлены поверх его истории", config.TargetDir)
+		}
+	}
+
+	// Поврежденный после сбоя репозиторий дал бы невнятные ошибки go-git на середине
+	if config.Append && repoExists {
+		if err := checkRepository(repo, false); err != nil {
+			if !config.IgnoreHealthCheck {
+				return fmt.Errorf("%w; чтобы добавить версии несмотря на это, включите IgnoreHealthCheck", err)
+			}
+			config.logger().Printf("Предупреждение: %v; версии добавляются, так как включен IgnoreHealthCheck", err)
+		}
+	}
+
+	if err := markRepository(repo); err != nil {
+		config.logger().Printf("Предупреждение: не удалось пометить репозиторий: %v", err)
 	}
 
 	// Получаем существующие версии, если используется режим добавления
 	existingVersions := make(map[string]bool)
 	if config.Append {
-		refs, err := repo.References()
-		if err != nil {
-			return fmt.Errorf("ошибка получения ссылок: %v", err)
-		}
-		err = refs.ForEach(func(ref *plumbing.Reference) error {
-			if ref.Type() == plumbing.HashReference {
-				commit, err := repo.CommitObject(ref.Hash())
-				if err != nil {
-					return nil
-				}
-				// Извлекаем версию из сообщения коммита
-				if strings.Contains(commit.Message, "Version") {
-					parts := strings.Split(commit.Message, ":")
-					if len(parts) > 0 {
-						version := strings.TrimSpace(strings.TrimPrefix(parts[0], "Version"))
-						existingVersions[version] = true
-					}
-				}
-			}
-			return nil
-		})
+		existingVersions, err = migratedVersions(repo, config.TargetDir)
 		if err != nil {
-			return fmt.Errorf("ошибка при анализе истории: %v", err)
+			return err
 		}
 	}
 
@@ -188,131 +628,799 @@ func MigrateToGit(config Config, folders []FolderInfo) error {
 		return fmt.Errorf("ошибка получения рабочей директории: %v", err)
 	}
 
+	managed := toolManagedPaths(config)
+	if repoExists {
+		if err := checkCleanWorktree(worktree, managed); err != nil {
+			return err
+		}
+	}
+
+	// Коммиты ложатся на выбранную ветку, а не на ту, что сейчас в HEAD
+	if config.Branch != "" && repoExists {
+		if err := checkoutBranch(repo, worktree, config.Branch); err != nil {
+			return fmt.Errorf("ошибка переключения на ветку %s: %v", config.Branch, err)
+		}
+	}
+
+	if config.WorkspaceMode {
+		removeStaleWorkspaces(config)
+	}
+
+	m := &migration{
+		config:   config,
+		src:      src,
+		repo:     repo,
+		worktree: worktree,
+		names:    names,
+		filter:   filter,
+		managed:  managed,
+		result:   result,
+		throttle: newThrottle(config),
+		signKey:  signKey,
+		lfs:      lfs,
+	}
+	if config.HashCache {
+		m.hashes = loadHashCache(config.TargetDir, config.logger())
+		m.hashes.paranoid = config.Paranoid
+		defer m.hashes.save(config.Verbose)
+	}
+
 	// Обрабатываем каждую папку
-	for _, folder := range folders {
+	for i, folder := range folders {
+		if err := ctx.Err(); err != nil {
+			config.logger().Printf("Миграция прервана перед версией %s", Printable(folder.Version))
+			return err
+		}
+		if config.OnProgress != nil {
+			config.OnProgress(i+1, len(folders), folder)
+		}
+
 		// Пропускаем существующие версии в режиме добавления
 		if config.Append && existingVersions[folder.Version] {
-			log.Printf("Пропуск версии %s, так как она уже существует в репозитории", folder.Version)
+			config.logger().Printf("Пропуск версии %s, так как она уже существует в репозитории", Printable(folder.Version))
+			result.SkippedVersions = append(result.SkippedVersions, folder.Version)
+			continue
+		}
+
+		// Папка могла исчезнуть после сканирования: без Strict это не ошибка миграции
+		if err := checkSourceFolder(m.src, folder); err != nil {
+			if config.Strict {
+				return fmt.Errorf("%s: %v", StageVanished, err)
+			}
+			config.logger().Printf("Предупреждение: версия %s пропущена, %s: %v", Printable(folder.Version), StageVanished, err)
+			result.Vanished = append(result.Vanished, FolderFailure{
+				Version: folder.Version,
+				Path:    folder.Path,
+				Stage:   StageVanished,
+				Err:     err.Error(),
+			})
+			hook.send(ProgressEvent{Type: EventFailed, Version: folder.Version, Path: folder.Path, Err: err.Error()})
+			continue
+		}
+
+		config.logger().Printf("Обработка папки: %s (версия: %s)", Printable(filepath.Base(folder.Path)), Printable(folder.Version))
+
+		head, err := m.head()
+		if err != nil {
+			return fmt.Errorf("ошибка чтения HEAD: %v", err)
+		}
+
+		if config.IdlePriority && i > 0 {
+			time.Sleep(idlePause)
+		}
+		started := time.Now()
+		var record CommitRecord
+		stage, err := runFolder(ctx, config.FolderTimeout, func(ctx context.Context, task *folderTask) error {
+			// Запись читается только после успешного завершения, когда горутина уже отработала
+			defer func() { record = task.createdCommit() }()
+			ctx = withThrottle(ctx, m.throttle)
+
+			// Версия с удаленного источника загружается на время своего коммита
+			if _, remote := m.src.(*sftpSource); remote {
+				task.setStage("загрузка версии")
+			}
+			source, cleanup, err := fetchFolder(ctx, m.src, config, folder)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+			if len(source.MergedPaths) < 2 {
+				return m.migrateFolder(ctx, task, source, i+1)
+			}
+
+			// Части версии собираются в одну папку, которая переносится как обычная
+			task.setStage("объединение папок версии")
+			dir, conflicts, err := stageMergedVersion(ctx, config, source)
+			if err != nil {
+				return fmt.Errorf("ошибка объединения папок версии: %v", err)
+			}
+			defer os.RemoveAll(filepath.Dir(dir))
+			if len(conflicts) > 0 {
+				m.warn(folder, "в нескольких папках версии %s есть одни и те же файлы (%d), взяты из более поздних: %s",
+					folder.Version, len(conflicts), strings.Join(firstN(conflicts, verifyExamples), ", "))
+			}
+			staged := source
+			staged.Path = dir
+			return m.migrateFolder(ctx, task, staged, i+1)
+		})
+		if m.throttle != nil {
+			m.logThroughput(time.Since(started))
+		}
+		if err == errNoChanges {
+			result.Unchanged = append(result.Unchanged, folder.Version)
+			continue
+		}
+		if err != nil && ctx.Err() != nil {
+			// Отмена не ошибка папки: откатываем недоделанную версию и останавливаемся
+			if restoreErr := m.restore(head); restoreErr != nil {
+				return fmt.Errorf("ошибка восстановления рабочей директории: %v", restoreErr)
+			}
+			config.logger().Printf("Миграция прервана на версии %s, рабочая директория возвращена к последнему коммиту", Printable(folder.Version))
+			return ctx.Err()
+		}
+		if err == nil {
+			if record.Hash != "" {
+				// Объединенная версия переносилась из временной папки
+				record.Path = folder.Path
+				result.Commits = append(result.Commits, record)
+				result.CommitsCreated += record.Parts
+			}
+			event := ProgressEvent{Type: EventFolder, Version: folder.Version, Path: folder.Path}
+			if commit, err := m.head(); err == nil && !commit.IsZero() {
+				event.Commit = commit.String()
+			}
+			hook.send(event)
 			continue
 		}
 
-		log.Printf("Обработка папки: %s (версия: %s)", filepath.Base(folder.Path), folder.Version)
+		result.Failed = append(result.Failed, FolderFailure{
+			Version:  folder.Version,
+			Path:     folder.Path,
+			Stage:    stage,
+			Err:      err.Error(),
+			TimedOut: errors.Is(err, ErrFolderTimeout),
+		})
+		config.logger().Printf("Не удалось обработать версию %s: %v", Printable(folder.Version), err)
+		hook.send(ProgressEvent{Type: EventFailed, Version: folder.Version, Path: folder.Path, Err: err.Error()})
+
+		// Незавершенная версия не должна попасть в следующий коммит
+		if restoreErr := m.restore(head); restoreErr != nil {
+			return fmt.Errorf("ошибка восстановления рабочей директории: %v", restoreErr)
+		}
+
+		if config.ErrorPolicy != ErrorPolicySkip {
+			return err
+		}
+	}
+
+	manifest := migrationManifest{
+		MigratedAt: time.Now(),
+		SourceDir:  config.SourceDir,
+		Filesystem: result.Filesystem,
+		Settings:   settingsOf(config),
+		Order:      result.Order,
+		Collapsed:  collapsedSnapshots(folders),
+	}
+	// Свернутые снимки прошлых запусков тоже должны оставаться в манифесте
+	if config.Append {
+		if previous, err := readManifest(config.TargetDir); err == nil {
+			manifest.Collapsed = append(previous.Collapsed, manifest.Collapsed...)
+		}
+	}
+	if config.WebhookURL != "" {
+		manifest.Webhook = redactURL(config.WebhookURL)
+	}
+	if err := writeManifest(config.TargetDir, manifest); err != nil {
+		config.logger().Printf("Предупреждение: не удалось записать манифест миграции: %v", err)
+	}
+
+	return nil
+}
 
+// repoVersions возвращает версии, коммиты которых находятся на вершинах ссылок репозитория
+func repoVersions(repo *git.Repository) (map[string]bool, error) {
+	versions := make(map[string]bool)
+	refs, err := repo.References()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения ссылок: %v", err)
+	}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.HashReference {
+			commit, err := repo.CommitObject(ref.Hash())
+			if err != nil {
+				return nil
+			}
+			// Извлекаем версию из сообщения коммита
+			if strings.Contains(commit.Message, "Version") {
+				parts := strings.Split(commit.Message, ":")
+				if len(parts) > 0 {
+					version := strings.TrimSpace(strings.TrimPrefix(parts[0], "Version"))
+					versions[version] = true
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при анализе истории: %v", err)
+	}
+	return versions, nil
+}
+
+// skipsUnchanged проверяет, что версии без изменений пропускаются: задан
+// SkipUnchanged или его синоним SkipEmptyCommits
+func skipsUnchanged(config Config) bool {
+	return config.SkipUnchanged || config.SkipEmptyCommits
+}
+
+// errNoChanges возвращается migrateFolder, если версия ничего не меняет и коммит не создан
+var errNoChanges = errors.New("версия не содержит изменений")
+
+// migration содержит общие для всех папок объекты миграции в Git
+type migration struct {
+	config   Config
+	src      sourceFS // Источник версий: локальный или SFTP
+	repo     *git.Repository
+	worktree *git.Worktree
+	names    *nameDecoder
+	filter   *contentFilter
+	managed  toolPaths  // Файлы, которые создает сам конвертер
+	hashes   *hashCache // Кэш хешей файлов (nil, если выключен)
+	lfs      *lfsRules  // Правила Git LFS (nil, если LFS не используется)
+	throttle *throttle  // Ограничение скорости копирования (nil, если выключено)
+	result   *MigrationResult
+
+	// signKey подписывает коммиты и теги (nil — без подписи)
+	signKey *openpgp.Entity
+
+	// gitMu не дает брошенной по таймауту обработке папки менять индекс
+	// и ссылки одновременно с восстановлением рабочей директории
+	gitMu sync.Mutex
+	// warnMu защищает список предупреждений в result
+	warnMu sync.Mutex
+}
+
+// warn пишет предупреждение в лог и запоминает его в итогах миграции для папки
+func (m *migration) warn(folder FolderInfo, format string, args ...any) {
+	msg := escapeControl(fmt.Sprintf(format, args...))
+	m.config.logger().Printf("Предупреждение: %s", msg)
+	m.addWarning(folder, msg)
+}
+
+// addWarning запоминает предупреждение папки, уже выведенное в лог
+func (m *migration) addWarning(folder FolderInfo, msg string) {
+	m.warnMu.Lock()
+	defer m.warnMu.Unlock()
+	path := folder.Path
+	if len(folder.MergedPaths) > 0 {
+		// Объединенная версия обрабатывается во временной папке
+		path = folder.MergedPaths[0]
+	}
+	m.result.Warnings = append(m.result.Warnings, FolderWarning{Version: folder.Version, Path: path, Message: escapeControl(msg)})
+}
+
+// migrateFolder переносит одну папку в рабочую директорию и создает коммит версии
+func (m *migration) migrateFolder(ctx context.Context, task *folderTask, folder FolderInfo, index int) error {
+	config := m.config
+	names := m.names
+
+	var fileCount int
+	var newFiles []string
+	var changes changeSet
+	var err error
+	modes := make(fileModes)
+
+	// Повторный запуск в режиме добавления не должен плодить пустые коммиты,
+	// а с SkipUnchanged не нужны и коммиты версий, повторяющих предыдущую
+	if config.Append || (skipsUnchanged(config) && !config.KeepVersionDir) {
+		task.setStage("сравнение с последним коммитом")
+		same, err := folderMatchesHead(m.repo, folder.Path, names, m.filter, m.managed, config.NormalizeEOL, m.lfs, m.hashes)
+		if err != nil {
+			return fmt.Errorf("ошибка сравнения с последним коммитом: %v", err)
+		}
+		if same {
+			config.logger().Printf("Пропуск версии %s: нет изменений", Printable(folder.Version))
+			return errNoChanges
+		}
+	}
+
+	// Счетчик пропусков мог вырасти при сравнении с последним коммитом
+	m.filter.takeSkips()
+
+	if config.KeepVersionDir {
+		// Каждая версия лежит в своей папке, остальные версии не трогаем
+		prefix := names.repoPath(filepath.Base(folder.Path))
+		versionDir := filepath.Join(config.TargetDir, prefix)
+		task.setStage("очистка папки версии")
+		if err := clearDirectoryIn(config.TargetDir, versionDir, m.managed, config.logger()); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("ошибка очистки директории: %v", err)
+		}
+
+		task.setStage("копирование файлов")
+		versionModes := make(fileModes)
+		fileCount, newFiles, err = copyFilesAndTrack(ctx, folder.Path, versionDir, false, false, names, m.filter, versionModes, config.logger())
+		if err != nil {
+			return fmt.Errorf("ошибка копирования файлов: %v", err)
+		}
+		// Права записаны относительно папки версии, а индексу нужны пути от корня
+		for rel, mode := range versionModes {
+			modes[filepath.ToSlash(filepath.Join(prefix, rel))] = mode
+		}
+		if len(newFiles) == 0 {
+			config.logger().Printf("В папке %s не найдено файлов для добавления, коммит не создается", Printable(filepath.Base(folder.Path)))
+			return errNoChanges
+		}
+	} else if config.Incremental {
+		// Переносим только отличия от текущего содержимого рабочей директории
+		task.setStage("синхронизация файлов")
+		changes, err = syncIncremental(ctx, folder.Path, config.TargetDir, names, m.filter, modes, m.managed, m.hashes, m.lfs)
+		if err != nil {
+			return fmt.Errorf("ошибка синхронизации файлов: %v", err)
+		}
+		if changes.Empty() {
+			config.logger().Printf("Пропуск версии %s: нет изменений", Printable(folder.Version))
+			return errNoChanges
+		}
+		fileCount = changes.Total
+		config.logger().Printf("Изменения: добавлено %d, изменено %d, удалено %d",
+			len(changes.Added), len(changes.Modified), len(changes.Deleted))
+	} else if config.WorkspaceMode && !config.Append {
+		// Версия собирается во временной папке и переносится в рабочую
+		// директорию одним шагом, пока папка не готова, там лежит прошлая версия
+		task.setStage("копирование файлов")
+		workspace, err := makeWorkspace(config)
+		if err != nil {
+			return fmt.Errorf("ошибка создания временной папки: %v", err)
+		}
+		defer os.RemoveAll(workspace)
+
+		var staged []string
+		fileCount, staged, err = copyFilesAndTrack(ctx, folder.Path, workspace, false, false, names, m.filter, modes, config.logger())
+		if err != nil {
+			return fmt.Errorf("ошибка копирования файлов: %v", err)
+		}
+		if len(staged) == 0 {
+			config.logger().Printf("В папке %s не найдено файлов для добавления, коммит не создается", Printable(filepath.Base(folder.Path)))
+			return errNoChanges
+		}
+
+		m.gitMu.Lock()
+		err = ctx.Err()
+		if err == nil {
+			task.setStage("перенос в рабочую директорию")
+			if err = clearDirectory(config.TargetDir, m.managed, config.logger()); err == nil {
+				err = moveTree(workspace, config.TargetDir)
+			}
+		}
+		m.gitMu.Unlock()
+		if err != nil {
+			return fmt.Errorf("ошибка переноса версии в рабочую директорию: %v", err)
+		}
+
+		for _, file := range staged {
+			rel, err := filepath.Rel(workspace, file)
+			if err != nil {
+				return err
+			}
+			newFiles = append(newFiles, filepath.Join(config.TargetDir, rel))
+		}
+	} else {
 		// Очищаем рабочую директорию только если не в режиме добавления (append)
 		if !config.Append {
-			if err := clearDirectory(config.TargetDir); err != nil {
+			task.setStage("очистка рабочей директории")
+			if err := clearDirectory(config.TargetDir, m.managed, config.logger()); err != nil {
 				return fmt.Errorf("ошибка очистки директории: %v", err)
 			}
+		} else {
+			// Файлы добавляются поверх прошлой версии, удалить их нельзя
+			missing, err := filesMissingInSource(m.repo, folder.Path, names, m.filter, m.managed)
+			if err != nil {
+				return fmt.Errorf("ошибка сравнения с последним коммитом: %v", err)
+			}
+			if len(missing) > 0 {
+				m.warn(folder, "в режиме добавления не переносится удаление %d файлов, например %s", len(missing), missing[0])
+			}
 		}
 
 		// Копируем файлы и получаем список новых файлов
-		fileCount, newFiles, err := copyFilesAndTrack(folder.Path, config.TargetDir, config.Append)
+		task.setStage("копирование файлов")
+		fileCount, newFiles, err = copyFilesAndTrack(ctx, folder.Path, config.TargetDir, config.Append, !config.IgnoreModeChanges, names, m.filter, modes, config.logger())
 		if err != nil {
 			return fmt.Errorf("ошибка копирования файлов: %v", err)
 		}
 
-		if fileCount == 0 {
-			log.Printf("В папке %s не найдено файлов для добавления", filepath.Base(folder.Path))
+		if len(newFiles) == 0 {
+			config.logger().Printf("В папке %s не найдено файлов для добавления, коммит не создается", Printable(filepath.Base(folder.Path)))
+			return errNoChanges
+		}
+	}
+
+	appleDouble, skippedFiles := m.filter.takeSkips()
+	if appleDouble > 0 {
+		msg := fmt.Sprintf(appleDoubleMessage, Printable(filepath.Base(folder.Path)), appleDouble)
+		config.logger().Printf("%s", msg)
+		m.addWarning(folder, msg)
+	}
+	for _, msg := range skippedFiles {
+		m.addWarning(folder, "Пропущен "+msg)
+	}
+
+	if config.HookCommand != "" {
+		task.setStage("команда перед коммитом")
+		if err := runHookCommand(ctx, config, folder, index); err != nil {
+			return fmt.Errorf("ошибка команды перед коммитом: %v", err)
+		}
+	}
+
+	authorName, authorEmail := resolveAuthor(config, folder.Version)
+	authorName, _ = names.decode(authorName)
+
+	// Имя папки может быть в старой кодировке, в сообщение попадает только UTF-8
+	folderName, _ := names.decode(filepath.Base(folder.Path))
+
+	// Формируем сообщение коммита
+	commitMsg := collapsedMessage(mergedMessage(commitMessage(config, names, folder, folderName, fileCount, authorName), folder), folder)
+	expand := func(s string) string {
+		return expandPlaceholders(s, folder, folderName, fileCount, authorName)
+	}
+
+	// После истечения времени обработки индекс и ссылки уже не трогаем
+	m.gitMu.Lock()
+	defer m.gitMu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Файлы, которые нужно добавить в индекс: изменения или только новые файлы
+	var paths []string
+	if config.Incremental && !config.KeepVersionDir {
+		paths = append(append(paths, changes.Added...), changes.Modified...)
+	}
+	for _, file := range newFiles {
+		relPath, err := filepath.Rel(config.TargetDir, file)
+		if err != nil {
+			m.warn(folder, "не удалось получить относительный путь для %s: %v", file, err)
 			continue
 		}
+		paths = append(paths, relPath)
+	}
+
+	// Концы строк приводятся до переноса в LFS: указатели считаются по
+	// содержимому, которое попадет в историю
+	if normalizesEOL(config.NormalizeEOL) {
+		task.setStage("приведение концов строк")
+		changed, err := normalizeLineEndings(config, paths)
+		if err != nil {
+			return fmt.Errorf("ошибка приведения концов строк: %v", err)
+		}
+		if changed > 0 {
+			config.logger().Printf("Концы строк приведены к %s в файлах: %d", strings.ToUpper(string(config.NormalizeEOL)), changed)
+		}
+	}
 
-		// Получаем информацию об авторе из файла, если он указан
-		authorName := config.Author
-		authorEmail := config.Email
-		if config.AuthorsFile != "" {
-			if name, email, err := getAuthorInfo(folder.Version, config.AuthorsFile); err == nil && name != "" && email != "" {
-				authorName = name
-				authorEmail = email
+	// Большие файлы заменяются указателями до подсчета объема: в истории
+	// останутся только указатели
+	if m.lfs != nil {
+		task.setStage("перенос файлов в Git LFS")
+		stored, err := convertToLFS(config, m.lfs, paths)
+		if err != nil {
+			return fmt.Errorf("ошибка переноса файла в Git LFS: %v", err)
+		}
+		if len(stored) > 0 {
+			config.logger().Printf("В Git LFS перенесено файлов: %d", len(stored))
+		}
+	}
+
+	// Проверяем объем версии до первого коммита, чтобы в режиме Strict
+	// нарушение не попало в историю
+	if config.WarnCommitSize > 0 {
+		task.setStage("проверка объема версии")
+		added, err := addedFiles(m.repo, config.TargetDir, paths)
+		if err != nil {
+			return fmt.Errorf("ошибка подсчета объема версии: %v", err)
+		}
+		if msg := checkBudget(folder.Version, added, config.WarnCommitSize); msg != "" {
+			if config.Strict {
+				return fmt.Errorf("%s", msg)
 			}
+			m.warn(folder, "%s", msg)
+		}
+	}
+
+	// Слишком большую версию записываем несколькими коммитами
+	parts := [][]string{paths}
+	if config.MaxCommitSize > 0 {
+		parts, err = splitCommitParts(config.TargetDir, paths, config.MaxCommitSize)
+		if err != nil {
+			return fmt.Errorf("ошибка разбиения версии на части: %v", err)
+		}
+		if len(parts) > 1 {
+			config.logger().Printf("Версия %s больше %s и будет записана %d коммитами",
+				Printable(folder.Version), FormatSize(config.MaxCommitSize), len(parts))
 		}
+	}
 
-		// Формируем сообщение коммита
-		var commitMsg string
-		if config.MessageTemplate != "" {
-			commitMsg = strings.ReplaceAll(config.MessageTemplate, "{version}", folder.Version)
-			commitMsg = strings.ReplaceAll(commitMsg, "{folder}", filepath.Base(folder.Path))
-			commitMsg = strings.ReplaceAll(commitMsg, "{date}", time.Unix(folder.CreationTime, 0).Format("2006-01-02 15:04:05"))
-			commitMsg = strings.ReplaceAll(commitMsg, "{files}", fmt.Sprintf("%d", fileCount))
-			commitMsg = strings.ReplaceAll(commitMsg, "{author}", authorName)
-		} else {
-			commitMsg = fmt.Sprintf("Version %s: %s (created: %s)",
-				folder.Version,
-				filepath.Base(folder.Path),
-				time.Unix(folder.CreationTime, 0).Format("2006-01-02 15:04:05"))
+	// Блобы неизменившихся файлов берутся из кэша хешей по их источникам.
+	// Команда перед коммитом может менять копии, тогда хеши считаются заново
+	var sources map[string]string
+	if m.hashes != nil && config.HookCommand == "" {
+		prefix := ""
+		if config.KeepVersionDir {
+			prefix = names.repoPath(filepath.Base(folder.Path))
+		}
+		sources, err = versionSources(folder.Path, prefix, names, m.filter, m.managed, m.lfs)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения файлов версии: %v", err)
 		}
+	}
+
+	var commit plumbing.Hash
+	committed := false
+	for i, part := range parts {
+		final := i == len(parts)-1
 
-		// Добавляем только новые файлы в индекс
-		for _, file := range newFiles {
-			relPath, err := filepath.Rel(config.TargetDir, file)
+		// Добавляем файлы части в индекс одним обновлением индекса
+		task.setStage("добавление в индекс")
+		stager, err := newIndexStager(m.repo, config.TargetDir, modes)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения индекса: %v", err)
+		}
+		stager.reuseFrom(m.hashes, sources)
+		if i == 0 && config.Incremental && !config.KeepVersionDir {
+			stageDeletions(stager, config.TargetDir, changes.Deleted, config.logger())
+		}
+		if i == 0 && (!config.Append || config.KeepVersionDir) {
+			// Файлы прошлой версии, которых нет в этой, удаляются из индекса
+			if removed := stager.removeMissing(m.managed); len(removed) > 0 {
+				config.logger().Printf("Удалено файлов, которых нет в версии %s: %d", Printable(folder.Version), len(removed))
+			}
+		}
+		for _, rel := range part {
+			if err := stager.add(rel); err != nil {
+				m.warn(folder, "не удалось добавить файл %s: %v", rel, err)
+			}
+		}
+		if final && config.WriteGitignore {
+			// .gitignore попадает в первый коммит репозитория
+			head, err := m.head()
 			if err != nil {
-				log.Printf("Предупреждение: не удалось получить относительный путь для %s: %v", file, err)
-				continue
+				return fmt.Errorf("ошибка чтения HEAD: %v", err)
+			}
+			if head.IsZero() {
+				if err := writeGitignore(config, folder.Path); err != nil {
+					return fmt.Errorf("ошибка записи .gitignore: %v", err)
+				}
+				if err := stager.add(gitignoreFile); err != nil {
+					return fmt.Errorf("ошибка добавления .gitignore: %v", err)
+				}
 			}
-			_, err = worktree.Add(relPath)
+		}
+		if final && config.WriteEOLAttributes {
+			// Правило концов строк попадает в первый коммит репозитория
+			head, err := m.head()
 			if err != nil {
-				log.Printf("Предупреждение: не удалось добавить файл %s: %v", relPath, err)
+				return fmt.Errorf("ошибка чтения HEAD: %v", err)
+			}
+			if head.IsZero() {
+				if err := writeEOLAttributes(config.TargetDir, config.NormalizeEOL); err != nil {
+					return fmt.Errorf("ошибка записи %s: %v", attributesFile, err)
+				}
+				if err := stager.add(attributesFile); err != nil {
+					return fmt.Errorf("ошибка добавления %s: %v", attributesFile, err)
+				}
+			}
+		}
+		if final && m.lfs != nil {
+			// Правила LFS попадают в первый коммит и дополняются по мере появления больших файлов
+			if _, err := os.Stat(filepath.Join(config.TargetDir, attributesFile)); err == nil {
+				if err := stager.add(attributesFile); err != nil {
+					return fmt.Errorf("ошибка добавления %s: %v", attributesFile, err)
+				}
 			}
 		}
+		if final && config.WriteMetadataFile {
+			meta := newVersionMetadata(folder, folderName, fileCount, changes)
+			if err := writeMetadataFile(config, meta); err != nil {
+				return fmt.Errorf("ошибка записи файла метаданных: %v", err)
+			}
+			if err := stager.add(metadataPath(config)); err != nil {
+				return fmt.Errorf("ошибка добавления файла метаданных: %v", err)
+			}
+		}
+		if err := stager.flush(); err != nil {
+			return fmt.Errorf("ошибка записи индекса: %v", err)
+		}
+
+		// Полное сообщение версии получает только последняя часть
+		// Длинная первая строка переносится в тело до трейлеров, чтобы они остались в конце
+		msg := appendTrailers(wrapSubject(commitMsg, subjectLimit(config)), config.CommitTrailers, expand)
+		task.setStage("создание коммита")
+		if !final {
+			msg = partMessage(config, folder, folderName, commitMsg, i+1, len(parts))
+			msg = appendTrailers(wrapSubject(msg, subjectLimit(config)), config.CommitTrailers, expand)
+			task.setStage(fmt.Sprintf("создание коммита (часть %d/%d)", i+1, len(parts)))
+		}
 
 		// Создаем коммит
-		commit, err := worktree.Commit(commitMsg, &git.CommitOptions{
+		authored := time.Unix(folder.CreationTime, 0)
+		options := &git.CommitOptions{
 			Author: &object.Signature{
 				Name:  authorName,
 				Email: authorEmail,
-				When:  time.Unix(folder.CreationTime, 0),
+				When:  authored,
 			},
-		})
+			Committer: committerSignature(config, authorName, authorEmail, authored),
+			SignKey:   m.signKey,
+		}
+		commit, err = m.worktree.Commit(msg, options)
 
+		// Промежуточная часть из одних неизменившихся файлов коммит не создает,
+		// последняя создается в любом случае: на нее ставятся тег и метаданные
+		if err == git.ErrEmptyCommit && !final {
+			config.logger().Printf("Часть %d/%d версии %s не меняет файлов, коммит не создается", i+1, len(parts), Printable(folder.Version))
+			continue
+		}
+
+		// Дерево индекса совпало с деревом последнего коммита: в режиме
+		// добавления существующие файлы не перезаписываются, а версия могла
+		// повторить предыдущую. Без SkipUnchanged у каждой версии свой коммит
+		if err == git.ErrEmptyCommit {
+			if !committed && (config.Append || skipsUnchanged(config)) {
+				config.logger().Printf("Пропуск версии %s: нет изменений", Printable(folder.Version))
+				return errNoChanges
+			}
+			options.AllowEmptyCommits = true
+			commit, err = m.worktree.Commit(msg, options)
+		}
 		if err != nil {
 			return fmt.Errorf("ошибка создания коммита: %v", err)
 		}
+		committed = true
+
+		commit, err = applyMessageEncoding(m.repo, commit, config.MessageEncoding, m.signKey)
+		if err != nil {
+			return fmt.Errorf("ошибка перекодирования коммита: %v", err)
+		}
 
-		log.Printf("Создан коммит %s для версии %s", commit.String(), folder.Version)
+		if final {
+			config.logger().Printf("Создан коммит %s для версии %s", commit.String(), Printable(folder.Version))
+		} else {
+			config.logger().Printf("Создан коммит %s для части %d/%d версии %s", commit.String(), i+1, len(parts), Printable(folder.Version))
+		}
 	}
 
+	// Предупреждения о пропущенных файлах легко не заметить в логе, поэтому
+	// сверяем итоговое дерево с папкой версии
+	task.setStage("проверка коммита")
+	prefix := ""
+	if config.KeepVersionDir {
+		prefix = names.repoPath(filepath.Base(folder.Path))
+	}
+	// Команда перед коммитом и приведение концов строк могут менять файлы,
+	// их размеры не сверяются
+	checkSizes := !config.Append && config.HookCommand == "" && !normalizesEOL(config.NormalizeEOL)
+	check, err := verifyCommit(m.repo, commit, folder.Path, prefix, names, m.filter, m.lfs, checkSizes)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки коммита: %v", err)
+	}
+	if len(check.Collisions) > 0 && m.result.Filesystem != nil && !m.result.Filesystem.CaseSensitive {
+		m.warn(folder, "имена файлов различаются только регистром, в рабочей директории сохранится один из них: %s",
+			strings.Join(firstN(check.Collisions, verifyExamples), ", "))
+	}
+	if n := check.problems(); n > config.VerifyTolerance {
+		return fmt.Errorf("%w: коммит %s, папка %s: %s", ErrVerificationFailed, commit.String(), Printable(folderName), check)
+	} else if n > 0 {
+		m.warn(folder, "коммит версии %s не совпадает с папкой: %s", folder.Version, check)
+	}
+
+	if config.AnnotatedTags || config.CreateTags {
+		task.setStage("создание тега")
+		var err error
+		if config.AnnotatedTags || knownAuthor(config, folder.Version) {
+			tagger := object.Signature{Name: authorName, Email: authorEmail, When: time.Unix(folder.CreationTime, 0)}
+			err = createVersionTag(m.repo, config, folder, commit, tagger, m.signKey)
+		} else {
+			err = createLightweightTag(m.repo, config, folder, commit)
+		}
+		switch {
+		case err == git.ErrTagExists && config.TagConflictPolicy == TagConflictFail:
+			return fmt.Errorf("тег %s уже существует", versionTagName(config, folder.Version))
+		case err == git.ErrTagExists:
+			m.warn(folder, "тег %s уже существует, версия %s не помечена", versionTagName(config, folder.Version), folder.Version)
+		case err != nil:
+			m.warn(folder, "не удалось создать тег для версии %s: %v", folder.Version, err)
+		}
+	}
+
+	task.setCommit(CommitRecord{
+		Version:   folder.Version,
+		Path:      folder.Path,
+		Hash:      commit.String(),
+		FileCount: fileCount,
+		Parts:     len(parts),
+	})
 	return nil
 }
 
-// clearDirectory удаляет все файлы и папки в указанной директории, кроме .git и системных директорий
-func clearDirectory(dir string) error {
-	// Список системных директорий и файлов, которые нужно игнорировать
-	systemDirs := map[string]bool{
-		".git":         true,
-		".Trash":       true,
-		".Trashes":     true,
-		".config":      true,
-		".cache":       true,
-		".local":       true,
-		"Library":      true,
-		"Applications": true,
-		"System":       true,
-		"Users":        true,
-		"bin":          true,
-		"etc":          true,
-		"usr":          true,
-		"var":          true,
-		"tmp":          true,
-		"opt":          true,
-	}
-
-	// Проверяем, не является ли директория системной
-	baseName := filepath.Base(dir)
-	if systemDirs[baseName] {
-		return nil // Пропускаем системные директории
-	}
-
-	// Проверяем, не находится ли директория в домашней директории пользователя
+// head возвращает коммит, на который указывает HEAD, или нулевой хеш в пустом репозитории
+func (m *migration) head() (plumbing.Hash, error) {
+	ref, err := m.repo.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		return plumbing.ZeroHash, nil
+	}
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return ref.Hash(), nil
+}
+
+// restore возвращает индекс, рабочую директорию и ветку к коммиту head,
+// сделанному до обработки неудавшейся папки
+func (m *migration) restore(head plumbing.Hash) error {
+	m.gitMu.Lock()
+	defer m.gitMu.Unlock()
+
+	if !head.IsZero() {
+		return m.worktree.Reset(&git.ResetOptions{Commit: head, Mode: git.HardReset})
+	}
+
+	// Коммитов еще нет: очищаем индекс и скопированные файлы
+	if err := m.repo.Storer.SetIndex(&index.Index{Version: 2}); err != nil {
+		return err
+	}
+	if m.config.Append {
+		return nil
+	}
+	return clearDirectory(m.config.TargetDir, m.managed, m.config.logger())
+}
+
+// clearDirectory удаляет все файлы и папки в указанной директории, кроме .git,
+// системных директорий и файлов, которые создает сам конвертер (managed)
+func clearDirectory(dir string, managed toolPaths, logger Logger) error {
+	return clearDirectoryIn(dir, dir, managed, logger)
+}
+
+// systemDirs — системные директории и файлы, которые очистка не трогает
+var systemDirs = map[string]bool{
+	".git":         true,
+	".Trash":       true,
+	".Trashes":     true,
+	".config":      true,
+	".cache":       true,
+	".local":       true,
+	"Library":      true,
+	"Applications": true,
+	"System":       true,
+	"Users":        true,
+	"bin":          true,
+	"etc":          true,
+	"usr":          true,
+	"var":          true,
+	"tmp":          true,
+	"opt":          true,
+}
+
+// keepsDirectory проверяет, что очистка не заходит в директорию dir:
+// системную или скрытую в домашней директории пользователя
+func keepsDirectory(dir string) bool {
+	if systemDirs[filepath.Base(dir)] {
+		return true
+	}
 	homeDir, err := os.UserHomeDir()
-	if err == nil {
-		// Если директория находится в домашней директории и начинается с точки (скрытая),
-		// то пропускаем её
-		if strings.HasPrefix(dir, homeDir) {
-			relPath, err := filepath.Rel(homeDir, dir)
-			if err == nil && strings.HasPrefix(relPath, ".") && !strings.Contains(relPath, "..") {
-				return nil
-			}
+	if err == nil && strings.HasPrefix(dir, homeDir) {
+		relPath, err := filepath.Rel(homeDir, dir)
+		if err == nil && strings.HasPrefix(relPath, ".") && !strings.Contains(relPath, "..") {
+			return true
 		}
 	}
+	return false
+}
+
+// clearDirectoryIn очищает директорию dir внутри корня рабочей директории root
+func clearDirectoryIn(root, dir string, managed toolPaths, logger Logger) error {
+	if keepsDirectory(dir) {
+		return nil
+	}
 
 	entries, err := os.ReadDir(dir)
 	if err != nil {
@@ -327,6 +1435,15 @@ func clearDirectory(dir string) error {
 
 		path := filepath.Join(dir, entry.Name())
 
+		// Файлы конвертера перезаписываются им же, удалять их не нужно
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if managed.has(rel) {
+			continue
+		}
+
 		// Проверяем, не является ли файл символической ссылкой
 		fileInfo, err := os.Lstat(path)
 		if err != nil {
@@ -341,22 +1458,25 @@ func clearDirectory(dir string) error {
 		// Используем более безопасный подход к удалению файлов
 		if entry.IsDir() {
 			// Для директорий сначала рекурсивно удаляем содержимое
-			if err := clearDirectory(path); err != nil {
+			if err := clearDirectoryIn(root, path, managed, logger); err != nil {
 				// Если не удалось очистить поддиректорию, просто логируем ошибку и продолжаем
-				log.Printf("Предупреждение: %v", err)
+				logger.Printf("Предупреждение: %v", err)
+				continue
+			}
+			if managed.containsUnder(rel) {
 				continue
 			}
 			// Затем удаляем саму директорию
 			if err := os.Remove(path); err != nil {
 				// Если не удалось удалить директорию, просто логируем ошибку и продолжаем
-				log.Printf("Предупреждение: не удалось удалить директорию %s: %v", path, err)
+				logger.Printf("Предупреждение: не удалось удалить директорию %s: %v", path, err)
 				continue
 			}
 		} else {
 			// Для файлов просто удаляем
 			if err := os.Remove(path); err != nil {
 				// Если не удалось удалить файл, просто логируем ошибку и продолжаем
-				log.Printf("Предупреждение: не удалось удалить файл %s: %v", path, err)
+				logger.Printf("Предупреждение: не удалось удалить файл %s: %v", path, err)
 				continue
 			}
 		}
@@ -364,17 +1484,67 @@ func clearDirectory(dir string) error {
 	return nil
 }
 
-// copyFilesAndTrack копирует файлы из исходной директории в целевую и возвращает список новых файлов
-func copyFilesAndTrack(src, dst string, appendMode bool) (int, []string, error) {
+// clearableContents подсчитывает файлы и их размер, которые удалит
+// clearDirectoryIn(root, dir, managed): обход пропускает то же, что и очистка
+func clearableContents(root, dir string, managed toolPaths) (int, int64, error) {
+	if keepsDirectory(dir) {
+		return 0, 0, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	count, size := 0, int64(0)
+	for _, entry := range entries {
+		if systemDirs[entry.Name()] {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return 0, 0, err
+		}
+		if managed.has(rel) {
+			continue
+		}
+		info, err := os.Lstat(path)
+		if err != nil {
+			return 0, 0, fmt.Errorf("не удалось получить информацию о файле %s: %v", path, err)
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			continue
+		}
+		if info.IsDir() {
+			n, s, err := clearableContents(root, path, managed)
+			if err != nil {
+				return 0, 0, err
+			}
+			count += n
+			size += s
+			continue
+		}
+		count++
+		size += info.Size()
+	}
+	return count, size, nil
+}
+
+// copyFilesAndTrack копирует файлы из исходной директории в целевую и возвращает список новых файлов.
+// Имена не в UTF-8 перекодируются с помощью names, файлы отбрасываемых типов пропускает filter.
+// В режиме добавления существующие файлы не копируются, но при syncModes у них
+// обновляется исполняемый бит, если он изменился в новой версии
+func copyFilesAndTrack(ctx context.Context, src, dst string, appendMode, syncModes bool, names *nameDecoder, filter *contentFilter, modes fileModes, logger Logger) (int, []string, error) {
 	fileCount := 0
 	var newFiles []string
-	ignoreDirs := []string{".git", "__pycache__", "venv", ".venv", "node_modules", ".idea", ".vscode", "dist", "build", "env"}
-	ignoreFiles := []string{".DS_Store", "*.pyc", "*.pyo", "*.pyd", ".gitignore", ".gitattributes", "*.swp", "*.swo", "*.log", "*.bak"}
 
 	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
 		// Получаем относительный путь
 		relPath, err := filepath.Rel(src, path)
@@ -389,27 +1559,41 @@ func copyFilesAndTrack(src, dst string, appendMode bool) (int, []string, error)
 
 		// Проверяем, нужно ли игнорировать директорию
 		if info.IsDir() {
-			for _, ignoreDir := range ignoreDirs {
-				if info.Name() == ignoreDir {
-					return filepath.SkipDir
-				}
+			if filter.ignore().dir(info.Name()) {
+				return filepath.SkipDir
 			}
-			return nil
-		}
-
-		// Проверяем, нужно ли игнорировать файл
-		for _, pattern := range ignoreFiles {
-			matched, err := filepath.Match(pattern, info.Name())
+			ignored, err := filter.gitignored(src, relPath, true)
 			if err != nil {
 				return err
 			}
-			if matched {
-				return nil
+			if ignored {
+				return filepath.SkipDir
 			}
+			return nil
+		}
+
+		// Проверяем, нужно ли игнорировать файл
+		ignored, err := filter.ignore().file(info.Name())
+		if err == nil && !ignored {
+			ignored, err = filter.gitignored(src, relPath, false)
+		}
+		if err != nil {
+			return err
+		}
+		if ignored {
+			return nil
+		}
+		skipped, err := filter.skipped(path, info.Size())
+		if err != nil {
+			return err
+		}
+		if skipped {
+			return nil
 		}
 
 		// Создаем директории в целевом пути
-		targetPath := filepath.Join(dst, relPath)
+		repoPath := names.repoPath(relPath)
+		targetPath := filepath.Join(dst, repoPath)
 		targetDir := filepath.Dir(targetPath)
 		if err := os.MkdirAll(targetDir, 0755); err != nil {
 			return err
@@ -417,16 +1601,26 @@ func copyFilesAndTrack(src, dst string, appendMode bool) (int, []string, error)
 
 		// В режиме добавления проверяем, существует ли файл
 		if appendMode {
-			if _, err := os.Stat(targetPath); err == nil {
-				// Файл уже существует, пропускаем его
+			if targetInfo, err := os.Stat(targetPath); err == nil {
+				// Файл уже существует, переносим только смену исполняемого бита
+				if !syncModes || !execChanged(sourceFileMode(path, info.Mode()), targetInfo.Mode()) {
+					return nil
+				}
+				if err := os.Chmod(targetPath, info.Mode().Perm()); err != nil {
+					return err
+				}
+				logger.Printf("Изменены права файла %s: %v", repoPath, sourceFileMode(path, info.Mode()).Perm())
+				modes.record(repoPath, path, info)
+				newFiles = append(newFiles, targetPath)
 				return nil
 			}
 		}
 
-		// Копируем файл
-		if err := copyFile(path, targetPath); err != nil {
+		// Копируем файл, права берем из уже полученной информации о нем
+		if err := copyFileMode(ctx, path, targetPath, info.Mode()); err != nil {
 			return err
 		}
+		modes.record(repoPath, path, info)
 
 		// Добавляем путь к новому файлу в список
 		newFiles = append(newFiles, targetPath)
@@ -437,36 +1631,124 @@ func copyFilesAndTrack(src, dst string, appendMode bool) (int, []string, error)
 	return fileCount, newFiles, err
 }
 
-// copyFiles копирует файлы из исходной директории в целевую (для обратной совместимости)
-func copyFiles(src, dst string, appendMode bool) (int, error) {
-	count, _, err := copyFilesAndTrack(src, dst, appendMode)
-	return count, err
+// copyFile копирует один файл
+func copyFile(ctx context.Context, src, dst string) error {
+	sourceInfo, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	return copyFileMode(ctx, src, dst, sourceInfo.Mode())
 }
 
-// copyFile копирует один файл
-func copyFile(src, dst string) error {
+// copyFileMode копирует один файл и выставляет ему указанные права,
+// не запрашивая их повторно у файловой системы. После отмены ctx копирование
+// прерывается, а недописанный файл удаляется
+func copyFileMode(ctx context.Context, src, dst string, mode os.FileMode) error {
 	sourceFile, err := os.Open(src)
 	if err != nil {
 		return err
 	}
 	defer sourceFile.Close()
 
-	destFile, err := os.Create(dst)
+	destFile, err := os.OpenFile(dst, os.O_RDWR|os.O_CREATE|os.O_TRUNC, mode.Perm())
 	if err != nil {
 		return err
 	}
 	defer destFile.Close()
 
-	if _, err := io.Copy(destFile, sourceFile); err != nil {
-		return err
+	// Обертка отключает быстрое копирование средствами ОС, поэтому нужна
+	// только когда контекст может быть отменен
+	var reader io.Reader = sourceFile
+	if ctx.Done() != nil {
+		reader = contextReader{ctx: ctx, r: sourceFile}
+	}
+	if t := throttleFrom(ctx); t != nil {
+		if err := t.file(ctx); err != nil {
+			return err
+		}
+		reader = t.reader(ctx, reader)
 	}
 
-	sourceInfo, err := os.Stat(src)
-	if err != nil {
+	if _, err := io.Copy(destFile, reader); err != nil {
+		destFile.Close()
+		os.Remove(dst)
 		return err
 	}
 
-	return os.Chmod(dst, sourceInfo.Mode())
+	return destFile.Chmod(mode)
+}
+
+// commitMessage формирует сообщение коммита версии по шаблону или стандартное
+func commitMessage(config Config, names *nameDecoder, folder FolderInfo, folderName string, fileCount int, authorName string) string {
+	var msg string
+	if config.MessageTemplate != "" {
+		msg = expandPlaceholders(config.MessageTemplate, folder, folderName, fileCount, authorName)
+	} else {
+		msg = fmt.Sprintf("Version %s: %s (created: %s)",
+			Printable(folder.Version),
+			Printable(folderName),
+			time.Unix(folder.CreationTime, 0).Format("2006-01-02 15:04:05"))
+	}
+	msg, _ = names.decode(msg)
+	return msg
+}
+
+// resolveAuthor определяет автора коммита для версии: по умолчанию из настроек,
+// затем из файла авторов. В режиме Anonymize всегда возвращается обезличенный автор
+func resolveAuthor(config Config, version string) (string, string) {
+	if config.Anonymize {
+		name, email := config.AnonymousAuthor, config.AnonymousEmail
+		if name == "" {
+			name = defaultAnonymousAuthor
+		}
+		if email == "" {
+			email = defaultAnonymousEmail
+		}
+		return name, email
+	}
+
+	// Получаем информацию об авторе из файла, если он указан
+	authorName := config.Author
+	authorEmail := config.Email
+	if config.AuthorsFile != "" {
+		if name, email, err := getAuthorInfo(version, config.AuthorsFile); err == nil && name != "" && email != "" {
+			// Перевод строки в имени или адресе испортил бы заголовок коммита
+			authorName = Printable(name)
+			authorEmail = Printable(email)
+		}
+	}
+	return authorName, authorEmail
+}
+
+// knownAuthor проверяет, что автор версии найден в файле авторов, а не
+// подставлен по умолчанию
+func knownAuthor(config Config, version string) bool {
+	if config.AuthorsFile == "" || config.Anonymize {
+		return false
+	}
+	name, email, err := getAuthorInfo(version, config.AuthorsFile)
+	return err == nil && name != "" && email != ""
+}
+
+// extensionSet приводит расширения к виду ".go" в нижнем регистре.
+// Для пустого списка возвращает nil
+func extensionSet(extensions []string) map[string]bool {
+	if len(extensions) == 0 {
+		return nil
+	}
+
+	set := make(map[string]bool, len(extensions))
+	for _, ext := range extensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		set[ext] = true
+	}
+	return set
 }
 
 // getAuthorInfo получает информацию об авторе из файла сопоставления
@@ -496,26 +1778,31 @@ func getAuthorInfo(version string, authorsFile string) (string, string, error) {
 	return "", "", nil
 }
 
-// getFolderCreationTime получает время создания папки на основе анализа файлов
-func getFolderCreationTime(folderPath string) int64 {
+// getFolderCreationTime получает время создания папки на основе анализа файлов.
+// Если задан список extensions, учитываются только файлы с этими расширениями.
+// Возвращает также количество просмотренных файлов и false, если время
+// определить не удалось и вместо него взято текущее
+func getFolderCreationTime(src sourceFS, folderPath string, extensions []string) (int64, int, bool) {
 	var fileTimes []int64
+	sampled := extensionSet(extensions)
+	// Шаблоны сразу в нижнем регистре, чтобы не приводить их для каждого файла
 	keyFilePatterns := []string{
-		"version.py", "version.txt", "VERSION",
+		"version.py", "version.txt", "version",
 		"main.py", "app.py", "bot.py", "config.py",
 		"requirements.txt", "setup.py",
-		"Dockerfile", "docker-compose.yml",
+		"dockerfile", "docker-compose.yml",
 	}
 
 	processedFiles := 0
 	maxFiles := 500
 
-	err := filepath.Walk(folderPath, func(path string, info os.FileInfo, err error) error {
+	err := src.WalkDir(folderPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return nil
 		}
 
 		// Пропускаем служебные директории
-		if info.IsDir() {
+		if d.IsDir() {
 			base := filepath.Base(path)
 			if strings.HasPrefix(base, ".") || base == "__pycache__" ||
 				base == "venv" || base == "env" || base == ".venv" {
@@ -524,8 +1811,9 @@ func getFolderCreationTime(folderPath string) int64 {
 			return nil
 		}
 
+		// Дальше обходить дерево незачем: выборка уже набрана
 		if processedFiles >= maxFiles {
-			return filepath.SkipDir
+			return filepath.SkipAll
 		}
 
 		// Пропускаем служебные файлы
@@ -534,12 +1822,22 @@ func getFolderCreationTime(folderPath string) int64 {
 			return nil
 		}
 
+		// Сгенерированные файлы со случайным временем изменения искажают медиану
+		if sampled != nil && !sampled[strings.ToLower(filepath.Ext(base))] {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
 		modTime := info.ModTime().Unix()
 		fileTimes = append(fileTimes, modTime)
 
 		// Проверяем, является ли файл ключевым
+		lowerBase := strings.ToLower(base)
 		for _, pattern := range keyFilePatterns {
-			if strings.Contains(strings.ToLower(base), strings.ToLower(pattern)) {
+			if strings.Contains(lowerBase, pattern) {
 				fileTimes = append(fileTimes, modTime)
 				break
 			}
@@ -551,7 +1849,7 @@ func getFolderCreationTime(folderPath string) int64 {
 
 	if err != nil || len(fileTimes) == 0 {
 		// Если не удалось получить времена файлов, возвращаем текущее время
-		return time.Now().Unix()
+		return time.Now().Unix(), processedFiles, false
 	}
 
 	// Сортируем времена и берем медиану
@@ -559,5 +1857,5 @@ func getFolderCreationTime(folderPath string) int64 {
 		return fileTimes[i] < fileTimes[j]
 	})
 
-	return fileTimes[len(fileTimes)/2]
+	return fileTimes[len(fileTimes)/2], processedFiles, true
 }
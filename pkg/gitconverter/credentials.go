@@ -0,0 +1,227 @@
+package gitconverter
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/scrypt"
+)
+
+// credentialService — имя сервиса, под которым записи хранятся в системном хранилище
+const credentialService = "folder-to-git"
+
+// ErrCredentialNotFound возвращается, если в хранилище нет записи с таким именем
+var ErrCredentialNotFound = errors.New("учетные данные не найдены")
+
+// CredentialStore хранит секреты для доступа к удаленным репозиториям.
+// В Config указывается только имя записи, сам секрет читается при отправке
+type CredentialStore interface {
+	Get(name string) (string, error)
+	Set(name, secret string) error
+	Delete(name string) error
+}
+
+// keyringStore хранит секреты в системном хранилище: Keychain в macOS,
+// диспетчер учетных данных в Windows, Secret Service в Linux
+type keyringStore struct{}
+
+// NewKeyringStore возвращает хранилище на основе системного хранилища секретов
+func NewKeyringStore() CredentialStore {
+	return keyringStore{}
+}
+
+func (keyringStore) Get(name string) (string, error) {
+	secret, err := keyring.Get(credentialService, name)
+	if err == keyring.ErrNotFound {
+		return "", ErrCredentialNotFound
+	}
+	return secret, err
+}
+
+func (keyringStore) Set(name, secret string) error {
+	return keyring.Set(credentialService, name, secret)
+}
+
+func (keyringStore) Delete(name string) error {
+	err := keyring.Delete(credentialService, name)
+	if err == keyring.ErrNotFound {
+		return ErrCredentialNotFound
+	}
+	return err
+}
+
+// fileStore хранит секреты в файле, зашифрованном AES-GCM ключом из парольной
+// фразы. Нужен на серверах без системного хранилища
+type fileStore struct {
+	path       string
+	passphrase string
+	mu         sync.Mutex
+}
+
+// encryptedFile — формат файла fileStore
+type encryptedFile struct {
+	Salt  []byte `json:"salt"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+// Параметры scrypt для получения ключа из парольной фразы
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltSize     = 16
+)
+
+// NewFileStore возвращает хранилище в зашифрованном файле path
+func NewFileStore(path, passphrase string) CredentialStore {
+	return &fileStore{path: path, passphrase: passphrase}
+}
+
+func (s *fileStore) Get(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := secrets[name]
+	if !ok {
+		return "", ErrCredentialNotFound
+	}
+	return secret, nil
+}
+
+func (s *fileStore) Set(name, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	secrets[name] = secret
+	return s.save(secrets)
+}
+
+func (s *fileStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[name]; !ok {
+		return ErrCredentialNotFound
+	}
+	delete(secrets, name)
+	return s.save(secrets)
+}
+
+// load расшифровывает файл. Отсутствующий файл считается пустым хранилищем
+func (s *fileStore) load() (map[string]string, error) {
+	secrets := make(map[string]string)
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("поврежден файл учетных данных %s: %v", s.path, err)
+	}
+	gcm, err := s.cipher(file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("неверная парольная фраза или поврежден файл %s", s.path)
+	}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, fmt.Errorf("поврежден файл учетных данных %s: %v", s.path, err)
+	}
+	return secrets, nil
+}
+
+// save шифрует секреты с новой солью и записывает файл с правами только для владельца
+func (s *fileStore) save(secrets map[string]string) error {
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	file := encryptedFile{Salt: make([]byte, saltSize)}
+	if _, err := rand.Read(file.Salt); err != nil {
+		return err
+	}
+	gcm, err := s.cipher(file.Salt)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, plain, nil)
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0600)
+}
+
+// cipher получает ключ из парольной фразы и соли
+func (s *fileStore) cipher(salt []byte) (cipher.AEAD, error) {
+	if s.passphrase == "" {
+		return nil, fmt.Errorf("не задана парольная фраза для файла учетных данных")
+	}
+	key, err := scrypt.Key([]byte(s.passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// resolveCredential читает секрет name из хранилища config.CredentialStore
+// (по умолчанию системного) и разбирает его на имя пользователя и пароль.
+// Секрет хранится как "пользователь:токен" или как один токен
+func resolveCredential(config Config, name string) (string, string, error) {
+	store := config.CredentialStore
+	if store == nil {
+		store = NewKeyringStore()
+	}
+	secret, err := store.Get(name)
+	if err != nil {
+		return "", "", fmt.Errorf("ошибка чтения учетных данных %q: %v", name, err)
+	}
+	if user, token, ok := strings.Cut(secret, ":"); ok && user != "" {
+		return user, token, nil
+	}
+	// Серверы, принимающие токен как пароль, не проверяют имя пользователя
+	return "git", secret, nil
+}
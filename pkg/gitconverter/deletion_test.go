@@ -0,0 +1,103 @@
+package gitconverter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Во второй версии нет old.txt и всей папки lib с вложенной подпапкой
+var (
+	deletionV1 = map[string]string{
+		"a.txt":       "1\n",
+		"old.txt":     "old\n",
+		"lib/z.txt":   "z\n",
+		"lib/x/y.txt": "y\n",
+		"keep/k.txt":  "k\n",
+	}
+	deletionV2 = map[string]string{
+		"a.txt":      "2\n",
+		"keep/k.txt": "k\n",
+	}
+)
+
+func TestDeletedFilesLeaveTree(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"обычный режим", func(*Config) {}},
+		{"временная папка", func(c *Config) { c.WorkspaceMode = true }},
+		{"только изменения", func(c *Config) { c.Incremental = true }},
+		{"коммит по частям", func(c *Config) { c.MaxCommitSize = 2 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := t.TempDir()
+			config := testConfig(versionSource(t, deletionV1, deletionV2), dst)
+			tt.modify(&config)
+			result := mustRun(t, config)
+
+			// В режиме по частям коммит версии — последний из ее частей
+			byHash := make(map[string]*object.Commit)
+			for _, commit := range history(t, dst) {
+				byHash[commit.Hash.String()] = commit
+			}
+			if len(result.Commits) != 2 {
+				t.Fatalf("записей о коммитах %d, ожидалось 2", len(result.Commits))
+			}
+			first, last := byHash[result.Commits[0].Hash], byHash[result.Commits[1].Hash]
+			if got := treeNames(t, first); !reflect.DeepEqual(got, []string{"a.txt", "keep/k.txt", "lib/x/y.txt", "lib/z.txt", "old.txt"}) {
+				t.Errorf("дерево первой версии: %v", got)
+			}
+			if got := treeNames(t, last); !reflect.DeepEqual(got, []string{"a.txt", "keep/k.txt"}) {
+				t.Errorf("дерево второй версии: %v", got)
+			}
+			if _, err := os.Stat(filepath.Join(dst, "lib")); !os.IsNotExist(err) {
+				t.Errorf("удаленная папка осталась в рабочей директории: %v", err)
+			}
+		})
+	}
+}
+
+func TestAppendWarnsAboutDeletions(t *testing.T) {
+	src := versionSource(t, deletionV1)
+	dst := t.TempDir()
+	mustRun(t, testConfig(src, dst))
+
+	// Вторая версия появилась в источнике позже
+	v2 := versionSource(t, nil, map[string]string{"a.txt": "2\n", "keep/k.txt": "k\n", "new.txt": "new\n"})
+	if err := os.Rename(filepath.Join(v2, "v2"), filepath.Join(src, "v2")); err != nil {
+		t.Fatal(err)
+	}
+	config := testConfig(src, dst)
+	config.Append = true
+	result := mustRun(t, config)
+
+	var warnings []string
+	for _, w := range result.Warnings {
+		if w.Version == "2" {
+			warnings = append(warnings, w.Message)
+		}
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "удаление 3 файлов") {
+		t.Errorf("предупреждения версии 2: %v", warnings)
+	}
+
+	// Добавление только дописывает новые файлы: прошлые не удаляются и не перезаписываются
+	commits := history(t, dst)
+	if len(commits) != 2 {
+		t.Fatalf("коммитов %d, ожидалось 2", len(commits))
+	}
+	want := []string{"a.txt", "keep/k.txt", "lib/x/y.txt", "lib/z.txt", "new.txt", "old.txt"}
+	if got := treeNames(t, commits[1]); !reflect.DeepEqual(got, want) {
+		t.Errorf("дерево версии, добавленной поверх: %v", got)
+	}
+	if files := treeFiles(t, commits[1]); files["a.txt"].Content != "1\n" {
+		t.Errorf("a.txt перезаписан: %q", files["a.txt"].Content)
+	}
+}
@@ -0,0 +1,84 @@
+package gitconverter
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// findVersionPaths возвращает пути в источнике src, подходящие под шаблон
+// поиска, и директории, которые не удалось прочитать при обходе.
+//
+// По умолчанию это непосредственные потомки корня root, найденные Glob. С
+// Recursive директории обходятся на любой глубине до
+// MaxDepth (0 — без ограничения), и шаблон Pattern сравнивается с именем
+// каждой директории. Внутрь папки, из имени которой извлекается версия,
+// обход не спускается: ее поддиректории — часть версии, а не другие версии.
+// Директории из списка пропускаемых, .git и сам TargetDir не обходятся,
+// символические ссылки на директории не раскрываются
+func findVersionPaths(src sourceFS, root string, config Config, re *regexp.Regexp) (matches, unreadable []string, err error) {
+	if !config.Recursive {
+		matches, err = src.Glob(src.Join(root, config.Pattern))
+		return matches, nil, err
+	}
+
+	if _, err := filepath.Match(config.Pattern, ""); err != nil {
+		return nil, nil, err
+	}
+	rules, err := newIgnoreRules(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Репозиторий может лежать только внутри локального источника
+	target := ""
+	if _, local := src.(localSource); local && config.TargetDir != "" {
+		target, _ = filepath.Abs(config.TargetDir)
+	}
+
+	root = src.Join(root)
+	err = src.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsPermission(err) {
+				// Недоступная исходная директория сообщается после обхода
+				if path != root {
+					unreadable = append(unreadable, path)
+				}
+				return filepath.SkipDir
+			}
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if path == root || !d.IsDir() {
+			return nil
+		}
+		if rules.dir(d.Name()) {
+			return filepath.SkipDir
+		}
+		if target != "" {
+			if abs, _ := filepath.Abs(path); abs == target {
+				return filepath.SkipDir
+			}
+		}
+
+		if rel, err := src.Rel(root, path); err == nil && config.MaxDepth > 0 &&
+			strings.Count(filepath.ToSlash(rel), "/")+1 > config.MaxDepth {
+			return filepath.SkipDir
+		}
+
+		name := d.Name()
+		if ok, _ := filepath.Match(config.Pattern, name); !ok {
+			return nil
+		}
+		matches = append(matches, path)
+		// Папка без версии попадет в Unmatched, но в ней могут быть версии
+		if _, ok := config.VersionOverrides[name]; ok || re.MatchString(name) {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return matches, unreadable, err
+}
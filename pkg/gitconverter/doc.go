@@ -0,0 +1,34 @@
+// Package gitconverter переносит папки с версиями проекта в историю
+// Git-репозитория: каждая папка становится коммитом с датой и автором версии.
+//
+// # Поддерживаемый API
+//
+// Для встраивания в другие программы предназначены:
+//
+//   - Config — настройки миграции; нулевые значения полей означают поведение
+//     по умолчанию, описанное в комментарии к полю;
+//   - Converter (New, Scan, Estimate, Migrate, MigrateContext, Run) — сканирование и миграция;
+//   - FolderInfo, ScanResult, MigrationEstimate, MigrationResult и типы,
+//     входящие в них (FolderFailure, FolderWarning, PlannedCommit и другие);
+//   - ProgressEvent и Config.OnScanProgress — сведения о ходе работы;
+//   - Logger и Config.Logger — куда писать лог (по умолчанию стандартный log);
+//   - ошибки ErrInvalidConfig, ErrForeignRepository, ErrInsufficientSpace,
+//     ErrVerificationFailed, ErrDirtyWorktree, ErrPushFailed, ErrRepoUnhealthy,
+//     ErrFolderTimeout и ErrCredentialNotFound,
+//     которые проверяются через errors.Is;
+//   - вспомогательные операции с готовым репозиторием: RetrofitTags,
+//     RewriteAuthors, ReleaseNotes, PushRepository, Watch, Check, а также MigrateSingle
+//     для переноса одной папки без поиска версий.
+//
+// Совместимость определяется константой APIVersion по правилам SemVer: в
+// пределах одной старшей версии экспортированные имена не удаляются и не
+// меняют сигнатуру, в Config и типы результатов только добавляются поля,
+// а нулевое значение нового поля сохраняет прежнее поведение. Тексты ошибок
+// и сообщений лога (на русском языке) в контракт не входят, сравнивать нужно
+// сами ошибки. Неэкспортированные функции пакета — внутренние детали
+// реализации и могут меняться в любом выпуске.
+//
+// Свободные функции FindVersionedFolders, ScanVersionedFolders,
+// EstimateMigration, MigrateToGit и MigrateToGitResult сохранены ради
+// совместимости и помечены как устаревшие.
+package gitconverter
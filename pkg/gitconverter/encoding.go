@@ -0,0 +1,88 @@
+package gitconverter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// isUTF8Encoding проверяет, что кодировка не задана или совпадает с UTF-8
+func isUTF8Encoding(name string) bool {
+	switch strings.ToLower(strings.ReplaceAll(name, "_", "-")) {
+	case "", "utf-8", "utf8":
+		return true
+	}
+	return false
+}
+
+// lookupEncoding находит кодировку по имени (windows-1251, koi8-r и т.п.)
+func lookupEncoding(name string) (encoding.Encoding, error) {
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("неизвестная кодировка %q: %v", name, err)
+	}
+	return enc, nil
+}
+
+// applyMessageEncoding перезаписывает только что созданный коммит так, чтобы
+// сообщение и имена были в заданной кодировке, а в заголовке commit был
+// указан encoding. Ветка, на которую указывает HEAD, переносится на новый коммит
+func applyMessageEncoding(repo *git.Repository, hash plumbing.Hash, name string, signKey *openpgp.Entity) (plumbing.Hash, error) {
+	if isUTF8Encoding(name) {
+		return hash, nil
+	}
+
+	enc, err := lookupEncoding(name)
+	if err != nil {
+		return hash, err
+	}
+	encoder := enc.NewEncoder()
+
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return hash, err
+	}
+
+	if commit.Message, err = encoder.String(commit.Message); err != nil {
+		return hash, fmt.Errorf("сообщение не представимо в кодировке %s: %v", name, err)
+	}
+	if commit.Author.Name, err = encoder.String(commit.Author.Name); err != nil {
+		return hash, fmt.Errorf("имя автора не представимо в кодировке %s: %v", name, err)
+	}
+	if commit.Committer.Name, err = encoder.String(commit.Committer.Name); err != nil {
+		return hash, fmt.Errorf("имя коммитера не представимо в кодировке %s: %v", name, err)
+	}
+	commit.Encoding = object.MessageEncoding(name)
+	if err := signCommit(commit, signKey); err != nil {
+		return hash, err
+	}
+
+	obj := repo.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		return hash, err
+	}
+	newHash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return hash, err
+	}
+
+	head, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return hash, err
+	}
+	target := plumbing.HEAD
+	if head.Type() == plumbing.SymbolicReference {
+		target = head.Target()
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(target, newHash)); err != nil {
+		return hash, err
+	}
+
+	return newHash, nil
+}
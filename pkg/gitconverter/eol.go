@@ -0,0 +1,295 @@
+package gitconverter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// EOLMode определяет, к каким концам строк приводятся текстовые файлы
+type EOLMode string
+
+const (
+	EOLNone EOLMode = "none" // Файлы переносятся как есть (по умолчанию)
+	EOLLF   EOLMode = "lf"   // Концы строк CRLF заменяются на LF
+	EOLCRLF EOLMode = "crlf" // Концы строк LF заменяются на CRLF
+)
+
+// binarySniffSize — сколько байт от начала файла проверяется на нулевой
+// байт, как это делает Git
+const binarySniffSize = 8000
+
+// eolHeader открывает правило .gitattributes, добавленное конвертером
+const eolHeader = "# Добавлено folder-to-git: концы строк текстовых файлов"
+
+// validateEOLMode проверяет значение NormalizeEOL
+func validateEOLMode(mode EOLMode) error {
+	switch mode {
+	case "", EOLNone, EOLLF, EOLCRLF:
+		return nil
+	}
+	return fmt.Errorf("неизвестный режим концов строк %q", mode)
+}
+
+// normalizesEOL проверяет, что концы строк нужно приводить
+func normalizesEOL(mode EOLMode) bool {
+	return mode == EOLLF || mode == EOLCRLF
+}
+
+// isBinary считает содержимое двоичным, если в его начале есть нулевой байт
+func isBinary(data []byte) bool {
+	if len(data) > binarySniffSize {
+		data = data[:binarySniffSize]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// normalizeEOL приводит концы строк текста data к mode. Одиночные CR не
+// меняются. Двоичное содержимое возвращается как есть
+func normalizeEOL(data []byte, mode EOLMode) []byte {
+	if !normalizesEOL(mode) || isBinary(data) {
+		return data
+	}
+	lf := bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if mode == EOLCRLF {
+		return bytes.ReplaceAll(lf, []byte("\n"), []byte("\r\n"))
+	}
+	return lf
+}
+
+// eolWriter приводит концы строк потока к mode и пишет результат в w.
+// CR в конце куска откладывается до следующей записи, поэтому результат не
+// зависит от того, как поток разбит на куски
+type eolWriter struct {
+	w       io.Writer
+	mode    EOLMode
+	cr      bool  // Последний записанный байт — отложенный CR
+	changed bool  // Хотя бы один конец строки заменен
+	written int64 // Байт записано в w
+	buf     []byte
+}
+
+func (e *eolWriter) Write(p []byte) (int, error) {
+	out := e.buf[:0]
+	for _, c := range p {
+		if e.cr {
+			e.cr = false
+			if c == '\n' {
+				if e.mode == EOLLF {
+					out = append(out, '\n')
+					e.changed = true
+				} else {
+					out = append(out, '\r', '\n')
+				}
+				continue
+			}
+			out = append(out, '\r')
+		}
+		switch {
+		case c == '\r':
+			e.cr = true
+		case c == '\n' && e.mode == EOLCRLF:
+			out = append(out, '\r', '\n')
+			e.changed = true
+		default:
+			out = append(out, c)
+		}
+	}
+	e.buf = out
+	if err := e.emit(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close дописывает CR, отложенный в конце потока
+func (e *eolWriter) Close() error {
+	if !e.cr {
+		return nil
+	}
+	e.cr = false
+	return e.emit([]byte{'\r'})
+}
+
+// emit пишет data в w и учитывает записанный объем
+func (e *eolWriter) emit(data []byte) error {
+	n, err := e.w.Write(data)
+	e.written += int64(n)
+	return err
+}
+
+// copyNormalized копирует r в w, приводя концы строк к mode. Возвращает
+// объем записанного и признак того, что содержимое изменилось
+func copyNormalized(w io.Writer, r io.Reader, mode EOLMode) (int64, bool, error) {
+	e := &eolWriter{w: w, mode: mode}
+	if _, err := io.Copy(e, r); err != nil {
+		return e.written, e.changed, err
+	}
+	if err := e.Close(); err != nil {
+		return e.written, e.changed, err
+	}
+	return e.written, e.changed, nil
+}
+
+// openSniffed открывает файл path и проверяет его первые binarySniffSize
+// байт, не читая остального. Второе значение истинно для двоичного файла.
+// Файл возвращается перемотанным в начало
+func openSniffed(path string) (*os.File, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	head := make([]byte, binarySniffSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		file.Close()
+		return nil, false, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, false, err
+	}
+	return file, isBinary(head[:n]), nil
+}
+
+// measureNormalized считает объем файла после приведения концов строк к
+// mode и перематывает его в начало. Второе значение истинно, если
+// содержимое изменится
+func measureNormalized(file *os.File, mode EOLMode) (int64, bool, error) {
+	size, changed, err := copyNormalized(io.Discard, file, mode)
+	if err != nil {
+		return 0, false, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, false, err
+	}
+	return size, changed, nil
+}
+
+// normalizeFile приводит концы строк файла path к mode. Содержимое не
+// загружается в память: оно переписывается во временный файл рядом, который
+// затем заменяет исходный с правами perm. Возвращает истину, если файл
+// изменился
+func normalizeFile(path string, mode EOLMode, perm os.FileMode) (bool, error) {
+	file, binary, err := openSniffed(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	if binary {
+		return false, nil
+	}
+	// Сначала только проверяем: большинство файлов уже в нужном виде
+	if _, changed, err := measureNormalized(file, mode); err != nil || !changed {
+		return false, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".eol-*")
+	if err != nil {
+		return false, err
+	}
+	_, _, err = copyNormalized(tmp, file, mode)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), perm)
+	}
+	if err == nil {
+		// Windows не заменяет открытый файл
+		file.Close()
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return false, err
+	}
+	return true, nil
+}
+
+// normalizeLineEndings приводит концы строк в файлах paths (пути
+// относительно config.TargetDir) к config.NormalizeEOL. Файлы меняются на
+// месте с сохранением прав, двоичные файлы, ссылки и указатели LFS не
+// трогаются. Возвращает число измененных файлов
+func normalizeLineEndings(config Config, paths []string) (int, error) {
+	if !normalizesEOL(config.NormalizeEOL) {
+		return 0, nil
+	}
+	changed := 0
+	for _, rel := range paths {
+		full := filepath.Join(config.TargetDir, rel)
+		info, err := os.Lstat(full)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if _, ok := readLFSPointer(full); ok {
+			continue
+		}
+		modified, err := normalizeFile(full, config.NormalizeEOL, info.Mode().Perm())
+		if err != nil {
+			return changed, fmt.Errorf("%s: %v", rel, err)
+		}
+		if modified {
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// normalizedBlobHash возвращает хеш объекта blob, который получится из
+// файла path после приведения концов строк к mode. Файл читается потоком
+// дважды: сначала для подсчета объема, затем для хеша
+func normalizedBlobHash(path string, mode EOLMode) (plumbing.Hash, error) {
+	file, binary, err := openSniffed(path)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	defer file.Close()
+	if binary || !normalizesEOL(mode) {
+		return blobHash(path)
+	}
+
+	size, _, err := measureNormalized(file, mode)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	hasher := plumbing.NewHasher(plumbing.BlobObject, size)
+	if _, _, err := copyNormalized(hasher, file, mode); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return hasher.Sum(), nil
+}
+
+// eolAttributesRule возвращает правило .gitattributes для режима mode
+func eolAttributesRule(mode EOLMode) string {
+	return "* text=auto eol=" + string(mode)
+}
+
+// writeEOLAttributes добавляет правило концов строк в начало .gitattributes
+// рабочей директории: более поздние строки, например правила LFS, должны
+// иметь приоритет над ним. Имеющиеся строки сохраняются
+func writeEOLAttributes(root string, mode EOLMode) error {
+	file := filepath.Join(root, attributesFile)
+	existing, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	rule := eolAttributesRule(mode)
+	for _, line := range bytes.Split(existing, []byte("\n")) {
+		if string(bytes.TrimSpace(line)) == rule {
+			return nil
+		}
+	}
+
+	var b bytes.Buffer
+	b.WriteString(eolHeader + "\n" + rule + "\n")
+	if len(existing) > 0 {
+		b.WriteByte('\n')
+		b.Write(existing)
+	}
+	return os.WriteFile(file, b.Bytes(), 0644)
+}
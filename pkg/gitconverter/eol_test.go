@@ -0,0 +1,233 @@
+package gitconverter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// eolTests — входные данные и ожидаемый результат приведения к LF и CRLF
+var eolTests = []struct {
+	name     string
+	in       string
+	lf, crlf string
+}{
+	{"пусто", "", "", ""},
+	{"LF", "a\nb\n", "a\nb\n", "a\r\nb\r\n"},
+	{"CRLF", "a\r\nb\r\n", "a\nb\n", "a\r\nb\r\n"},
+	{"смешанные", "a\r\nb\nc\r\n", "a\nb\nc\n", "a\r\nb\r\nc\r\n"},
+	{"одиночный CR", "a\rb\r\n", "a\rb\n", "a\rb\r\n"},
+	{"CR в конце", "a\r\nb\r", "a\nb\r", "a\r\nb\r"},
+	{"CR перед CRLF", "a\r\r\nb", "a\r\nb", "a\r\r\nb"},
+	{"без концов строк", "abc", "abc", "abc"},
+	{"двоичный", "\x00a\r\nb\n", "\x00a\r\nb\n", "\x00a\r\nb\n"},
+}
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"пусто", nil, false},
+		{"текст", []byte("a\r\nb\n"), false},
+		{"одиночный CR", []byte("a\rb"), false},
+		{"NUL в начале", []byte("\x00abc"), true},
+		{"NUL в середине", []byte("ab\x00c\r\n"), true},
+		{"NUL в конце проверяемой части", append(bytes.Repeat([]byte("a"), binarySniffSize-1), 0), true},
+		{"NUL после проверяемой части", append(bytes.Repeat([]byte("a"), binarySniffSize), 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBinary(tt.data); got != tt.want {
+				t.Errorf("isBinary = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeEOL(t *testing.T) {
+	for _, tt := range eolTests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, mode := range []struct {
+				mode EOLMode
+				want string
+			}{{EOLLF, tt.lf}, {EOLCRLF, tt.crlf}, {EOLNone, tt.in}, {"", tt.in}} {
+				if got := string(normalizeEOL([]byte(tt.in), mode.mode)); got != mode.want {
+					t.Errorf("%q: %q, ожидалось %q", mode.mode, got, mode.want)
+				}
+			}
+		})
+	}
+}
+
+func TestEOLWriterChunks(t *testing.T) {
+	for _, tt := range eolTests {
+		if isBinary([]byte(tt.in)) {
+			continue
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			for _, mode := range []struct {
+				mode EOLMode
+				want string
+			}{{EOLLF, tt.lf}, {EOLCRLF, tt.crlf}} {
+				// Запись по одному байту разрывает CRLF между кусками
+				var out bytes.Buffer
+				e := &eolWriter{w: &out, mode: mode.mode}
+				for i := 0; i < len(tt.in); i++ {
+					if _, err := e.Write([]byte{tt.in[i]}); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if err := e.Close(); err != nil {
+					t.Fatal(err)
+				}
+				if out.String() != mode.want {
+					t.Errorf("%s: %q, ожидалось %q", mode.mode, out.String(), mode.want)
+				}
+				if e.changed != (mode.want != tt.in) || e.written != int64(len(mode.want)) {
+					t.Errorf("%s: изменено %v, записано %d", mode.mode, e.changed, e.written)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeLineEndings(t *testing.T) {
+	// Текст длиннее проверяемой части и буфера копирования, нулевой байт
+	// после проверяемой части не делает файл двоичным, как и в Git
+	long := strings.Repeat("строка\r\n", 10000) + "\x00\r\n"
+	files := map[string]string{
+		"crlf.txt":  "a\r\nb\r\n",
+		"lf.txt":    "a\nb\n",
+		"mixed.txt": "a\r\nb\nc\rd",
+		"long.txt":  long,
+		"bin.dat":   "\x00a\r\nb\r\n",
+		"lfs.bin":   lfsSpec + "\r\noid sha256:" + strings.Repeat("0", 64) + "\r\nsize 1\r\n",
+	}
+
+	for _, mode := range []EOLMode{EOLLF, EOLCRLF} {
+		t.Run(string(mode), func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, files)
+			if err := os.Chmod(filepath.Join(dir, "crlf.txt"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			var paths []string
+			for name := range files {
+				paths = append(paths, name)
+			}
+
+			// Хеши считаются до приведения и должны совпасть с итоговыми файлами.
+			// Хеш указателя LFS план считает отдельно
+			hashes := make(map[string]plumbing.Hash)
+			for name := range files {
+				if name == "lfs.bin" {
+					continue
+				}
+				hash, err := normalizedBlobHash(filepath.Join(dir, name), mode)
+				if err != nil {
+					t.Fatal(err)
+				}
+				hashes[name] = hash
+			}
+
+			config := Config{TargetDir: dir, NormalizeEOL: mode}
+			changed, err := normalizeLineEndings(config, paths)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := map[string]string{
+				"bin.dat": files["bin.dat"],
+				"lfs.bin": files["lfs.bin"],
+			}
+			wantChanged := 3
+			if mode == EOLLF {
+				want["crlf.txt"], want["lf.txt"], want["mixed.txt"] = "a\nb\n", "a\nb\n", "a\nb\nc\rd"
+				want["long.txt"] = strings.ReplaceAll(long, "\r\n", "\n")
+			} else {
+				want["crlf.txt"], want["lf.txt"], want["mixed.txt"] = "a\r\nb\r\n", "a\r\nb\r\n", "a\r\nb\r\nc\rd"
+				want["long.txt"] = long
+				wantChanged = 2
+			}
+			if changed != wantChanged {
+				t.Errorf("изменено файлов %d, ожидалось %d", changed, wantChanged)
+			}
+			for name, content := range want {
+				data, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != content {
+					t.Errorf("%s: %.40q, ожидалось %.40q", name, data, content)
+				}
+				if hash := plumbing.ComputeHash(plumbing.BlobObject, data); name != "lfs.bin" && hash != hashes[name] {
+					t.Errorf("%s: хеш до приведения %s, после %s", name, hashes[name], hash)
+				}
+			}
+
+			// Права сохраняются, временные файлы не остаются
+			if runtime.GOOS != "windows" {
+				info, err := os.Stat(filepath.Join(dir, "crlf.txt"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.Mode().Perm() != 0755 {
+					t.Errorf("права crlf.txt %v", info.Mode().Perm())
+				}
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(files) {
+				t.Errorf("в папке %d файлов, ожидалось %d", len(entries), len(files))
+			}
+		})
+	}
+}
+
+func TestWriteEOLAttributes(t *testing.T) {
+	rule := eolAttributesRule(EOLLF)
+	lfs := "*.bin filter=lfs diff=lfs merge=lfs -text\n"
+	tests := []struct {
+		name     string
+		existing string
+		want     string
+	}{
+		{"нет файла", "", eolHeader + "\n" + rule + "\n"},
+		{"правило добавляется перед LFS", lfs, eolHeader + "\n" + rule + "\n\n" + lfs},
+		{"правило уже есть", lfs + rule + "\n", lfs + rule + "\n"},
+		{"правило с пробелами", "  " + rule + "\r\n", "  " + rule + "\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			file := filepath.Join(dir, attributesFile)
+			if tt.existing != "" {
+				if err := os.WriteFile(file, []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// Повторный вызов ничего не меняет
+			for i := 0; i < 2; i++ {
+				if err := writeEOLAttributes(dir, EOLLF); err != nil {
+					t.Fatal(err)
+				}
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf(".gitattributes\n%q\nожидалось\n%q", data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package gitconverter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"folder_to_git/internal/diskspace"
+)
+
+// gitStorageFactor — запас на хранение объектов Git поверх рабочей копии
+const gitStorageFactor = 2
+
+// MigrationEstimate содержит оценку объема миграции
+type MigrationEstimate struct {
+	Folders       int
+	Files         int
+	Bytes         int64 // Суммарный размер файлов всех версий
+	RequiredBytes int64 // Ориентировочно необходимое место на диске
+}
+
+// EstimateMigration оценивает объем данных и место на диске, нужное для миграции.
+// Для папок, не заполненных EnrichFolders, размер подсчитывается заново
+//
+// Deprecated: используйте Converter.Estimate.
+func EstimateMigration(config Config, folders []FolderInfo) (*MigrationEstimate, error) {
+	src, err := openSource(config)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	return estimateMigration(src, config, folders)
+}
+
+// estimateMigration оценивает объем миграции папок источника src
+func estimateMigration(src sourceFS, config Config, folders []FolderInfo) (*MigrationEstimate, error) {
+	estimate := &MigrationEstimate{Folders: len(folders)}
+	filter, err := newContentFilter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, folder := range folders {
+		size, count := folder.Size, folder.FileCount
+		if size == 0 && count == 0 {
+			var err error
+			size, count, err = folderStats(context.Background(), src, folder.Path, filter)
+			if os.IsNotExist(err) {
+				// Папка исчезла после сканирования, при переносе она будет пропущена
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("ошибка подсчета размера %s: %v", folder.Path, err)
+			}
+		}
+		estimate.Bytes += size
+		estimate.Files += count
+	}
+
+	// В режиме без Git файлы копируются один раз, иначе нужен запас под объекты
+	estimate.RequiredBytes = estimate.Bytes
+	if !config.NoGit {
+		estimate.RequiredBytes *= gitStorageFactor
+	}
+
+	return estimate, nil
+}
+
+// checkDiskSpace проверяет, хватит ли свободного места в TargetDir для миграции.
+// При нехватке возвращает ошибку или, если задан IgnoreDiskSpace, только предупреждает
+func checkDiskSpace(src sourceFS, config Config, folders []FolderInfo) error {
+	estimate, err := estimateMigration(src, config, folders)
+	if err != nil {
+		return err
+	}
+
+	free, err := diskspace.Free(existingParent(config.TargetDir))
+	if err != nil {
+		config.logger().Printf("Предупреждение: не удалось определить свободное место: %v", err)
+		return nil
+	}
+
+	if config.Verbose {
+		config.logger().Printf("Оценка миграции: %d файлов, %s; требуется около %s, свободно %s",
+			estimate.Files, FormatSize(estimate.Bytes), FormatSize(estimate.RequiredBytes), FormatSize(int64(free)))
+	}
+
+	if uint64(estimate.RequiredBytes) <= free {
+		return nil
+	}
+
+	err = fmt.Errorf("%w в %s: требуется около %s, свободно %s",
+		ErrInsufficientSpace, config.TargetDir, FormatSize(estimate.RequiredBytes), FormatSize(int64(free)))
+	if config.IgnoreDiskSpace {
+		config.logger().Printf("Предупреждение: %v", err)
+		return nil
+	}
+	return err
+}
+
+// existingParent возвращает ближайшую существующую директорию для пути
+func existingParent(path string) string {
+	path = filepath.Clean(path)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// ClearsTarget сообщает, удалит ли миграция с этими настройками текущее
+// содержимое рабочей директории TargetDir. У голого репозитория рабочей
+// директории нет
+func ClearsTarget(config Config) bool {
+	return !config.DryRun && !config.NoGit && !config.Bare && !config.Append && !config.KeepVersionDir
+}
+
+// TargetContents подсчитывает файлы в TargetDir, которые удалит очистка
+// перед первой версией. Обход пропускает то же, что и сама очистка: .git и
+// другие системные папки, файлы конвертера и символические ссылки
+func TargetContents(config Config) (int, int64, error) {
+	if _, err := os.Stat(config.TargetDir); os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	return clearableContents(config.TargetDir, config.TargetDir, toolManagedPaths(config))
+}
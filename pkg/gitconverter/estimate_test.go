@@ -0,0 +1,66 @@
+package gitconverter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Оценка очистки должна совпадать с тем, что clearDirectory удаляет на деле:
+// каталоги сборки, node_modules и журналы тоже удаляются, хотя при подсчете
+// версий они исключены правилами по умолчанию
+func TestTargetContentsMatchesClearDirectory(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":                   "package main",
+		"build/app":                 "binary",
+		"dist/app.tar":              "tar",
+		"node_modules/x/index.js":   "module",
+		"debug.log":                 "log line",
+		defaultMetadataFile:         "version: 1",
+		".git/HEAD":                 "ref: refs/heads/master",
+		".git/objects/pack/a.pack":  "pack",
+		"sub/tmp/keep.txt":          "системная папка",
+		"sub/nested/.hidden/in.txt": "hidden",
+	}
+	writeFiles(t, dir, files)
+	if err := os.Symlink("main.go", filepath.Join(dir, "link")); err != nil {
+		t.Skipf("символические ссылки недоступны: %v", err)
+	}
+
+	config := Config{TargetDir: dir, WriteMetadataFile: true}
+	count, size, err := TargetContents(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := listTree(t, dir)
+	if err := clearDirectory(dir, toolManagedPaths(config), &testLogger{}); err != nil {
+		t.Fatal(err)
+	}
+	after := make(map[string]bool)
+	for _, path := range listTree(t, dir) {
+		after[path] = true
+	}
+	removed, removedSize := 0, int64(0)
+	for _, path := range before {
+		if !after[path] {
+			removed++
+			removedSize += int64(len(files[path]))
+		}
+	}
+
+	if count != removed || size != removedSize {
+		t.Errorf("оценка %d файлов (%d байт), удалено %d (%d байт)", count, size, removed, removedSize)
+	}
+	if count != 6 {
+		t.Errorf("файлов к удалению %d, ожидалось 6", count)
+	}
+}
+
+func TestTargetContentsMissingDir(t *testing.T) {
+	count, size, err := TargetContents(Config{TargetDir: filepath.Join(t.TempDir(), "none")})
+	if err != nil || count != 0 || size != 0 {
+		t.Errorf("для отсутствующей папки получено %d, %d, %v", count, size, err)
+	}
+}
@@ -0,0 +1,36 @@
+package gitconverter
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Типы событий хода миграции
+const (
+	EventFolder = "folder" // Версия перенесена
+	EventFailed = "failed" // Версию перенести не удалось
+	EventDone   = "done"   // Миграция завершена
+)
+
+// ProgressFunc получает номер обрабатываемой папки done из total и саму папку
+type ProgressFunc func(done, total int, folder FolderInfo)
+
+// ProgressEvent описывает событие хода миграции для внешних наблюдателей
+type ProgressEvent struct {
+	Type    string           `json:"type"`
+	Time    time.Time        `json:"time"`
+	Version string           `json:"version,omitempty"`
+	Path    string           `json:"path,omitempty"`
+	Commit  string           `json:"commit,omitempty"` // Коммит перенесенной версии
+	Err     string           `json:"error,omitempty"`
+	Result  *MigrationResult `json:"result,omitempty"` // Итоги миграции, только для EventDone
+}
+
+// MarshalJSON добавляет к событию поле schemaVersion
+func (e ProgressEvent) MarshalJSON() ([]byte, error) {
+	type plain ProgressEvent
+	return json.Marshal(struct {
+		SchemaVersion int `json:"schemaVersion"`
+		plain
+	}{SchemaVersion, plain(e)})
+}
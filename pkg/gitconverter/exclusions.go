@@ -0,0 +1,116 @@
+package gitconverter
+
+import (
+	"path/filepath"
+)
+
+// ExcludedFolder описывает папку, которую пользователь исключил из миграции.
+// Исключения хранятся в манифесте репозитория и действуют в следующих запусках
+type ExcludedFolder struct {
+	Path    string `json:"path"`    // Абсолютный путь папки
+	Version string `json:"version"` // Версия на момент исключения
+}
+
+// Matches проверяет, относится ли исключение к папке: по пути, а если
+// источник переехал — по имени папки и версии
+func (e ExcludedFolder) Matches(folder FolderInfo) bool {
+	path := absPath(folder.Path)
+	if e.Path == path {
+		return true
+	}
+	return filepath.Base(e.Path) == filepath.Base(path) && e.Version == folder.Version
+}
+
+// ExcludedFolders возвращает исключения, сохраненные в манифесте targetDir
+func ExcludedFolders(targetDir string) ([]ExcludedFolder, error) {
+	manifest, err := readManifest(targetDir)
+	return manifest.Excluded, err
+}
+
+// ClearExcludedFolders забывает исключения, сохраненные прошлыми запусками
+func ClearExcludedFolders(targetDir string) error {
+	manifest, err := readManifest(targetDir)
+	if err != nil || len(manifest.Excluded) == 0 {
+		return err
+	}
+	manifest.Excluded = nil
+	return writeManifest(targetDir, manifest)
+}
+
+// applyExclusions убирает из списка исключенные папки. Исключения берутся из
+// манифеста и из Config.ExcludeFolders (путь или имя папки); возвращается
+// также пропущенные папки и полный список исключений для манифеста
+func applyExclusions(config Config, folders []FolderInfo) ([]FolderInfo, []FolderInfo, []ExcludedFolder) {
+	var remembered []ExcludedFolder
+	if !config.NoGit {
+		manifest, err := readManifest(config.TargetDir)
+		if err != nil {
+			config.logger().Printf("Предупреждение: не удалось прочитать манифест миграции: %v", err)
+		}
+		remembered = manifest.Excluded
+	}
+
+	requested := make(map[string]bool, len(config.ExcludeFolders))
+	for _, entry := range config.ExcludeFolders {
+		requested[entry] = true
+	}
+
+	all := remembered
+	var kept []FolderInfo
+	var skipped []FolderInfo
+	for _, folder := range folders {
+		name := filepath.Base(folder.Path)
+		if requested[name] || requested[folder.Path] || requested[absPath(folder.Path)] {
+			config.logger().Printf("Пропуск версии %s (%s): исключено пользователем", Printable(folder.Version), Printable(name))
+			skipped = append(skipped, folder)
+			if !excludedIn(remembered, folder) {
+				all = append(all, ExcludedFolder{Path: absPath(folder.Path), Version: folder.Version})
+			}
+			continue
+		}
+		if excludedIn(remembered, folder) {
+			config.logger().Printf("Пропуск версии %s (%s): ранее исключено пользователем", Printable(folder.Version), Printable(name))
+			skipped = append(skipped, folder)
+			continue
+		}
+		kept = append(kept, folder)
+	}
+	return kept, skipped, all
+}
+
+// saveExclusions сохраняет список исключений в манифесте targetDir
+func saveExclusions(targetDir string, excluded []ExcludedFolder) error {
+	manifest, err := readManifest(targetDir)
+	if err != nil {
+		return err
+	}
+	manifest.Excluded = excluded
+	return writeManifest(targetDir, manifest)
+}
+
+// excludedIn проверяет, есть ли папка в списке исключений
+func excludedIn(excluded []ExcludedFolder, folder FolderInfo) bool {
+	for _, e := range excluded {
+		if e.Matches(folder) {
+			return true
+		}
+	}
+	return false
+}
+
+// absPath возвращает абсолютный путь, а при ошибке — исходный
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// folderVersions возвращает версии папок
+func folderVersions(folders []FolderInfo) []string {
+	versions := make([]string, 0, len(folders))
+	for _, folder := range folders {
+		versions = append(versions, folder.Version)
+	}
+	return versions
+}
@@ -0,0 +1,25 @@
+package gitconverter
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// fileModes хранит права исходных файлов по путям в репозитории. Права
+// переносятся в индекс явно, поэтому исполняемый бит не теряется, даже если
+// файловая система рабочей директории не умеет его хранить
+type fileModes map[string]os.FileMode
+
+// record запоминает права файла, скопированного в репозиторий по пути rel
+func (m fileModes) record(rel, src string, info os.FileInfo) {
+	if m == nil || !info.Mode().IsRegular() {
+		return
+	}
+	m[filepath.ToSlash(rel)] = sourceFileMode(src, info.Mode())
+}
+
+// execChanged проверяет, отличается ли исполняемость файла. Git хранит
+// только этот бит, остальные изменения прав в историю не попадают
+func execChanged(a, b os.FileMode) bool {
+	return (a.Perm()&0111 != 0) != (b.Perm()&0111 != 0)
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package gitconverter
+
+import "os"
+
+// sourceFileMode возвращает права исходного файла для индекса
+func sourceFileMode(path string, mode os.FileMode) os.FileMode {
+	return mode
+}
@@ -0,0 +1,190 @@
+package gitconverter
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// script — содержимое исполняемого файла. Строка #! нужна для Windows, где
+// исполняемость определяется по ней
+const script = "#!/bin/sh\necho ok\n"
+
+func TestCommitKeepsExecutableBit(t *testing.T) {
+	src := versionSource(t, map[string]string{
+		"run.sh":        script,
+		"README":        "text\n",
+		"bin/tool":      script,
+		"bin/data.conf": "key=value\n",
+	})
+	for _, name := range []string{"run.sh", "bin/tool"} {
+		if err := os.Chmod(filepath.Join(src, "v1", filepath.FromSlash(name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dst := t.TempDir()
+	mustRun(t, testConfig(src, dst))
+
+	commits := history(t, dst)
+	if len(commits) != 1 {
+		t.Fatalf("коммитов %d, ожидался 1", len(commits))
+	}
+	want := map[string]filemode.FileMode{
+		"run.sh":        filemode.Executable,
+		"bin/tool":      filemode.Executable,
+		"README":        filemode.Regular,
+		"bin/data.conf": filemode.Regular,
+	}
+	files := treeFiles(t, commits[0])
+	for name, mode := range want {
+		if got := files[name].Mode; got != mode.String() {
+			t.Errorf("%s: режим %s, ожидался %s", name, got, mode)
+		}
+	}
+}
+
+func TestExecutableBitChangeIsCommitted(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows не хранит исполняемый бит")
+	}
+	src := versionSource(t,
+		map[string]string{"run.sh": script, "lib.sh": script},
+		map[string]string{"run.sh": script, "lib.sh": script},
+	)
+	if err := os.Chmod(filepath.Join(src, "v1", "lib.sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(src, "v2", "run.sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, incremental := range []bool{false, true} {
+		dst := t.TempDir()
+		config := testConfig(src, dst)
+		config.Incremental = incremental
+		mustRun(t, config)
+
+		commits := history(t, dst)
+		if len(commits) != 2 {
+			t.Fatalf("incremental=%v: коммитов %d, ожидалось 2", incremental, len(commits))
+		}
+		v1, v2 := treeFiles(t, commits[0]), treeFiles(t, commits[1])
+		checks := []struct {
+			files map[string]treeFile
+			name  string
+			mode  filemode.FileMode
+		}{
+			{v1, "run.sh", filemode.Regular},
+			{v1, "lib.sh", filemode.Executable},
+			{v2, "run.sh", filemode.Executable},
+			{v2, "lib.sh", filemode.Regular},
+		}
+		for i, c := range checks {
+			if got := c.files[c.name].Mode; got != c.mode.String() {
+				t.Errorf("incremental=%v, версия %d: %s имеет режим %s, ожидался %s", incremental, i/2+1, c.name, got, c.mode)
+			}
+		}
+	}
+}
+
+// Рабочая директория может не хранить исполняемый бит (FAT, общие папки
+// Windows). Режим из карты прав копирования должен попасть в индекс и тогда
+func TestStagerUsesRecordedModes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"run.sh": script, "sub/plain.txt": "text\n"})
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modes := fileModes{"run.sh": 0755, "sub/plain.txt": 0644}
+	stager, err := newIndexStager(repo, dir, modes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rel := range []string{"run.sh", filepath.Join("sub", "plain.txt")} {
+		if err := stager.add(rel); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := stager.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := worktree.Commit("v1", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: testEpoch},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := treeFiles(t, commit)
+	if got := files["run.sh"].Mode; got != filemode.Executable.String() {
+		t.Errorf("run.sh: режим %s, ожидался %s", got, filemode.Executable)
+	}
+	if got := files["sub/plain.txt"].Mode; got != filemode.Regular.String() {
+		t.Errorf("sub/plain.txt: режим %s, ожидался %s", got, filemode.Regular)
+	}
+}
+
+func TestExecChanged(t *testing.T) {
+	tests := []struct {
+		a, b os.FileMode
+		want bool
+	}{
+		{0644, 0644, false},
+		{0644, 0600, false},
+		{0755, 0700, false},
+		{0644, 0755, true},
+		{0755, 0644, true},
+		{0640, 0650, true},
+	}
+	for _, tt := range tests {
+		if got := execChanged(tt.a, tt.b); got != tt.want {
+			t.Errorf("execChanged(%v, %v) = %v, ожидалось %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFileModesRecord(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.sh": script})
+	path := filepath.Join(dir, "a.sh")
+	if err := os.Chmod(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dirInfo, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modes := make(fileModes)
+	modes.record(filepath.Join("sub", "a.sh"), path, info)
+	modes.record("sub", dir, dirInfo)
+	if got := modes["sub/a.sh"]; got.Perm()&0111 == 0 {
+		t.Errorf("права sub/a.sh %v, ожидался исполняемый файл", got)
+	}
+	if _, ok := modes["sub"]; ok {
+		t.Error("права директории записаны")
+	}
+
+	var none fileModes
+	none.record("a.sh", path, info) // nil-карта не должна паниковать
+}
@@ -0,0 +1,24 @@
+//go:build windows
+
+package gitconverter
+
+import (
+	"io"
+	"os"
+)
+
+// sourceFileMode возвращает права исходного файла для индекса. Windows не
+// хранит исполняемый бит, поэтому скрипты из Linux-архивов распознаются по #!
+func sourceFileMode(path string, mode os.FileMode) os.FileMode {
+	f, err := os.Open(path)
+	if err != nil {
+		return mode
+	}
+	defer f.Close()
+
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(f, head); err == nil && string(head) == "#!" {
+		return mode | 0111
+	}
+	return mode
+}
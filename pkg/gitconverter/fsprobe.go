@@ -0,0 +1,81 @@
+package gitconverter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FilesystemProbe описывает возможности файловой системы целевой директории
+type FilesystemProbe struct {
+	Executable    bool `json:"executable"`    // Хранит исполняемый бит
+	Symlinks      bool `json:"symlinks"`      // Позволяет создавать символические ссылки
+	CaseSensitive bool `json:"caseSensitive"` // Различает имена, отличающиеся только регистром
+}
+
+// Losses перечисляет, что будет потеряно в рабочей директории на этой файловой системе
+func (p FilesystemProbe) Losses() []string {
+	var losses []string
+	if !p.Executable {
+		losses = append(losses, "исполняемый бит файлов (в коммитах он сохраняется)")
+	}
+	if !p.Symlinks {
+		losses = append(losses, "символические ссылки")
+	}
+	if !p.CaseSensitive {
+		losses = append(losses, "файлы, имена которых отличаются только регистром")
+	}
+	return losses
+}
+
+// probeFilesystem проверяет возможности файловой системы dir на временных файлах
+func probeFilesystem(dir string) (FilesystemProbe, error) {
+	var probe FilesystemProbe
+
+	tmp, err := os.MkdirTemp(dir, ".folder-to-git-probe-")
+	if err != nil {
+		return probe, err
+	}
+	defer os.RemoveAll(tmp)
+
+	file := filepath.Join(tmp, "Probe")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		return probe, err
+	}
+
+	if err := os.Chmod(file, 0755); err == nil {
+		if info, err := os.Stat(file); err == nil {
+			probe.Executable = info.Mode().Perm()&0100 != 0
+		}
+	}
+
+	probe.Symlinks = os.Symlink("Probe", filepath.Join(tmp, "link")) == nil
+
+	_, err = os.Stat(filepath.Join(tmp, "probe"))
+	probe.CaseSensitive = os.IsNotExist(err)
+
+	return probe, nil
+}
+
+// checkTargetFilesystem проверяет файловую систему TargetDir и предупреждает
+// о том, что будет потеряно, а в режиме Strict отказывается продолжать
+func checkTargetFilesystem(config Config) (*FilesystemProbe, error) {
+	probe, err := probeFilesystem(config.TargetDir)
+	if err != nil {
+		config.logger().Printf("Предупреждение: не удалось проверить файловую систему %s: %v", config.TargetDir, err)
+		return nil, nil
+	}
+
+	losses := probe.Losses()
+	if len(losses) == 0 {
+		return &probe, nil
+	}
+	if config.Strict {
+		return &probe, fmt.Errorf("файловая система %s не сохранит: %s", config.TargetDir, strings.Join(losses, ", "))
+	}
+	for _, loss := range losses {
+		config.logger().Printf("Предупреждение: файловая система %s не сохранит %s", config.TargetDir, loss)
+	}
+	return &probe, nil
+}
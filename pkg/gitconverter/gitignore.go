@@ -0,0 +1,140 @@
+package gitconverter
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// gitignoreFile — имя файла правил игнорирования в корне репозитория
+const gitignoreFile = ".gitignore"
+
+// gitignoreHeader открывает раздел, добавленный конвертером
+const gitignoreHeader = "# Добавлено folder-to-git: файлы, пропущенные при переносе версий"
+
+// gitignoreRules возвращает правила для .gitignore, соответствующие
+// спискам игнорирования ignore. Служебные файлы Git в них не попадают
+func gitignoreRules(ignore ignoreRules) []string {
+	var rules []string
+	for _, dir := range ignore.dirs {
+		if dir == ".git" {
+			continue
+		}
+		rules = append(rules, dir+"/")
+	}
+	for _, pattern := range ignore.files {
+		if pattern == ".gitignore" || pattern == ".gitattributes" {
+			continue
+		}
+		rules = append(rules, pattern)
+	}
+	return rules
+}
+
+// gitignoreContent объединяет .gitignore исходной папки с правилами
+// конвертера: правила источника сохраняются, недостающие дописываются в конец
+func gitignoreContent(srcFolder string, ignore ignoreRules) ([]byte, error) {
+	existing, err := os.ReadFile(filepath.Join(srcFolder, gitignoreFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	present := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(existing))
+	for scanner.Scan() {
+		present[strings.TrimSpace(scanner.Text())] = true
+	}
+
+	var missing []string
+	for _, rule := range gitignoreRules(ignore) {
+		if !present[rule] {
+			missing = append(missing, rule)
+		}
+	}
+
+	var b bytes.Buffer
+	b.Write(existing)
+	if len(missing) == 0 {
+		return b.Bytes(), nil
+	}
+	if len(existing) > 0 {
+		if !bytes.HasSuffix(existing, []byte("\n")) {
+			b.WriteByte('\n')
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString(gitignoreHeader + "\n")
+	for _, rule := range missing {
+		b.WriteString(rule + "\n")
+	}
+	return b.Bytes(), nil
+}
+
+// writeGitignore записывает .gitignore в корень рабочей директории
+func writeGitignore(config Config, srcFolder string) error {
+	ignore, err := newIgnoreRules(config)
+	if err != nil {
+		return err
+	}
+	content, err := gitignoreContent(srcFolder, ignore)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(config.TargetDir, gitignoreFile), content, 0644)
+}
+
+// readSourceGitignore собирает правила .gitignore исходной папки root и ее
+// поддиректорий. Правила вложенного файла действуют только в его поддереве,
+// директории из списков ignore и уже исключенные директории не читаются
+func readSourceGitignore(root string, ignore ignoreRules) (gitignore.Matcher, error) {
+	var patterns []gitignore.Pattern
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		var domain []string
+		if path != root {
+			if ignore.dir(d.Name()) {
+				return filepath.SkipDir
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			domain = strings.Split(filepath.ToSlash(rel), "/")
+			if gitignore.NewMatcher(patterns).Match(domain, true) {
+				return filepath.SkipDir
+			}
+		}
+
+		data, err := os.ReadFile(filepath.Join(path, gitignoreFile))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			line := strings.TrimSuffix(scanner.Text(), "\r")
+			if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+				continue
+			}
+			patterns = append(patterns, gitignore.ParsePattern(line, domain))
+		}
+		return scanner.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return gitignore.NewMatcher(patterns), nil
+}
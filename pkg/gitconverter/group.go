@@ -0,0 +1,185 @@
+package gitconverter
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// GroupPeriod определяет период, снимки за который сворачиваются в один коммит
+type GroupPeriod string
+
+const (
+	GroupNone  GroupPeriod = "none"  // Коммит на каждую папку (по умолчанию)
+	GroupDay   GroupPeriod = "day"   // Последний снимок каждого дня
+	GroupWeek  GroupPeriod = "week"  // Последний снимок каждой недели ISO (с понедельника)
+	GroupMonth GroupPeriod = "month" // Последний снимок каждого месяца
+)
+
+// validateGroupBy проверяет значение GroupBy
+func validateGroupBy(period GroupPeriod) error {
+	switch period {
+	case "", GroupNone, GroupDay, GroupWeek, GroupMonth:
+		return nil
+	}
+	return fmt.Errorf("неизвестный период группировки %q", period)
+}
+
+// periodKey возвращает период, в который попадает время t, по местному времени
+func periodKey(period GroupPeriod, t time.Time) string {
+	switch period {
+	case GroupDay:
+		return t.Format("2006-01-02")
+	case GroupWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case GroupMonth:
+		return t.Format("2006-01")
+	}
+	return ""
+}
+
+// groupByPeriod оставляет из папок каждого периода только самую позднюю по
+// времени создания, остальные перечисляются в ее Collapsed. Порядок
+// оставшихся папок сохраняется. Папки из exclude не группируются и
+// остаются как есть
+func groupByPeriod(folders []FolderInfo, exclude map[string]bool, period GroupPeriod, logger Logger) []FolderInfo {
+	if period == "" || period == GroupNone {
+		return folders
+	}
+
+	newest := make(map[string]int)
+	keys := make([]string, len(folders))
+	for i, folder := range folders {
+		if exclude[folder.Path] {
+			continue
+		}
+		keys[i] = periodKey(period, time.Unix(folder.CreationTime, 0))
+		if j, ok := newest[keys[i]]; !ok || folder.CreationTime >= folders[j].CreationTime {
+			newest[keys[i]] = i
+		}
+	}
+
+	collapsed := make(map[int][]FolderInfo)
+	for i, folder := range folders {
+		if exclude[folder.Path] {
+			continue
+		}
+		if j := newest[keys[i]]; j != i {
+			collapsed[j] = append(collapsed[j], folder)
+		}
+	}
+
+	grouped := make([]FolderInfo, 0, len(newest))
+	for i, folder := range folders {
+		if !exclude[folder.Path] && newest[keys[i]] != i {
+			continue
+		}
+		if snapshots := collapsed[i]; len(snapshots) > 0 {
+			folder.Collapsed = snapshots
+			names := make([]string, len(snapshots))
+			for k, snapshot := range snapshots {
+				names[k] = Printable(filepath.Base(snapshot.Path))
+			}
+			logger.Printf("Период %s: в коммит версии %s свернуты снимки %s",
+				keys[i], Printable(folder.Version), strings.Join(names, ", "))
+		}
+		grouped = append(grouped, folder)
+	}
+	return grouped
+}
+
+// groupExclusions возвращает папки, которые не группируются: пропущенные
+// skipped и в режиме добавления уже перенесенные версии. Иначе снимок,
+// перенесенный прошлым запуском, свернулся бы в коммит нового снимка того
+// же периода
+func groupExclusions(config Config, folders, skipped []FolderInfo) map[string]bool {
+	exclude := make(map[string]bool, len(skipped))
+	for _, folder := range skipped {
+		exclude[folder.Path] = true
+	}
+	if !config.Append || config.GroupBy == "" || config.GroupBy == GroupNone {
+		return exclude
+	}
+	repo, err := openRepository(config.TargetDir)
+	if err != nil {
+		return exclude
+	}
+	migrated, err := migratedVersions(repo, config.TargetDir)
+	if err != nil {
+		config.logger().Printf("Предупреждение: %v", err)
+		return exclude
+	}
+	for _, folder := range folders {
+		if migrated[folder.Version] {
+			exclude[folder.Path] = true
+		}
+	}
+	return exclude
+}
+
+// migratedVersions возвращает версии, которые уже есть в репозитории:
+// коммиты на вершинах ссылок и снимки, свернутые GroupBy в прошлых запусках
+func migratedVersions(repo *git.Repository, targetDir string) (map[string]bool, error) {
+	versions, err := repoVersions(repo)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := readManifest(targetDir)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения манифеста миграции: %v", err)
+	}
+	for _, snapshot := range manifest.Collapsed {
+		versions[snapshot.Version] = true
+	}
+	return versions, nil
+}
+
+// collapsedMessage дописывает к сообщению коммита список свернутых снимков
+func collapsedMessage(msg string, folder FolderInfo) string {
+	if len(folder.Collapsed) == 0 {
+		return msg
+	}
+	names := make([]string, len(folder.Collapsed))
+	for i, snapshot := range folder.Collapsed {
+		names[i] = Printable(filepath.Base(snapshot.Path))
+	}
+	return strings.TrimRight(msg, "\n") + "\n\nCollapsed snapshots: " + strings.Join(names, ", ")
+}
+
+// collapsedVersions возвращает версии снимков, свернутых в папки folders
+func collapsedVersions(folders []FolderInfo) []string {
+	var versions []string
+	for _, folder := range folders {
+		versions = append(versions, folderVersions(folder.Collapsed)...)
+	}
+	return versions
+}
+
+// CollapsedSnapshot описывает снимок, свернутый GroupBy в коммит более
+// позднего снимка того же периода
+type CollapsedSnapshot struct {
+	Version      string    `json:"version"`
+	Path         string    `json:"path"`
+	CreationTime time.Time `json:"creationTime"`
+	Into         string    `json:"into"` // Версия, в коммит которой свернут снимок
+}
+
+// collapsedSnapshots перечисляет снимки, свернутые в папки folders
+func collapsedSnapshots(folders []FolderInfo) []CollapsedSnapshot {
+	var snapshots []CollapsedSnapshot
+	for _, folder := range folders {
+		for _, snapshot := range folder.Collapsed {
+			snapshots = append(snapshots, CollapsedSnapshot{
+				Version:      snapshot.Version,
+				Path:         snapshot.Path,
+				CreationTime: time.Unix(snapshot.CreationTime, 0),
+				Into:         folder.Version,
+			})
+		}
+	}
+	return snapshots
+}
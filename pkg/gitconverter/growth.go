@@ -0,0 +1,109 @@
+package gitconverter
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// growthSampleSize — сколько байт с начала, середины и конца файла
+// учитывается при сравнении содержимого для оценки роста репозитория
+const growthSampleSize = 64 * 1024
+
+// VersionGrowth — приблизительная оценка прироста репозитория от версии.
+// Содержимое сравнивается по размеру и выборке байт, а не целиком, и сжатие
+// Git не учитывается, поэтому это оценка сверху
+type VersionGrowth struct {
+	Version         string `json:"version"`
+	Files           int    `json:"files"`
+	Bytes           int64  `json:"bytes"`           // Размер всех файлов версии
+	NewBytes        int64  `json:"newBytes"`        // Содержимое, которого не было в предыдущих версиях
+	ReusedBytes     int64  `json:"reusedBytes"`     // Содержимое, уже встречавшееся раньше
+	CumulativeBytes int64  `json:"cumulativeBytes"` // Оценка размера репозитория после этой версии
+}
+
+// EstimateGrowth оценивает, сколько нового содержимого добавит каждая версия
+// и каким станет репозиторий. Ничего не записывает на диск
+func EstimateGrowth(config Config, folders []FolderInfo) ([]VersionGrowth, error) {
+	filter, err := newContentFilter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[[sha256.Size]byte]bool)
+	var total int64
+	growth := make([]VersionGrowth, 0, len(folders))
+
+	for _, folder := range folders {
+		files, err := listFiles(folder.Path, false, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		g := VersionGrowth{Version: folder.Version, Files: len(files)}
+		for rel := range files {
+			path := filepath.Join(folder.Path, rel)
+			key, size, err := sampleKey(path)
+			if err != nil {
+				return nil, err
+			}
+			g.Bytes += size
+			if seen[key] {
+				g.ReusedBytes += size
+				continue
+			}
+			seen[key] = true
+			g.NewBytes += size
+		}
+		total += g.NewBytes
+		g.CumulativeBytes = total
+		growth = append(growth, g)
+	}
+	return growth, nil
+}
+
+// sampleKey вычисляет ключ содержимого файла по размеру и выборке из начала,
+// середины и конца. Небольшие файлы хешируются целиком
+func sampleKey(path string) ([sha256.Size]byte, int64, error) {
+	var key [sha256.Size]byte
+
+	f, err := os.Open(path)
+	if err != nil {
+		return key, 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return key, 0, err
+	}
+	size := info.Size()
+
+	h := sha256.New()
+	binary.Write(h, binary.LittleEndian, size)
+	if size <= 3*growthSampleSize {
+		if _, err := io.Copy(h, f); err != nil {
+			return key, 0, err
+		}
+	} else {
+		for _, offset := range []int64{0, size/2 - growthSampleSize/2, size - growthSampleSize} {
+			if _, err := io.Copy(h, io.NewSectionReader(f, offset, growthSampleSize)); err != nil {
+				return key, 0, err
+			}
+		}
+	}
+	copy(key[:], h.Sum(nil))
+	return key, size, nil
+}
+
+// logGrowth выводит оценку роста репозитория по версиям
+func logGrowth(growth []VersionGrowth, logger Logger) {
+	logger.Printf("Оценка роста репозитория (приблизительно, без учета сжатия Git):")
+	for _, g := range growth {
+		logger.Printf("  %s: %d файлов, %s; новое содержимое %s, повторное %s; репозиторий ~%s",
+			g.Version, g.Files, FormatSize(g.Bytes), FormatSize(g.NewBytes),
+			FormatSize(g.ReusedBytes), FormatSize(g.CumulativeBytes))
+	}
+}
@@ -0,0 +1,216 @@
+package gitconverter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// hashCacheFile — файл кэша хешей в каталоге конвертера внутри служебной папки Git
+const hashCacheFile = "hashcache.json"
+
+// hashEntry — хеш содержимого файла вместе с данными stat, при которых он посчитан
+type hashEntry struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"` // Наносекунды Unix
+	Hash    string `json:"hash"`  // Хеш объекта blob Git
+}
+
+// hashCache хранит хеши файлов между запусками, чтобы не перечитывать
+// неизменившиеся папки. Запись устаревает, как только меняется размер или
+// время изменения файла. Нулевой *hashCache считает хеши без кэша
+type hashCache struct {
+	path     string
+	logger   Logger
+	paranoid bool // Не доверять записям: хеши считаются заново и только обновляют кэш
+
+	mu      sync.Mutex
+	entries map[string]hashEntry // Абсолютный путь -> хеш
+	blobs   map[string]hashEntry // Путь в репозитории -> блоб, записанный в этом запуске, и stat его источника
+	dirty   bool
+	hits    int
+	misses  int
+}
+
+// hashCachePath возвращает путь файла кэша для репозитория в targetDir
+func hashCachePath(targetDir string) string {
+	return filepath.Join(gitDirPath(targetDir), manifestDir, hashCacheFile)
+}
+
+// loadHashCache читает кэш хешей репозитория. Поврежденный кэш не мешает
+// миграции: он начинается заново
+func loadHashCache(targetDir string, logger Logger) *hashCache {
+	cache := &hashCache{path: hashCachePath(targetDir), logger: logger, entries: make(map[string]hashEntry), blobs: make(map[string]hashEntry)}
+	data, err := os.ReadFile(cache.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Printf("Предупреждение: не удалось прочитать кэш хешей: %v", err)
+		}
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache.entries); err != nil {
+		logger.Printf("Предупреждение: кэш хешей поврежден и будет создан заново: %v", err)
+		cache.entries = make(map[string]hashEntry)
+	}
+	return cache
+}
+
+// ClearHashCache удаляет кэш хешей файлов репозитория в targetDir
+func ClearHashCache(targetDir string) error {
+	err := os.Remove(hashCachePath(targetDir))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// blobHash возвращает хеш объекта blob для файла, по возможности из кэша
+func (c *hashCache) blobHash(path string) (plumbing.Hash, error) {
+	if c == nil {
+		return blobHash(path)
+	}
+
+	key := absPath(path)
+	info, err := os.Stat(key)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if hash, ok := c.cached(key, info); ok {
+		return hash, nil
+	}
+
+	hash, err := blobHash(key)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	c.remember(key, info, hash)
+	return hash, nil
+}
+
+// cached возвращает хеш файла path с данными stat info из кэша, не читая
+// файл. Второе значение ложно, если записи нет, она устарела или кэшу не
+// доверяют
+func (c *hashCache) cached(path string, info os.FileInfo) (plumbing.Hash, bool) {
+	if c == nil {
+		return plumbing.ZeroHash, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[absPath(path)]
+	if ok && !c.paranoid && entry.Size == info.Size() && entry.ModTime == info.ModTime().UnixNano() {
+		c.hits++
+		return plumbing.NewHash(entry.Hash), true
+	}
+	c.misses++
+	return plumbing.ZeroHash, false
+}
+
+// reusableBlob возвращает хеш блоба файла name в репозитории, скопированного
+// из src с данными stat info, не читая файл: по записи самого источника или
+// по блобу, записанному для того же пути из предыдущей версии, источник
+// которого имел тот же размер и время изменения
+func (c *hashCache) reusableBlob(src, name string, info os.FileInfo) (plumbing.Hash, bool) {
+	if c == nil || c.paranoid {
+		return plumbing.ZeroHash, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, entry := range []hashEntry{c.entries[absPath(src)], c.blobs[name]} {
+		if entry.Hash != "" && entry.Size == info.Size() && entry.ModTime == info.ModTime().UnixNano() {
+			c.hits++
+			return plumbing.NewHash(entry.Hash), true
+		}
+	}
+	return plumbing.ZeroHash, false
+}
+
+// rememberBlob записывает хеш блоба файла name, скопированного из src с
+// данными stat info
+func (c *hashCache) rememberBlob(src, name string, info os.FileInfo, hash plumbing.Hash) {
+	if c == nil {
+		return
+	}
+	c.remember(src, info, hash)
+	c.mu.Lock()
+	c.blobs[name] = hashEntry{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Hash: hash.String()}
+	c.mu.Unlock()
+}
+
+// remember записывает хеш файла path, посчитанный при данных stat info
+func (c *hashCache) remember(path string, info os.FileInfo, hash plumbing.Hash) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.entries[absPath(path)] = hashEntry{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Hash: hash.String()}
+	c.dirty = true
+	c.mu.Unlock()
+}
+
+// sameContent сравнивает права и содержимое двух файлов, по возможности
+// сверяя хеши из кэша вместо побайтного чтения
+func (c *hashCache) sameContent(a, b string) (bool, error) {
+	if c == nil {
+		return sameContent(a, b)
+	}
+
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	if infoA.Size() != infoB.Size() || infoA.Mode().Perm() != infoB.Mode().Perm() {
+		return false, nil
+	}
+
+	hashA, err := c.blobHash(a)
+	if err != nil {
+		return false, err
+	}
+	hashB, err := c.blobHash(b)
+	if err != nil {
+		return false, err
+	}
+	return hashA == hashB, nil
+}
+
+// save записывает кэш на диск, если он изменился, и выводит статистику
+func (c *hashCache) save(verbose bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if verbose {
+		c.logger.Printf("Кэш хешей: попаданий %d, промахов %d", c.hits, c.misses)
+	}
+	if !c.dirty {
+		return
+	}
+
+	// Записи об удаленных файлах больше не пригодятся
+	for path := range c.entries {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			delete(c.entries, path)
+		}
+	}
+
+	data, err := json.Marshal(c.entries)
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(c.path), 0755); err == nil {
+			err = os.WriteFile(c.path, data, 0644)
+		}
+	}
+	if err != nil {
+		c.logger.Printf("Предупреждение: не удалось сохранить кэш хешей: %v", err)
+		return
+	}
+	c.dirty = false
+}
@@ -0,0 +1,168 @@
+package gitconverter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// healthCheckDepth — сколько коммитов от HEAD проверяет Check. Повреждения
+// после сбоя обычно в последних записанных объектах, а полный обход большой
+// истории занял бы слишком много времени
+const healthCheckDepth = 100
+
+// Check проверяет, что в репозиторий TargetDir можно добавлять версии:
+// коммиты на глубину до 100 от HEAD и их деревья читаются, объекты файлов
+// HEAD на месте, индекс читается и ссылается только на существующие объекты.
+// Повреждения возвращаются ошибкой, оборачивающей ErrRepoUnhealthy. Если их
+// нет, но индекс или рабочая директория отличаются от HEAD, возвращается
+// ErrDirtyWorktree. Пустой репозиторий исправен. Перед добавлением версий
+// та же проверка выполняется автоматически
+func Check(config Config) error {
+	if config.TargetDir == "" {
+		return fmt.Errorf("%w: не указана директория репозитория", ErrInvalidConfig)
+	}
+	repo, err := openRepository(config.TargetDir)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия репозитория: %v", err)
+	}
+	bare := isBareRepository(config.TargetDir)
+	if err := checkRepository(repo, bare); err != nil || bare {
+		return err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("ошибка получения рабочей директории: %v", err)
+	}
+	return checkCleanWorktree(worktree, toolManagedPaths(config))
+}
+
+// checkRepository проверяет открытый репозиторий; у голого репозитория нет
+// индекса, и он не сравнивается
+func checkRepository(repo *git.Repository, bare bool) error {
+	problems := repoProblems(repo, bare)
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: найдено проблем: %d: %s", ErrRepoUnhealthy, len(problems),
+		escapeControl(strings.Join(firstN(problems, verifyExamples), "; ")))
+}
+
+// repoProblems возвращает описания найденных повреждений
+func repoProblems(repo *git.Repository, bare bool) []string {
+	head, err := repo.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		return nil
+	}
+	if err != nil {
+		return []string{fmt.Sprintf("HEAD не читается: %v", err)}
+	}
+
+	var problems []string
+	commits := make(map[plumbing.Hash]bool)
+	trees := make(map[plumbing.Hash]bool)
+	queue := []plumbing.Hash{head.Hash()}
+	var headTree *object.Tree
+	for len(queue) > 0 && len(commits) < healthCheckDepth {
+		hash := queue[0]
+		queue = queue[1:]
+		if commits[hash] {
+			continue
+		}
+		commits[hash] = true
+
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("коммит %s не читается: %v", hash, err))
+			continue
+		}
+		tree, err := commit.Tree()
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("дерево коммита %s не читается: %v", hash, err))
+			continue
+		}
+		if hash == head.Hash() {
+			headTree = tree
+		}
+		problems = append(problems, treeProblems(repo, tree, "", hash == head.Hash(), trees)...)
+		queue = append(queue, commit.ParentHashes...)
+	}
+
+	if headTree != nil && !bare {
+		problems = append(problems, indexProblems(repo, headTree)...)
+	}
+	return problems
+}
+
+// treeProblems проверяет, что поддеревья tree читаются. С blobs проверяется
+// и наличие объектов файлов. Уже проверенные деревья в checked пропускаются:
+// соседние коммиты разделяют большую часть деревьев
+func treeProblems(repo *git.Repository, tree *object.Tree, dir string, blobs bool, checked map[plumbing.Hash]bool) []string {
+	var problems []string
+	for _, entry := range tree.Entries {
+		path := entry.Name
+		if dir != "" {
+			path = dir + "/" + entry.Name
+		}
+		switch entry.Mode {
+		case filemode.Dir:
+			if checked[entry.Hash] {
+				continue
+			}
+			subtree, err := repo.TreeObject(entry.Hash)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("дерево %s (%s) не читается: %v", path, entry.Hash, err))
+				continue
+			}
+			problems = append(problems, treeProblems(repo, subtree, path, blobs, checked)...)
+		case filemode.Submodule:
+		default:
+			if blobs && repo.Storer.HasEncodedObject(entry.Hash) != nil {
+				problems = append(problems, fmt.Sprintf("нет объекта файла %s (%s)", path, entry.Hash))
+			}
+		}
+	}
+	checked[tree.Hash] = true
+	return problems
+}
+
+// indexProblems сравнивает индекс с деревом HEAD. Запись, отличающаяся от
+// HEAD, — повреждение, только если ее объекта нет: иначе это подготовленное
+// к коммиту изменение, о котором сообщает checkCleanWorktree
+func indexProblems(repo *git.Repository, headTree *object.Tree) []string {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return []string{fmt.Sprintf("индекс не читается: %v", err)}
+	}
+
+	inHead := make(map[string]plumbing.Hash)
+	err = headTree.Files().ForEach(func(f *object.File) error {
+		inHead[f.Name] = f.Hash
+		return nil
+	})
+	if err != nil {
+		// Причина уже описана при обходе дерева HEAD
+		return nil
+	}
+
+	var missing []string
+	for _, entry := range idx.Entries {
+		if entry.Mode == filemode.Submodule || inHead[entry.Name] == entry.Hash {
+			continue
+		}
+		if repo.Storer.HasEncodedObject(entry.Hash) != nil {
+			missing = append(missing, entry.Name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return []string{fmt.Sprintf("индекс не совпадает с HEAD и ссылается на отсутствующие объекты %d файлов, например %s",
+		len(missing), strings.Join(firstN(missing, verifyExamples), ", "))}
+}
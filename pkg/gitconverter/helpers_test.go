@@ -0,0 +1,228 @@
+package gitconverter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// testLogger собирает сообщения лога, чтобы тесты могли их проверить
+type testLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *testLogger) Printf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+// contains проверяет, что какое-то сообщение содержит substr
+func (l *testLogger) contains(substr string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, line := range l.lines {
+		if strings.Contains(line, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// testEpoch — дата первой версии в тестовых источниках
+var testEpoch = time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// writeFiles создает в root файлы files: путь через "/" → содержимое
+func writeFiles(tb testing.TB, root string, files map[string]string) {
+	tb.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+// setTreeTime задает время изменения всем файлам и папкам dir
+func setTreeTime(tb testing.TB, dir string, when time.Time) {
+	tb.Helper()
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+		return os.Chtimes(path, when, when)
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// versionSource создает директорию с папками v1, v2, … — по одной на
+// элемент versions. Папка vN датирована N-1 днями позже testEpoch
+func versionSource(tb testing.TB, versions ...map[string]string) string {
+	tb.Helper()
+	src := tb.TempDir()
+	for i, files := range versions {
+		dir := filepath.Join(src, fmt.Sprintf("v%d", i+1))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			tb.Fatal(err)
+		}
+		writeFiles(tb, dir, files)
+		setTreeTime(tb, dir, testEpoch.AddDate(0, 0, i))
+	}
+	return src
+}
+
+// testConfig возвращает настройки переноса папок v1, v2, … из src в dst
+func testConfig(src, dst string) Config {
+	return Config{
+		SourceDir:      src,
+		TargetDir:      dst,
+		Pattern:        "v*",
+		ExtractPattern: "[0-9]+",
+		Author:         "Test",
+		Email:          "test@example.com",
+		Logger:         &testLogger{},
+	}
+}
+
+// runConverter сканирует источник и переносит найденные версии
+func runConverter(tb testing.TB, config Config) (*MigrationResult, error) {
+	tb.Helper()
+	c, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+	return c.Run()
+}
+
+// mustRun выполняет runConverter и завершает тест при ошибке
+func mustRun(tb testing.TB, config Config) *MigrationResult {
+	tb.Helper()
+	result, err := runConverter(tb, config)
+	if err != nil {
+		tb.Fatalf("миграция: %v", err)
+	}
+	return result
+}
+
+// history возвращает коммиты ветки HEAD репозитория dir от первого к последнему
+func history(tb testing.TB, dir string) []*object.Commit {
+	tb.Helper()
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	iter, err := repo.Log(&git.LogOptions{From: head.Hash()})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var commits []*object.Commit
+	err = iter.ForEach(func(c *object.Commit) error {
+		commits = append([]*object.Commit{c}, commits...)
+		return nil
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return commits
+}
+
+// treeFile — файл дерева коммита
+type treeFile struct {
+	Mode    string
+	Content string
+}
+
+// treeFiles возвращает файлы дерева коммита по путям
+func treeFiles(tb testing.TB, commit *object.Commit) map[string]treeFile {
+	tb.Helper()
+	tree, err := commit.Tree()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	files := make(map[string]treeFile)
+	err = tree.Files().ForEach(func(f *object.File) error {
+		content, err := f.Contents()
+		if err != nil {
+			return err
+		}
+		files[f.Name] = treeFile{Mode: f.Mode.String(), Content: content}
+		return nil
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return files
+}
+
+// treeNames возвращает отсортированные пути файлов дерева коммита
+func treeNames(tb testing.TB, commit *object.Commit) []string {
+	tb.Helper()
+	var names []string
+	for name := range treeFiles(tb, commit) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// tagNames возвращает имена тегов репозитория dir
+func tagNames(tb testing.TB, dir string) []string {
+	tb.Helper()
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	iter, err := repo.Tags()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var names []string
+	iter.ForEach(func(ref *plumbing.Reference) error {
+		names = append(names, ref.Name().Short())
+		return nil
+	})
+	sort.Strings(names)
+	return names
+}
+
+// listTree возвращает пути всех файлов и ссылок внутри dir
+func listTree(tb testing.TB, dir string) []string {
+	tb.Helper()
+	var paths []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return paths
+}
@@ -0,0 +1,69 @@
+package gitconverter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// defaultHookTimeout ограничивает время команды HookCommand, если HookTimeout не задан
+const defaultHookTimeout = 5 * time.Minute
+
+// hookTimeout возвращает ограничение времени команды перед коммитом
+func hookTimeout(config Config) time.Duration {
+	if config.HookTimeout > 0 {
+		return config.HookTimeout
+	}
+	return defaultHookTimeout
+}
+
+// runHookCommand выполняет HookCommand через оболочку в рабочей директории
+// репозитория. Команда получает FTG_VERSION, FTG_FOLDER (путь к папке версии)
+// и FTG_INDEX (номер папки в списке, с 1). Вывод команды пишется в лог.
+//
+// В коммит попадают правки команды в файлах, которые добавляются в индекс:
+// всех файлах версии, а в инкрементальном режиме только измененных.
+// Новые файлы, созданные командой, в коммит не попадают
+func runHookCommand(ctx context.Context, config Config, folder FolderInfo, index int) error {
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout(config))
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", config.HookCommand)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", config.HookCommand)
+	}
+	cmd.Dir = config.TargetDir
+	cmd.Env = append(os.Environ(),
+		"FTG_VERSION="+folder.Version,
+		"FTG_FOLDER="+folder.Path,
+		"FTG_INDEX="+strconv.Itoa(index),
+	)
+
+	config.logger().Printf("Выполнение команды перед коммитом версии %s: %s", Printable(folder.Version), config.HookCommand)
+	output, err := cmd.CombinedOutput()
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		config.logger().Printf("  [команда] %s", scanner.Text())
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("команда не завершилась за %v", hookTimeout(config))
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("команда завершилась с кодом %d", exitErr.ExitCode())
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка запуска команды: %v", err)
+	}
+	return nil
+}
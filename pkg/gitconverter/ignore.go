@@ -0,0 +1,79 @@
+package gitconverter
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// DefaultIgnoreDirs содержит имена директорий, которые по умолчанию не
+// копируются в репозиторий. Чтобы дополнить список, передайте в
+// Config.IgnoreDirs его копию с новыми именами
+var DefaultIgnoreDirs = []string{".git", "__pycache__", "venv", ".venv", "node_modules", ".idea", ".vscode", "dist", "build", "env"}
+
+// DefaultIgnoreFiles содержит шаблоны имен файлов, которые по умолчанию не
+// копируются в репозиторий
+var DefaultIgnoreFiles = []string{".DS_Store", "*.pyc", "*.pyo", "*.pyd", ".gitignore", ".gitattributes", "*.swp", "*.swo", "*.log", "*.bak"}
+
+// ignoreRules — действующие списки пропускаемых директорий и файлов
+type ignoreRules struct {
+	dirs  []string
+	files []string
+}
+
+// defaultIgnoreRules возвращает списки по умолчанию
+func defaultIgnoreRules() ignoreRules {
+	return ignoreRules{dirs: DefaultIgnoreDirs, files: DefaultIgnoreFiles}
+}
+
+// newIgnoreRules возвращает списки из настроек. Если оба списка пусты,
+// действуют списки по умолчанию, иначе — только заданные. С KeepGitignore
+// из списка файлов убирается .gitignore
+func newIgnoreRules(config Config) (ignoreRules, error) {
+	rules := defaultIgnoreRules()
+	if len(config.IgnoreDirs) != 0 || len(config.IgnoreFiles) != 0 {
+		for _, pattern := range config.IgnoreFiles {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return ignoreRules{}, fmt.Errorf("недопустимый шаблон пропускаемых файлов %q: %v", pattern, err)
+			}
+		}
+		rules = ignoreRules{dirs: config.IgnoreDirs, files: config.IgnoreFiles}
+	}
+	if config.KeepGitignore {
+		var files []string
+		for _, pattern := range rules.files {
+			if pattern != gitignoreFile {
+				files = append(files, pattern)
+			}
+		}
+		rules.files = files
+	}
+	return rules, nil
+}
+
+// dir проверяет, нужно ли пропустить директорию. Служебная папка .git
+// пропускается всегда, иначе в рабочей директории окажется чужой репозиторий
+func (r ignoreRules) dir(name string) bool {
+	if name == ".git" {
+		return true
+	}
+	for _, ignoreDir := range r.dirs {
+		if name == ignoreDir {
+			return true
+		}
+	}
+	return false
+}
+
+// file проверяет, нужно ли пропустить файл
+func (r ignoreRules) file(name string) (bool, error) {
+	for _, pattern := range r.files {
+		matched, err := filepath.Match(pattern, name)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
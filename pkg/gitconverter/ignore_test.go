@@ -0,0 +1,66 @@
+package gitconverter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIgnoreLists(t *testing.T) {
+	files := map[string]string{
+		"a.txt":             "a",
+		"cache.pyc":         "pyc",
+		"scratch.tmp":       "tmp",
+		"build/out.txt":     "out",
+		"node_modules/x.js": "x",
+		"sub/b.tmp":         "tmp",
+	}
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   []string
+	}{
+		{"по умолчанию", func(*Config) {}, []string{"a.txt", "scratch.tmp", "sub/b.tmp"}},
+		{"свои шаблоны файлов", func(c *Config) { c.IgnoreFiles = []string{"*.tmp"} },
+			[]string{"a.txt", "build/out.txt", "cache.pyc", "node_modules/x.js"}},
+		{"свои директории", func(c *Config) { c.IgnoreDirs = []string{"node_modules"} },
+			[]string{"a.txt", "build/out.txt", "cache.pyc", "scratch.tmp", "sub/b.tmp"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := t.TempDir()
+			config := testConfig(versionSource(t, files), dst)
+			tt.modify(&config)
+			mustRun(t, config)
+
+			if got := treeNames(t, history(t, dst)[0]); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("перенесены %v, ожидались %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// .gitignore первого коммита повторяет заданные списки, а не списки по умолчанию
+func TestWriteGitignoreUsesIgnoreLists(t *testing.T) {
+	src := versionSource(t, map[string]string{"a.txt": "a", "scratch.tmp": "tmp", "node_modules/x.js": "x"})
+	dst := t.TempDir()
+	config := testConfig(src, dst)
+	config.WriteGitignore = true
+	config.IgnoreFiles = []string{"*.tmp"}
+	config.IgnoreDirs = []string{"node_modules"}
+	mustRun(t, config)
+
+	files := treeFiles(t, history(t, dst)[0])
+	want := gitignoreHeader + "\nnode_modules/\n*.tmp\n"
+	if got := files[gitignoreFile].Content; got != want {
+		t.Errorf(".gitignore:\n%s\nожидалось:\n%s", got, want)
+	}
+	if _, ok := files["scratch.tmp"]; ok {
+		t.Error("пропускаемый файл перенесен")
+	}
+}
+
+func TestIgnoreListsRejectBadPattern(t *testing.T) {
+	if _, err := newIgnoreRules(Config{IgnoreFiles: []string{"[a-"}}); err == nil {
+		t.Error("недопустимый шаблон принят")
+	}
+}
@@ -0,0 +1,266 @@
+package gitconverter
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// changeSet содержит изменения рабочей директории относительно новой версии.
+// Пути указаны относительно корня репозитория
+type changeSet struct {
+	Added    []string
+	Modified []string
+	Deleted  []string
+	Total    int // Количество файлов в новой версии
+}
+
+// Empty проверяет, что версия ничего не меняет
+func (c changeSet) Empty() bool {
+	return len(c.Added) == 0 && len(c.Modified) == 0 && len(c.Deleted) == 0
+}
+
+// syncIncremental приводит рабочую директорию dst к содержимому src без полной
+// очистки: копирует новые и измененные файлы и удаляет исчезнувшие.
+// Имена не в UTF-8 перекодируются с помощью names, права скопированных
+// файлов записываются в modes. Файлы отбрасываемых типов пропускает filter,
+// файлы конвертера (managed) не сравниваются. Хеши берутся из кэша hashes.
+// Файл, уже замененный указателем Git LFS по правилам lfs, сравнивается с
+// источником по указателю
+func syncIncremental(ctx context.Context, src, dst string, names *nameDecoder, filter *contentFilter, modes fileModes, managed toolPaths, hashes *hashCache, lfs *lfsRules) (changeSet, error) {
+	var changes changeSet
+
+	rawFiles, err := listFiles(src, false, filter)
+	if err != nil {
+		return changes, err
+	}
+	dstFiles, err := listFiles(dst, true, filter)
+	if err != nil {
+		return changes, err
+	}
+	for rel := range dstFiles {
+		if managed.has(rel) {
+			delete(dstFiles, rel)
+		}
+	}
+	changes.Total = len(rawFiles)
+
+	// Имя файла в репозитории -> имя в источнике
+	srcFiles := make(map[string]string, len(rawFiles))
+	targets := make(map[string]struct{}, len(rawFiles))
+	for rel := range rawFiles {
+		target := names.repoPath(rel)
+		srcFiles[target] = rel
+		targets[target] = struct{}{}
+	}
+
+	for _, rel := range sortedKeys(targets) {
+		if err := ctx.Err(); err != nil {
+			return changes, err
+		}
+
+		srcPath := filepath.Join(src, srcFiles[rel])
+		dstPath := filepath.Join(dst, rel)
+
+		if _, ok := dstFiles[rel]; ok {
+			same, err := hashes.sameContent(srcPath, dstPath)
+			if err != nil {
+				return changes, err
+			}
+			if !same && lfs != nil {
+				if same, err = lfsPointerMatches(dstPath, srcPath); err != nil {
+					return changes, err
+				}
+			}
+			if same {
+				continue
+			}
+			changes.Modified = append(changes.Modified, rel)
+		} else {
+			changes.Added = append(changes.Added, rel)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+			return changes, err
+		}
+		if err := copyFile(ctx, srcPath, dstPath); err != nil {
+			return changes, err
+		}
+		if info, err := os.Lstat(srcPath); err == nil {
+			modes.record(rel, srcPath, info)
+		}
+	}
+
+	for _, rel := range sortedKeys(dstFiles) {
+		if _, ok := srcFiles[rel]; !ok {
+			changes.Deleted = append(changes.Deleted, rel)
+		}
+	}
+
+	return changes, nil
+}
+
+// stageDeletions удаляет исчезнувшие файлы из индекса и рабочей директории
+func stageDeletions(stager *indexStager, root string, deleted []string, logger Logger) {
+	for _, rel := range deleted {
+		// Неотслеживаемый Git файл достаточно удалить с диска
+		stager.remove(rel)
+		if err := os.Remove(filepath.Join(root, rel)); err != nil && !os.IsNotExist(err) {
+			logger.Printf("Предупреждение: не удалось удалить файл %s: %v", rel, err)
+			continue
+		}
+		removeEmptyParents(root, filepath.Dir(filepath.Join(root, rel)))
+	}
+}
+
+// listFiles возвращает относительные пути файлов с учетом правил игнорирования.
+// Для рабочей директории (worktree) пропускается служебная папка .git.
+// Списки игнорирования берутся из filter, он же в источнике пропускает
+// файлы отбрасываемых типов и исключенные .gitignore папки
+func listFiles(root string, worktree bool, filter *contentFilter) (map[string]struct{}, error) {
+	files := make(map[string]struct{})
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		// В связанном рабочем каталоге .git — файл, его тоже не трогаем
+		if worktree && d.Name() == ".git" {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			if filter.ignore().dir(d.Name()) {
+				return filepath.SkipDir
+			}
+			if !worktree {
+				ignored, err := filter.gitignored(root, rel, true)
+				if err != nil {
+					return err
+				}
+				if ignored {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+
+		// Символические ссылки в рабочей директории не трогаем, как и clearDirectory
+		if worktree && d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+
+		ignored, err := filter.ignore().file(d.Name())
+		if err != nil {
+			return err
+		}
+		if ignored {
+			return nil
+		}
+		if filter != nil && !worktree {
+			ignored, err := filter.gitignored(root, rel, false)
+			if err != nil {
+				return err
+			}
+			if ignored {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			skipped, err := filter.skipped(path, info.Size())
+			if err != nil {
+				return err
+			}
+			if skipped {
+				return nil
+			}
+		}
+
+		files[rel] = struct{}{}
+		return nil
+	})
+
+	return files, err
+}
+
+// sameContent сравнивает права и содержимое двух файлов
+func sameContent(a, b string) (bool, error) {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	if infoA.Size() != infoB.Size() || infoA.Mode().Perm() != infoB.Mode().Perm() {
+		return false, nil
+	}
+
+	fa, err := os.Open(a)
+	if err != nil {
+		return false, err
+	}
+	defer fa.Close()
+	fb, err := os.Open(b)
+	if err != nil {
+		return false, err
+	}
+	defer fb.Close()
+
+	bufA := make([]byte, 64*1024)
+	bufB := make([]byte, 64*1024)
+	for {
+		na, errA := io.ReadFull(fa, bufA)
+		nb, errB := io.ReadFull(fb, bufB)
+		if na != nb || !bytes.Equal(bufA[:na], bufB[:nb]) {
+			return false, nil
+		}
+		if errA == io.EOF || errA == io.ErrUnexpectedEOF {
+			return errB == io.EOF || errB == io.ErrUnexpectedEOF, nil
+		}
+		if errA != nil {
+			return false, errA
+		}
+		if errB != nil {
+			return false, errB
+		}
+	}
+}
+
+// removeEmptyParents удаляет опустевшие директории от dir вверх до root
+func removeEmptyParents(root, dir string) {
+	for dir != root && len(dir) > len(root) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// sortedKeys возвращает ключи множества в отсортированном порядке
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,105 @@
+package gitconverter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// organizeFolders раскладывает версии из источника src в пронумерованные
+// подпапки TargetDir в отсортированном порядке, не выполняя никаких операций Git
+func organizeFolders(ctx context.Context, src sourceFS, config Config, folders []FolderInfo) (*MigrationResult, error) {
+	result := &MigrationResult{}
+
+	names, err := newNameDecoder(config.SourceEncoding, config.logger())
+	if err != nil {
+		return result, err
+	}
+	filter, err := newContentFilter(config)
+	if err != nil {
+		return result, err
+	}
+
+	for i, folder := range folders {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if config.OnProgress != nil {
+			config.OnProgress(i+1, len(folders), folder)
+		}
+		name := layoutFolderName(i, CanonicalVersion(config, folder.Version))
+		target := filepath.Join(config.TargetDir, name)
+
+		entry := LayoutEntry{
+			Version: folder.Version,
+			Source:  folder.Path,
+			Target:  target,
+		}
+
+		if config.DryRun {
+			config.logger().Printf("Тестовый режим: %s -> %s", Printable(filepath.Base(folder.Path)), name)
+			result.Layout = append(result.Layout, entry)
+			continue
+		}
+
+		// Не смешиваем содержимое с уже существующей папкой
+		if _, err := os.Stat(target); err == nil {
+			return result, fmt.Errorf("папка %s уже существует", target)
+		}
+
+		if err := os.MkdirAll(target, 0755); err != nil {
+			return result, fmt.Errorf("ошибка создания директории: %v", err)
+		}
+
+		fileCount, err := layoutFolder(ctx, src, config, folder, target, names, filter)
+		if err != nil {
+			return result, err
+		}
+
+		entry.FileCount = fileCount
+		result.Layout = append(result.Layout, entry)
+		config.logger().Printf("Версия %s разложена в %s (%d файлов)", Printable(folder.Version), name, fileCount)
+		logAppleDoubleSkips(folder, filter, config.logger())
+	}
+
+	config.logger().Printf("Разложено %d папок в %s", len(result.Layout), config.TargetDir)
+	return result, nil
+}
+
+// layoutFolder копирует файлы версии в target и возвращает их число. Версия
+// с удаленного источника загружается на время копирования, а папки версии,
+// объединенной MergeSameVersion, сначала собираются вместе, как для коммита
+func layoutFolder(ctx context.Context, src sourceFS, config Config, folder FolderInfo, target string, names *nameDecoder, filter *contentFilter) (int, error) {
+	local, cleanup, err := fetchFolder(ctx, src, config, folder)
+	if err != nil {
+		return 0, err
+	}
+	defer cleanup()
+
+	source := local.Path
+	if len(local.MergedPaths) > 1 {
+		dir, conflicts, err := stageMergedVersion(ctx, config, local)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка объединения папок версии: %v", err)
+		}
+		defer os.RemoveAll(filepath.Dir(dir))
+		if len(conflicts) > 0 {
+			config.logger().Printf("Предупреждение: в нескольких папках версии %s есть одни и те же файлы (%d), взяты из более поздних: %s",
+				Printable(folder.Version), len(conflicts), Printable(strings.Join(firstN(conflicts, verifyExamples), ", ")))
+		}
+		source = dir
+	}
+
+	fileCount, _, err := copyFilesAndTrack(ctx, source, target, false, false, names, filter, nil, config.logger())
+	if err != nil {
+		return 0, fmt.Errorf("ошибка копирования файлов: %v", err)
+	}
+	return fileCount, nil
+}
+
+// layoutFolderName возвращает имя подпапки для версии с порядковым номером
+func layoutFolderName(index int, version string) string {
+	return fmt.Sprintf("%03d_%s", index+1, version)
+}
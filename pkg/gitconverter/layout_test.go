@@ -0,0 +1,57 @@
+package gitconverter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLayoutMergesSameVersion(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"v1a/a.txt": "a",
+		"v1b/b.txt": "b",
+		"v2/a.txt":  "a2",
+	})
+	setTreeTime(t, filepath.Join(src, "v1a"), testEpoch)
+	setTreeTime(t, filepath.Join(src, "v1b"), testEpoch.AddDate(0, 0, 1))
+	setTreeTime(t, filepath.Join(src, "v2"), testEpoch.AddDate(0, 0, 2))
+
+	dst := t.TempDir()
+	config := testConfig(src, dst)
+	config.NoGit = true
+	config.MergeSameVersion = true
+	result := mustRun(t, config)
+
+	if len(result.Layout) != 2 {
+		t.Fatalf("разложено папок %d, ожидалось 2: %+v", len(result.Layout), result.Layout)
+	}
+	if got := listTree(t, result.Layout[0].Target); !reflect.DeepEqual(got, []string{"a.txt", "b.txt"}) {
+		t.Errorf("в папке версии 1 файлы %v, ожидались обе части", got)
+	}
+}
+
+func TestLayoutGroupsByPeriod(t *testing.T) {
+	src := versionSource(t,
+		map[string]string{"a.txt": "1"},
+		map[string]string{"a.txt": "2"},
+		map[string]string{"a.txt": "3"},
+	)
+	dst := t.TempDir()
+	config := testConfig(src, dst)
+	config.NoGit = true
+	config.GroupBy = GroupMonth
+	result := mustRun(t, config)
+
+	if len(result.Layout) != 1 || result.Layout[0].Version != "3" {
+		t.Fatalf("разложены %+v, ожидалась только версия 3", result.Layout)
+	}
+	if !reflect.DeepEqual(result.Collapsed, []string{"1", "2"}) {
+		t.Errorf("свернуты версии %v, ожидались 1 и 2", result.Collapsed)
+	}
+	data, err := os.ReadFile(filepath.Join(result.Layout[0].Target, "a.txt"))
+	if err != nil || string(data) != "3" {
+		t.Errorf("в папке версии содержимое %q (%v), ожидалось последнего снимка", data, err)
+	}
+}
@@ -0,0 +1,337 @@
+package gitconverter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+const (
+	// lfsSpec — первая строка файла-указателя Git LFS
+	lfsSpec = "version https://git-lfs.github.com/spec/v1"
+	// lfsPointerMaxSize — указатель не бывает больше, файлы крупнее не разбираются
+	lfsPointerMaxSize = 1024
+	// attributesFile — имя файла атрибутов в корне репозитория
+	attributesFile = ".gitattributes"
+	// lfsAttributes — атрибуты файлов, хранящихся в LFS
+	lfsAttributes = "filter=lfs diff=lfs merge=lfs -text"
+	// lfsHeader открывает раздел .gitattributes, добавленный конвертером
+	lfsHeader = "# Добавлено folder-to-git: файлы, хранящиеся в Git LFS"
+)
+
+// lfsRules — правила отбора файлов для Git LFS. Нулевой указатель означает,
+// что LFS не используется
+type lfsRules struct {
+	patterns  []string
+	threshold int64
+}
+
+// newLFSRules возвращает правила из настроек или nil, если LFS не включен
+func newLFSRules(config Config) (*lfsRules, error) {
+	if len(config.LFSPatterns) == 0 && config.LFSThresholdBytes <= 0 {
+		return nil, nil
+	}
+	for _, pattern := range config.LFSPatterns {
+		if _, err := path.Match(strings.TrimPrefix(pattern, "/"), ""); err != nil {
+			return nil, fmt.Errorf("недопустимый шаблон LFS %q: %v", pattern, err)
+		}
+	}
+	return &lfsRules{patterns: config.LFSPatterns, threshold: config.LFSThresholdBytes}, nil
+}
+
+// matchesPattern проверяет, что путь rel в репозитории подходит под шаблон.
+// Как в .gitattributes, шаблон без "/" сравнивается с именем файла на любой
+// глубине, а шаблон с "/" — с путем от корня
+func (r *lfsRules) matchesPattern(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range r.patterns {
+		target := path.Base(rel)
+		if strings.Contains(pattern, "/") {
+			pattern, target = strings.TrimPrefix(pattern, "/"), rel
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// tracks проверяет, что файл rel размером size хранится в LFS
+func (r *lfsRules) tracks(rel string, size int64) bool {
+	if r == nil {
+		return false
+	}
+	return r.matchesPattern(rel) || (r.threshold > 0 && size > r.threshold)
+}
+
+// lfsPointer — содержимое файла-указателя
+type lfsPointer struct {
+	oid  string
+	size int64
+}
+
+// String возвращает текст указателя в формате спецификации LFS
+func (p lfsPointer) String() string {
+	return fmt.Sprintf("%s\noid sha256:%s\nsize %d\n", lfsSpec, p.oid, p.size)
+}
+
+// readLFSPointer читает указатель из файла path. Второе значение ложно, если
+// файл не является указателем
+func readLFSPointer(path string) (lfsPointer, bool) {
+	info, err := os.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > lfsPointerMaxSize {
+		return lfsPointer{}, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return lfsPointer{}, false
+	}
+	return parseLFSPointer(data)
+}
+
+// parseLFSPointer разбирает текст указателя
+func parseLFSPointer(data []byte) (lfsPointer, bool) {
+	var p lfsPointer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	if !scanner.Scan() || scanner.Text() != lfsSpec {
+		return p, false
+	}
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), " ")
+		switch key {
+		case "oid":
+			p.oid = strings.TrimPrefix(value, "sha256:")
+		case "size":
+			p.size, _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+	return p, len(p.oid) == sha256.Size*2
+}
+
+// lfsPointerOf вычисляет указатель для содержимого файла path
+func lfsPointerOf(path string) (lfsPointer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return lfsPointer{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return lfsPointer{}, err
+	}
+	return lfsPointer{oid: hex.EncodeToString(h.Sum(nil)), size: size}, nil
+}
+
+// lfsPointerHash возвращает хеш объекта blob указателя для файла path: так
+// файл, хранящийся в LFS, выглядит в дереве коммита
+func lfsPointerHash(path string) (plumbing.Hash, error) {
+	pointer, err := lfsPointerOf(path)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	content := pointer.String()
+	hasher := plumbing.NewHasher(plumbing.BlobObject, int64(len(content)))
+	io.WriteString(hasher, content)
+	return hasher.Sum(), nil
+}
+
+// lfsPointerMatches проверяет, что pointerPath — указатель на содержимое
+// файла src с теми же правами
+func lfsPointerMatches(pointerPath, src string) (bool, error) {
+	pointer, ok := readLFSPointer(pointerPath)
+	if !ok {
+		return false, nil
+	}
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return false, err
+	}
+	pointerInfo, err := os.Stat(pointerPath)
+	if err != nil {
+		return false, err
+	}
+	if srcInfo.Size() != pointer.size || srcInfo.Mode().Perm() != pointerInfo.Mode().Perm() {
+		return false, nil
+	}
+	actual, err := lfsPointerOf(src)
+	if err != nil {
+		return false, err
+	}
+	return actual.oid == pointer.oid, nil
+}
+
+// lfsObjectPath возвращает путь объекта oid в хранилище LFS репозитория
+func lfsObjectPath(gitDir, oid string) string {
+	return filepath.Join(gitDir, "lfs", "objects", oid[:2], oid[2:4], oid)
+}
+
+// storeLFS переносит содержимое файла path в хранилище LFS репозитория
+// gitDir и оставляет на его месте указатель с теми же правами. Файл,
+// который уже является указателем, не меняется
+func storeLFS(gitDir, path string) error {
+	if _, ok := readLFSPointer(path); ok {
+		return nil
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	pointer, err := lfsPointerOf(path)
+	if err != nil {
+		return err
+	}
+
+	object := lfsObjectPath(gitDir, pointer.oid)
+	if _, err := os.Stat(object); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(object), 0755); err != nil {
+			return err
+		}
+		// Рабочая директория и .git обычно на одном диске, иначе копируем
+		if err := os.Rename(path, object); err != nil {
+			if err := copyFile(context.Background(), path, object); err != nil {
+				return err
+			}
+		}
+	} else if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(pointer.String()), info.Mode().Perm())
+}
+
+// convertToLFS заменяет указателями файлы paths (пути относительно
+// config.TargetDir), которые по правилам r хранятся в LFS, и обновляет
+// .gitattributes. Возвращает пути перенесенных в LFS файлов
+func convertToLFS(config Config, r *lfsRules, paths []string) ([]string, error) {
+	if r == nil {
+		return nil, nil
+	}
+	gitDir := gitDirPath(config.TargetDir)
+
+	var stored, explicit []string
+	for _, rel := range paths {
+		full := filepath.Join(config.TargetDir, rel)
+		info, err := os.Lstat(full)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if !r.tracks(rel, info.Size()) {
+			continue
+		}
+		if err := storeLFS(gitDir, full); err != nil {
+			return stored, fmt.Errorf("%s: %v", rel, err)
+		}
+		stored = append(stored, filepath.ToSlash(rel))
+		if !r.matchesPattern(rel) {
+			// Порог размера не выражается в .gitattributes, файл указывается по пути
+			explicit = append(explicit, "/"+escapeAttributesPath(filepath.ToSlash(rel)))
+		}
+	}
+	return stored, writeLFSAttributes(config.TargetDir, r.patterns, explicit)
+}
+
+// escapeAttributesPath экранирует в пути символы шаблонов и пробелы, как
+// это делает git lfs track
+func escapeAttributesPath(p string) string {
+	var b strings.Builder
+	for _, c := range p {
+		switch c {
+		case '*', '?', '[', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(c)
+		case ' ':
+			b.WriteString("[[:space:]]")
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// writeLFSAttributes дописывает в .gitattributes рабочей директории правила
+// LFS для шаблонов patterns и путей explicit. Имеющиеся строки сохраняются,
+// поэтому правила файлов прошлых версий остаются в силе
+func writeLFSAttributes(root string, patterns, explicit []string) error {
+	file := filepath.Join(root, attributesFile)
+	existing, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	present := make(map[string]bool)
+	hasHeader := false
+	scanner := bufio.NewScanner(bytes.NewReader(existing))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		present[line] = true
+		hasHeader = hasHeader || line == lfsHeader
+	}
+
+	var missing []string
+	for _, pattern := range append(append([]string(nil), patterns...), explicit...) {
+		rule := pattern + " " + lfsAttributes
+		if !present[rule] {
+			missing = append(missing, rule)
+			present[rule] = true
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	var b bytes.Buffer
+	b.Write(existing)
+	if len(existing) > 0 && !bytes.HasSuffix(existing, []byte("\n")) {
+		b.WriteByte('\n')
+	}
+	if !hasHeader {
+		if len(existing) > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(lfsHeader + "\n")
+	}
+	for _, rule := range missing {
+		b.WriteString(rule + "\n")
+	}
+	return os.WriteFile(file, b.Bytes(), 0644)
+}
+
+// copyLFSObjects копирует объекты LFS из репозитория srcGitDir в dstGitDir,
+// пропуская уже имеющиеся
+func copyLFSObjects(srcGitDir, dstGitDir string) error {
+	root := filepath.Join(srcGitDir, "lfs", "objects")
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		// Незавершенные загрузки и прочие файлы не похожи на sha256
+		if len(d.Name()) != sha256.Size*2 {
+			return nil
+		}
+		dst := lfsObjectPath(dstGitDir, d.Name())
+		if _, err := os.Stat(dst); err == nil {
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		return copyFile(context.Background(), p, dst)
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,18 @@
+package gitconverter
+
+import "log"
+
+// Logger принимает сообщения о ходе сканирования и миграции. Интерфейсу
+// соответствует *log.Logger, так что вывод можно перенаправить в файл или
+// окно программы, не разбирая stderr
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// logger возвращает Logger из настроек или стандартный лог, если он не задан
+func (c Config) logger() Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return log.Default()
+}
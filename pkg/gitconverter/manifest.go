@@ -0,0 +1,72 @@
+package gitconverter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// manifestDir — каталог конвертера внутри служебной папки Git
+const manifestDir = "folder-to-git"
+
+// migrationManifest хранит сведения о последнем запуске миграции рядом с
+// репозиторием, но вне его истории
+type migrationManifest struct {
+	SchemaVersion int                 `json:"schemaVersion"`
+	MigratedAt    time.Time           `json:"migratedAt"`
+	SourceDir     string              `json:"sourceDir"`
+	Filesystem    *FilesystemProbe    `json:"filesystem,omitempty"`
+	Webhook       string              `json:"webhook,omitempty"`   // Адрес уведомлений без секретов
+	Excluded      []ExcludedFolder    `json:"excluded,omitempty"`  // Папки, исключенные пользователем
+	Settings      *RunSettings        `json:"settings,omitempty"`  // Настройки запуска для следующего добавления версий
+	Order         []OrderDecision     `json:"order,omitempty"`     // Порядок папок последнего запуска и его причины
+	Collapsed     []CollapsedSnapshot `json:"collapsed,omitempty"` // Снимки, свернутые GroupBy, всех запусков
+}
+
+// LoadOrderAudit читает из манифеста репозитория targetDir объяснение порядка
+// папок последнего запуска. Если манифеста нет, возвращается nil без ошибки
+func LoadOrderAudit(targetDir string) ([]OrderDecision, error) {
+	manifest, err := readManifest(targetDir)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения манифеста миграции: %v", err)
+	}
+	return manifest.Order, nil
+}
+
+// manifestPath возвращает путь манифеста репозитория в targetDir
+func manifestPath(targetDir string) string {
+	return filepath.Join(gitDirPath(targetDir), manifestDir, "manifest.json")
+}
+
+// readManifest читает манифест прошлого запуска. Если манифеста нет,
+// возвращается пустой манифест без ошибки
+func readManifest(targetDir string) (migrationManifest, error) {
+	var manifest migrationManifest
+	data, err := os.ReadFile(manifestPath(targetDir))
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return manifest, err
+	}
+	err = json.Unmarshal(data, &manifest)
+	return manifest, err
+}
+
+// writeManifest записывает манифест в <git-dir>/folder-to-git/manifest.json
+func writeManifest(targetDir string, manifest migrationManifest) error {
+	manifest.SchemaVersion = SchemaVersion
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := manifestPath(targetDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,128 @@
+package gitconverter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// mergeSameVersion объединяет папки с одинаковой версией в одну запись на
+// месте первой из них в списке, путем записи становится самая ранняя папка. Папки из exclude не объединяются и остаются как есть.
+// В MergedPaths папки идут по времени создания, более поздние при совпадении
+// путей перекрывают более ранние
+func mergeSameVersion(folders []FolderInfo, exclude map[string]bool, logger Logger) []FolderInfo {
+	groups := make(map[string][]FolderInfo)
+	for _, folder := range folders {
+		if !exclude[folder.Path] {
+			groups[folder.Version] = append(groups[folder.Version], folder)
+		}
+	}
+
+	merged := make([]FolderInfo, 0, len(folders))
+	for _, folder := range folders {
+		group := groups[folder.Version]
+		if exclude[folder.Path] || len(group) < 2 {
+			merged = append(merged, folder)
+			continue
+		}
+		if group[0].Path != folder.Path {
+			// Версия уже добавлена вместе с первой папкой
+			continue
+		}
+
+		sort.SliceStable(group, func(i, j int) bool {
+			return group[i].CreationTime < group[j].CreationTime
+		})
+		combined := group[0]
+		combined.MergedPaths = nil
+		combined.Size, combined.FileCount = 0, 0
+		names := make([]string, 0, len(group))
+		for _, part := range group {
+			combined.MergedPaths = append(combined.MergedPaths, part.Path)
+			combined.Size += part.Size
+			combined.FileCount += part.FileCount
+			combined.TimeFallback = combined.TimeFallback || part.TimeFallback
+			names = append(names, filepath.Base(part.Path))
+		}
+		merged = append(merged, combined)
+		logger.Printf("Папки версии %s объединяются в один коммит: %s", combined.Version, strings.Join(names, ", "))
+	}
+	return merged
+}
+
+// mergedSources возвращает файлы объединенной версии: относительный путь ->
+// полный путь в папке, из которой файл берется, и пути, которые есть в
+// нескольких папках
+func mergedSources(folder FolderInfo, filter *contentFilter) (map[string]string, []string, error) {
+	sources := make(map[string]string)
+	var conflicts []string
+	for _, part := range folder.MergedPaths {
+		files, err := listFiles(part, false, filter)
+		if err != nil {
+			return nil, nil, err
+		}
+		for rel := range files {
+			if _, ok := sources[rel]; ok {
+				conflicts = append(conflicts, rel)
+			}
+			sources[rel] = filepath.Join(part, rel)
+		}
+	}
+	sort.Strings(conflicts)
+	return sources, conflicts, nil
+}
+
+// stageMergedVersion собирает папки объединенной версии во временной
+// папке с именем первой из них. Файлы по возможности связываются жесткими
+// ссылками, а не копируются. Возвращает путь собранной папки и совпавшие пути
+func stageMergedVersion(ctx context.Context, config Config, folder FolderInfo) (string, []string, error) {
+	sources, conflicts, err := mergedSources(folder, nil)
+	if err != nil {
+		return "", nil, err
+	}
+
+	workspace, err := makeWorkspace(config)
+	if err != nil {
+		return "", nil, fmt.Errorf("ошибка создания временной папки: %v", err)
+	}
+	dir := filepath.Join(workspace, filepath.Base(folder.Path))
+
+	for rel, src := range sources {
+		if err := ctx.Err(); err != nil {
+			os.RemoveAll(workspace)
+			return "", nil, err
+		}
+		dst := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			os.RemoveAll(workspace)
+			return "", nil, err
+		}
+		if err := os.Link(src, dst); err == nil {
+			continue
+		}
+		if err := copyFile(ctx, src, dst); err != nil {
+			os.RemoveAll(workspace)
+			return "", nil, fmt.Errorf("ошибка копирования %s: %v", src, err)
+		}
+		// Время изменения нужно кэшу хешей и сравнению версий
+		if info, err := os.Stat(src); err == nil {
+			os.Chtimes(dst, info.ModTime(), info.ModTime())
+		}
+	}
+	return dir, conflicts, nil
+}
+
+// mergedMessage дописывает к сообщению коммита список объединенных папок
+func mergedMessage(msg string, folder FolderInfo) string {
+	if len(folder.MergedPaths) < 2 {
+		return msg
+	}
+	names := make([]string, len(folder.MergedPaths))
+	for i, path := range folder.MergedPaths {
+		names[i] = Printable(filepath.Base(path))
+	}
+	return strings.TrimRight(msg, "\n") + "\n\nMerged from: " + strings.Join(names, ", ")
+}
@@ -0,0 +1,60 @@
+package gitconverter
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultMetadataFile — файл метаданных версии в корне репозитория
+const defaultMetadataFile = ".folder-to-git.yaml"
+
+// versionMetadata описывает версию в файле метаданных
+type versionMetadata struct {
+	Version  string `yaml:"version"`
+	Folder   string `yaml:"folder"`
+	Created  string `yaml:"created"`
+	Imported string `yaml:"imported"`
+	Files    int    `yaml:"files"`
+	Added    int    `yaml:"added,omitempty"`
+	Modified int    `yaml:"modified,omitempty"`
+	Deleted  int    `yaml:"deleted,omitempty"`
+}
+
+// metadataPath возвращает путь файла метаданных относительно корня репозитория
+func metadataPath(config Config) string {
+	if config.MetadataFilePath != "" {
+		return filepath.Clean(config.MetadataFilePath)
+	}
+	return defaultMetadataFile
+}
+
+// writeMetadataFile перезаписывает файл метаданных для очередной версии
+func writeMetadataFile(config Config, meta versionMetadata) error {
+	data, err := yaml.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(config.TargetDir, metadataPath(config))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// newVersionMetadata собирает метаданные версии
+func newVersionMetadata(folder FolderInfo, folderName string, fileCount int, changes changeSet) versionMetadata {
+	return versionMetadata{
+		Version:  folder.Version,
+		Folder:   folderName,
+		Created:  time.Unix(folder.CreationTime, 0).Format(time.RFC3339),
+		Imported: time.Now().Format(time.RFC3339),
+		Files:    fileCount,
+		Added:    len(changes.Added),
+		Modified: len(changes.Modified),
+		Deleted:  len(changes.Deleted),
+	}
+}
@@ -0,0 +1,60 @@
+package gitconverter
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+)
+
+// defaultSourceEncoding используется для имен не в UTF-8, если кодировка не
+// задана: старые архивы обычно создавались в Windows с кириллицей
+const defaultSourceEncoding = "windows-1251"
+
+// nameDecoder приводит к UTF-8 имена папок и файлов, записанные в другой
+// кодировке. Без кодировки (источник в UTF-8) некорректные последовательности
+// заменяются символом U+FFFD
+type nameDecoder struct {
+	enc    encoding.Encoding
+	logger Logger
+}
+
+// newNameDecoder создает декодер для кодировки имен источника
+func newNameDecoder(name string, logger Logger) (*nameDecoder, error) {
+	if name == "" {
+		name = defaultSourceEncoding
+	}
+	if isUTF8Encoding(name) {
+		return &nameDecoder{logger: logger}, nil
+	}
+
+	enc, err := lookupEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	return &nameDecoder{enc: enc, logger: logger}, nil
+}
+
+// decode возвращает строку в UTF-8 и признак того, что ее пришлось перекодировать
+func (d *nameDecoder) decode(s string) (string, bool) {
+	if utf8.ValidString(s) {
+		return s, false
+	}
+
+	if d != nil && d.enc != nil {
+		if out, err := d.enc.NewDecoder().String(s); err == nil && utf8.ValidString(out) {
+			return out, true
+		}
+	}
+	return strings.ToValidUTF8(s, "�"), true
+}
+
+// repoPath возвращает путь файла в репозитории в UTF-8 и предупреждает,
+// если имя пришлось перекодировать
+func (d *nameDecoder) repoPath(rel string) string {
+	out, changed := d.decode(rel)
+	if changed {
+		d.logger.Printf("Предупреждение: имя файла %q не в UTF-8, сохранено как %s", rel, out)
+	}
+	return out
+}
@@ -0,0 +1,177 @@
+package gitconverter
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+
+	"folder_to_git/internal/versionorder"
+)
+
+// OrderRule называет правило, которое определило место папки в истории
+type OrderRule string
+
+const (
+	OrderByTime        OrderRule = "time"           // По времени создания
+	OrderTimeTie       OrderRule = "time-tie"       // Время совпало с соседней папкой, сохранен порядок поиска (по имени)
+	OrderByVersion     OrderRule = "version"        // По номеру версии (SortMode semver или natural)
+	OrderVersionTie    OrderRule = "version-tie"    // Версия совпала с соседней папкой, порядок по времени создания
+	OrderNotSemver     OrderRule = "not-semver"     // Версия не разобрана как SemVer, папка в конце по времени
+	OrderInversion     OrderRule = "inversion"      // Дата противоречит номеру версии, оставлен порядок дат
+	OrderPreferVersion OrderRule = "prefer-version" // Дата противоречит номеру версии, папка переставлена по номеру (PreferVersionOrder)
+	OrderManual        OrderRule = "manual"         // Порядок изменен после сканирования, например вручную в интерфейсе
+)
+
+// OrderDecision объясняет место папки в истории: исходные данные сортировки
+// и правило, которое его определило
+type OrderDecision struct {
+	Index        int               `json:"index"` // Место в истории, с 1
+	Version      string            `json:"version"`
+	Path         string            `json:"path"`
+	CreationTime time.Time         `json:"creationTime"`
+	TimeStrategy TimestampStrategy `json:"timeStrategy"`
+	TimeFallback bool              `json:"timeFallback,omitempty"` // Стратегия не сработала, время определено запасным способом
+	SortMode     SortMode          `json:"sortMode"`
+	Rule         OrderRule         `json:"rule"`
+}
+
+// explainOrder описывает уже упорядоченные папки, правило для i-й папки
+// сообщает rule
+func explainOrder(config Config, folders []FolderInfo, rule func(i int) OrderRule) []OrderDecision {
+	strategy := config.TimestampStrategy
+	if strategy == "" {
+		strategy = TimestampFileMtime
+	}
+	mode := config.SortMode
+	if mode == "" {
+		mode = SortByTime
+	}
+
+	decisions := make([]OrderDecision, len(folders))
+	for i, folder := range folders {
+		folderStrategy := strategy
+		if folder.TimeFromFile {
+			folderStrategy = TimestampTimeFile
+		}
+		decisions[i] = OrderDecision{
+			Index:        i + 1,
+			Version:      folder.Version,
+			Path:         folder.Path,
+			CreationTime: time.Unix(folder.CreationTime, 0).UTC(),
+			TimeStrategy: folderStrategy,
+			TimeFallback: folder.TimeFallback,
+			SortMode:     mode,
+			Rule:         rule(i),
+		}
+	}
+	return decisions
+}
+
+// sameNeighbour проверяет, что соседняя с i-й папка равна ей по условию same,
+// то есть их взаимный порядок решило правило следующего уровня
+func sameNeighbour(folders []FolderInfo, i int, same func(other FolderInfo) bool) bool {
+	return (i > 0 && same(folders[i-1])) || (i+1 < len(folders) && same(folders[i+1]))
+}
+
+// auditOrder объясняет порядок папок folders, переданных на миграцию. Порядок
+// сравнивается с тем, что дала бы сортировка; расхождения отмечаются как
+// OrderManual
+func auditOrder(config Config, folders []FolderInfo) []OrderDecision {
+	// Предупреждения сортировки уже выведены при сканировании
+	config.Verbose = false
+	sorted := append([]FolderInfo(nil), folders...)
+	_, decisions := sortFolders(config, sorted)
+	byPath := make(map[string]OrderDecision, len(decisions))
+	for _, d := range decisions {
+		byPath[d.Path] = d
+	}
+
+	audit := make([]OrderDecision, len(folders))
+	for i, folder := range folders {
+		d := byPath[folder.Path]
+		if d.Index != i+1 {
+			d.Rule = OrderManual
+		}
+		d.Index = i + 1
+		d.Version = folder.Version
+		audit[i] = d
+	}
+	return audit
+}
+
+// VersionInversion описывает пару папок, порядок которых по датам
+// противоречит порядку номеров версий
+type VersionInversion struct {
+	Earlier     string // Версия, которая по датам идет раньше, хотя ее номер больше
+	Later       string // Версия, которая по датам идет позже
+	EarlierPath string
+	LaterPath   string
+}
+
+// findInversions возвращает пары папок, у которых порядок по времени
+// не совпадает с порядком номеров версий
+func findInversions(folders []FolderInfo) []VersionInversion {
+	var inversions []VersionInversion
+	for i := range folders {
+		for j := i + 1; j < len(folders); j++ {
+			if versionorder.Natural(folders[i].Version, folders[j].Version) > 0 {
+				inversions = append(inversions, VersionInversion{
+					Earlier:     folders[i].Version,
+					Later:       folders[j].Version,
+					EarlierPath: folders[i].Path,
+					LaterPath:   folders[j].Path,
+				})
+			}
+		}
+	}
+	return inversions
+}
+
+// preferVersionOrder переставляет по номерам версий только папки из
+// противоречивых пар. Остальные папки остаются на своих местах
+func preferVersionOrder(folders []FolderInfo, inversions []VersionInversion) {
+	involved := make(map[string]bool)
+	for _, inv := range inversions {
+		involved[inv.EarlierPath] = true
+		involved[inv.LaterPath] = true
+	}
+
+	var positions []int
+	var moved []FolderInfo
+	for i, folder := range folders {
+		if involved[folder.Path] {
+			positions = append(positions, i)
+			moved = append(moved, folder)
+		}
+	}
+	sort.SliceStable(moved, func(i, j int) bool {
+		return versionorder.Natural(moved[i].Version, moved[j].Version) < 0
+	})
+	for k, i := range positions {
+		folders[i] = moved[k]
+	}
+}
+
+// maxLoggedInversions ограничивает число пар, выводимых в лог
+const maxLoggedInversions = 20
+
+// logInversions предупреждает о расхождении порядка дат и номеров версий
+func logInversions(inversions []VersionInversion, resolved bool, logger Logger) {
+	if len(inversions) == 0 {
+		return
+	}
+	logger.Printf("ВНИМАНИЕ: порядок папок по датам противоречит номерам версий (%d пар):", len(inversions))
+	for i, inv := range inversions {
+		if i == maxLoggedInversions {
+			logger.Printf("  ... и еще %d", len(inversions)-maxLoggedInversions)
+			break
+		}
+		logger.Printf("  %s (%s) раньше %s (%s)",
+			inv.Earlier, filepath.Base(inv.EarlierPath), inv.Later, filepath.Base(inv.LaterPath))
+	}
+	if resolved {
+		logger.Printf("Эти папки упорядочены по номерам версий (PreferVersionOrder)")
+	} else {
+		logger.Printf("Проверьте даты папок или включите PreferVersionOrder, иначе история будет вводить в заблуждение")
+	}
+}
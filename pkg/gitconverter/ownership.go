@@ -0,0 +1,100 @@
+package gitconverter
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Ключ в конфигурации репозитория, которым конвертер помечает свои репозитории
+const (
+	repoMarkerSection = "folder-to-git"
+	repoMarkerOption  = "managed"
+)
+
+// ownershipHistoryDepth — сколько последних коммитов просматривается в поисках
+// сообщений конвертера
+const ownershipHistoryDepth = 100
+
+// errStopWalk прерывает обход истории после первой найденной метки
+var errStopWalk = errors.New("stop")
+
+// markRepository записывает в конфигурацию репозитория метку конвертера
+func markRepository(repo *git.Repository) error {
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	section := cfg.Raw.Section(repoMarkerSection)
+	if section.Option(repoMarkerOption) == "true" {
+		return nil
+	}
+	section.SetOption(repoMarkerOption, "true")
+	return repo.SetConfig(cfg)
+}
+
+// producedByTool проверяет, создан ли репозиторий конвертером: по метке в
+// конфигурации, манифесту последнего запуска или сообщениям коммитов.
+// Репозиторий без коммитов считается своим — в нем нечего испортить
+func producedByTool(repo *git.Repository, config Config) (bool, error) {
+	if cfg, err := repo.Config(); err == nil &&
+		cfg.Raw.Section(repoMarkerSection).Option(repoMarkerOption) == "true" {
+		return true, nil
+	}
+
+	if _, err := os.Stat(manifestPath(config.TargetDir)); err == nil {
+		return true, nil
+	}
+
+	head, err := repo.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	commits, err := repo.Log(&git.LogOptions{From: head.Hash()})
+	if err != nil {
+		return false, err
+	}
+	defer commits.Close()
+
+	found := false
+	seen := 0
+	err = commits.ForEach(func(commit *object.Commit) error {
+		if isToolMessage(commit.Message, config.CommitTrailers) {
+			found = true
+			return errStopWalk
+		}
+		seen++
+		if seen >= ownershipHistoryDepth {
+			return errStopWalk
+		}
+		return nil
+	})
+	if err != nil && err != errStopWalk {
+		return false, err
+	}
+	return found, nil
+}
+
+// isToolMessage распознает сообщение коммита конвертера по стандартному
+// заголовку или по настроенным трейлерам
+func isToolMessage(msg string, trailers map[string]string) bool {
+	if strings.HasPrefix(msg, "Version ") {
+		return true
+	}
+	for _, line := range strings.Split(msg, "\n") {
+		for key := range trailers {
+			if strings.HasPrefix(line, key+": ") {
+				return true
+			}
+		}
+	}
+	return false
+}
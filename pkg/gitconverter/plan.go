@@ -0,0 +1,241 @@
+package gitconverter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Причины, по которым версия не попадет в историю
+const (
+	SkipExcluded = "исключено пользователем"
+	SkipExisting = "уже есть в репозитории"
+	SkipEmpty    = "нет файлов для добавления"
+	SkipNoChange = "нет изменений относительно предыдущей версии"
+)
+
+// PlannedCommit описывает коммит, который создаст миграция, или причину,
+// по которой версия будет пропущена
+type PlannedCommit struct {
+	Version  string    `json:"version"`
+	Path     string    `json:"path"`
+	Subject  string    `json:"subject,omitempty"` // Первая строка сообщения коммита
+	Author   string    `json:"author,omitempty"`
+	Email    string    `json:"email,omitempty"`
+	Date     time.Time `json:"date"`
+	Files    int       `json:"files"` // Файлов в версии
+	Added    int       `json:"added"`
+	Modified int       `json:"modified"`
+	Deleted  int       `json:"deleted"`
+	Bytes    int64     `json:"bytes"`             // Объем новых и измененных файлов, кроме файлов Git LFS
+	Warning  string    `json:"warning,omitempty"` // Превышение WarnCommitSize
+	LFS      []string  `json:"lfs,omitempty"`     // Новые и измененные файлы, которые попадут в Git LFS
+	Skipped  bool      `json:"skipped"`
+	Reason   string    `json:"reason,omitempty"` // Причина пропуска
+}
+
+// planMigration составляет план коммитов для папок folders в порядке миграции.
+// Исключенные пользователем папки excluded попадают в план с причиной пропуска.
+// Изменения считаются по хешам содержимого файлов относительно предыдущей
+// версии, в режиме добавления — относительно HEAD
+func planMigration(config Config, folders, excluded []FolderInfo) ([]PlannedCommit, error) {
+	names, err := newNameDecoder(config.SourceEncoding, config.logger())
+	if err != nil {
+		return nil, err
+	}
+	filter, err := newContentFilter(config)
+	if err != nil {
+		return nil, err
+	}
+	lfs, err := newLFSRules(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Состояние рабочей директории: путь в репозитории -> хеш содержимого
+	state := make(map[string]plumbing.Hash)
+	existing := make(map[string]bool)
+	if config.Append {
+		if repo, err := openRepository(config.TargetDir); err == nil {
+			if existing, err = migratedVersions(repo, config.TargetDir); err != nil {
+				return nil, err
+			}
+			if state, err = headFiles(repo, toolManagedPaths(config)); err != nil {
+				return nil, fmt.Errorf("ошибка чтения последнего коммита: %v", err)
+			}
+		}
+	}
+
+	skip := make(map[string]bool, len(excluded))
+	for _, folder := range excluded {
+		skip[folder.Path] = true
+	}
+
+	plan := make([]PlannedCommit, 0, len(folders))
+	for _, folder := range folders {
+		if skip[folder.Path] {
+			plan = append(plan, plannedSkip(folder, SkipExcluded))
+			continue
+		}
+		if config.Append && existing[folder.Version] {
+			plan = append(plan, plannedSkip(folder, SkipExisting))
+			continue
+		}
+
+		sources, err := planSources(folder, filter)
+		if err != nil {
+			return nil, err
+		}
+		if len(sources) == 0 {
+			plan = append(plan, plannedSkip(folder, SkipEmpty))
+			continue
+		}
+
+		// Каждая версия в своей папке не сравнивается с остальными
+		prefix := ""
+		if config.KeepVersionDir {
+			prefix = filepath.ToSlash(names.repoPath(filepath.Base(folder.Path))) + "/"
+		}
+
+		next := make(map[string]plumbing.Hash, len(sources))
+		step := PlannedCommit{Files: len(sources)}
+		var added []budgetFile
+		for rel, src := range sources {
+			name := prefix + filepath.ToSlash(names.repoPath(rel))
+			info, err := os.Lstat(src)
+			if err != nil {
+				return nil, err
+			}
+			// Файл LFS в дереве коммита — указатель, с ним и сравниваем
+			toLFS := info.Mode().IsRegular() && lfs.tracks(name, info.Size())
+			hash, err := storedBlobHash(config.NormalizeEOL, lfs, nil, name, src, info)
+			if err != nil {
+				return nil, err
+			}
+			next[name] = hash
+
+			old, ok := state[name]
+			switch {
+			case !ok:
+				step.Added++
+			case old != hash && (!config.Append || config.Incremental):
+				// В обычном режиме добавления существующие файлы не перезаписываются
+				step.Modified++
+			default:
+				continue
+			}
+			if toLFS {
+				step.LFS = append(step.LFS, name)
+				continue
+			}
+			added = append(added, budgetFile{path: name, size: info.Size()})
+			step.Bytes += info.Size()
+		}
+		sort.Strings(step.LFS)
+		step.Warning = checkBudget(folder.Version, added, config.WarnCommitSize)
+
+		switch {
+		case config.KeepVersionDir:
+			for name, hash := range next {
+				state[name] = hash
+			}
+		case config.Append && !config.Incremental:
+			for name, hash := range next {
+				if _, ok := state[name]; !ok {
+					state[name] = hash
+				}
+			}
+		default:
+			for name := range state {
+				if _, ok := next[name]; !ok {
+					step.Deleted++
+				}
+			}
+			state = next
+		}
+
+		// Без SkipUnchanged версия без изменений все равно получает свой коммит
+		if step.Added+step.Modified+step.Deleted == 0 && (config.Append || skipsUnchanged(config) || config.Incremental) {
+			plan = append(plan, plannedSkip(folder, SkipNoChange))
+			continue
+		}
+
+		authorName, authorEmail := resolveAuthor(config, folder.Version)
+		authorName, _ = names.decode(authorName)
+		folderName, _ := names.decode(filepath.Base(folder.Path))
+		msg := wrapSubject(collapsedMessage(mergedMessage(commitMessage(config, names, folder, folderName, len(sources), authorName), folder), folder), subjectLimit(config))
+		subject, _, _ := strings.Cut(msg, "\n")
+
+		step.Version = folder.Version
+		step.Path = folder.Path
+		step.Subject = subject
+		step.Author = authorName
+		step.Email = authorEmail
+		step.Date = time.Unix(folder.CreationTime, 0)
+		plan = append(plan, step)
+	}
+	return plan, nil
+}
+
+// planSources возвращает файлы версии: относительный путь -> полный путь
+func planSources(folder FolderInfo, filter *contentFilter) (map[string]string, error) {
+	if len(folder.MergedPaths) > 1 {
+		sources, _, err := mergedSources(folder, filter)
+		return sources, err
+	}
+	files, err := listFiles(folder.Path, false, filter)
+	if err != nil {
+		return nil, err
+	}
+	sources := make(map[string]string, len(files))
+	for rel := range files {
+		sources[rel] = filepath.Join(folder.Path, rel)
+	}
+	return sources, nil
+}
+
+// plannedSkip описывает пропускаемую версию
+func plannedSkip(folder FolderInfo, reason string) PlannedCommit {
+	return PlannedCommit{
+		Version: folder.Version,
+		Path:    folder.Path,
+		Date:    time.Unix(folder.CreationTime, 0),
+		Skipped: true,
+		Reason:  reason,
+	}
+}
+
+// headFiles возвращает файлы последнего коммита с хешами содержимого, кроме
+// файлов конвертера (managed). В пустом репозитории возвращает пустой список
+func headFiles(repo *git.Repository, managed toolPaths) (map[string]plumbing.Hash, error) {
+	files := make(map[string]plumbing.Hash)
+	ref, err := repo.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		return files, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if !managed.has(f.Name) {
+			files[f.Name] = f.Hash
+		}
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,9 @@
+package gitconverter
+
+// ErrorPolicy определяет, что делать, если обработка папки завершилась ошибкой
+type ErrorPolicy string
+
+const (
+	ErrorPolicyStop ErrorPolicy = "stop" // Остановить миграцию (по умолчанию)
+	ErrorPolicySkip ErrorPolicy = "skip" // Пропустить папку и перейти к следующей
+)
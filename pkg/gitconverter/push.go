@@ -0,0 +1,140 @@
+package gitconverter
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+// Параметры отправки по умолчанию
+const (
+	defaultRemoteName     = "origin"
+	defaultPushRetries    = 3
+	defaultPushRetryDelay = 5 * time.Second
+)
+
+// pushRefSpecs отправляет все ветки и теги
+var pushRefSpecs = []gitconfig.RefSpec{
+	"refs/heads/*:refs/heads/*",
+	"refs/tags/*:refs/tags/*",
+}
+
+// PushRepository отправляет ветки и теги репозитория TargetDir в удаленный
+// репозиторий. Протокол Git не позволяет продолжить прерванную передачу,
+// поэтому при обрыве соединения отправка повторяется целиком с нарастающей
+// паузой. Уже отправленные объекты сервер повторно не запрашивает
+func PushRepository(config Config) error {
+	repo, err := openRepository(config.TargetDir)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия репозитория: %v", err)
+	}
+
+	remoteName := config.RemoteName
+	if remoteName == "" {
+		remoteName = defaultRemoteName
+	}
+
+	remote, err := repo.Remote(remoteName)
+	if err == git.ErrRemoteNotFound {
+		if config.RemoteURL == "" {
+			return fmt.Errorf("удаленный репозиторий %s не настроен и не указан его адрес", remoteName)
+		}
+		remote, err = repo.CreateRemote(&gitconfig.RemoteConfig{
+			Name: remoteName,
+			URLs: []string{config.RemoteURL},
+		})
+		if err != nil {
+			return fmt.Errorf("ошибка добавления удаленного репозитория: %v", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("ошибка чтения удаленного репозитория: %v", err)
+	}
+
+	retries := config.PushRetries
+	if retries <= 0 {
+		retries = defaultPushRetries
+	}
+	delay := config.PushRetryDelay
+	if delay <= 0 {
+		delay = defaultPushRetryDelay
+	}
+
+	options := &git.PushOptions{
+		RemoteName: remoteName,
+		RefSpecs:   pushRefSpecs,
+	}
+	url := ""
+	if urls := remote.Config().URLs; len(urls) > 0 {
+		url = urls[0]
+	}
+	options.Auth, err = pushAuth(config, url)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = repo.Push(options)
+		if err == nil || err == git.NoErrAlreadyUpToDate {
+			config.logger().Printf("Репозиторий отправлен в %s", remoteName)
+			return nil
+		}
+
+		if attempt > retries || !isRetryablePushError(err) {
+			break
+		}
+		config.logger().Printf("Ошибка отправки (попытка %d из %d): %v. Повтор через %s", attempt, retries+1, err, delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+
+	return fmt.Errorf("не удалось отправить репозиторий в %s: %v. Локальный репозиторий в %s полностью готов, "+
+		"отправку можно повторить вручную: git -C %s push %s --all && git -C %s push %s --tags",
+		remoteName, err, config.TargetDir, config.TargetDir, remoteName, config.TargetDir, remoteName)
+}
+
+// pushAuth выбирает способ входа для адреса url: SSH-ключ PushSSHKey или
+// логин и токен из учетных данных PushCredential. Без них go-git использует
+// SSH-агент для ssh-адресов и анонимный доступ для HTTP
+func pushAuth(config Config, url string) (transport.AuthMethod, error) {
+	if config.PushSSHKey != "" {
+		user := gitssh.DefaultUsername
+		if endpoint, err := transport.NewEndpoint(url); err == nil && endpoint.User != "" {
+			user = endpoint.User
+		}
+		auth, err := gitssh.NewPublicKeysFromFile(user, config.PushSSHKey, config.PushSSHKeyPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения SSH-ключа %s: %v", config.PushSSHKey, err)
+		}
+		return auth, nil
+	}
+	if config.PushCredential != "" {
+		user, password, err := resolveCredential(config, config.PushCredential)
+		if err != nil {
+			return nil, err
+		}
+		return &githttp.BasicAuth{Username: user, Password: password}, nil
+	}
+	return nil, nil
+}
+
+// isRetryablePushError отделяет сетевые сбои от ошибок, которые повтор не исправит
+func isRetryablePushError(err error) bool {
+	for _, permanent := range []error{
+		transport.ErrAuthenticationRequired,
+		transport.ErrAuthorizationFailed,
+		transport.ErrRepositoryNotFound,
+		transport.ErrInvalidAuthMethod,
+		git.ErrNonFastForwardUpdate,
+	} {
+		if errors.Is(err, permanent) {
+			return false
+		}
+	}
+	return true
+}
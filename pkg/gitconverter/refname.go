@@ -0,0 +1,94 @@
+package gitconverter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// defaultRefReplacement заменяет символы, недопустимые в именах Git-ссылок
+const defaultRefReplacement = "-"
+
+// CanonicalVersion приводит версию к виду, допустимому в имени тега или ветки
+// (см. git check-ref-format). Исходная FolderInfo.Version при этом не меняется
+// и по-прежнему используется в сообщениях коммитов
+func CanonicalVersion(config Config, version string) string {
+	replacement := config.RefReplacement
+	if replacement == "" {
+		replacement = defaultRefReplacement
+	}
+
+	if config.RefLowercase {
+		version = strings.ToLower(version)
+	}
+
+	var b strings.Builder
+	for _, r := range version {
+		if isIllegalRefRune(r) {
+			b.WriteString(replacement)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	name := b.String()
+
+	// Последовательности, запрещенные внутри имени ссылки
+	for _, seq := range []string{"..", "@{"} {
+		for strings.Contains(name, seq) {
+			name = strings.ReplaceAll(name, seq, replacement)
+		}
+	}
+
+	// Схлопываем повторяющиеся замены
+	double := replacement + replacement
+	for strings.Contains(name, double) {
+		name = strings.ReplaceAll(name, double, replacement)
+	}
+
+	// Замены по краям ничего не несут, убираем их
+	name = strings.TrimSuffix(strings.TrimPrefix(name, replacement), replacement)
+
+	// Имя не может начинаться с точки или дефиса и заканчиваться на точку или .lock
+	name = strings.TrimLeft(name, ".-")
+	for trimmed := ""; trimmed != name; {
+		trimmed = name
+		name = strings.TrimRight(strings.TrimSuffix(name, ".lock"), ".")
+	}
+
+	if name == "@" {
+		return ""
+	}
+	return name
+}
+
+// validateRefReplacement проверяет замену RefReplacement: она сама попадает
+// в имена ссылок, поэтому не может содержать недопустимых символов. Точки
+// тоже запрещены: из них складываются "..", а по краям имени они отрезаются
+func validateRefReplacement(replacement string) error {
+	if replacement == "" {
+		return nil
+	}
+	for _, r := range replacement {
+		if isIllegalRefRune(r) || r == '.' {
+			return fmt.Errorf("недопустимая замена символов в именах ссылок %q", replacement)
+		}
+	}
+	if err := plumbing.NewTagReferenceName("v1" + replacement + "0").Validate(); err != nil {
+		return fmt.Errorf("недопустимая замена символов в именах ссылок %q", replacement)
+	}
+	return nil
+}
+
+// isIllegalRefRune проверяет, запрещен ли символ в имени Git-ссылки
+func isIllegalRefRune(r rune) bool {
+	if isUnsafeRune(r) || unicode.IsSpace(r) {
+		return true
+	}
+	switch r {
+	case '~', '^', ':', '?', '*', '[', '\\', '/':
+		return true
+	}
+	return false
+}
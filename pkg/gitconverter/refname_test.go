@@ -0,0 +1,56 @@
+package gitconverter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestCanonicalVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		version string
+		want    string
+	}{
+		{"допустимая версия", Config{}, "1.2.3", "1.2.3"},
+		{"пробелы и спецсимволы", Config{}, "1.0 beta~2^:x", "1.0-beta-2-x"},
+		{"две точки", Config{}, "1..2", "1-2"},
+		{"края", Config{}, ".-1.0.lock.", "1.0"},
+		{"нижний регистр", Config{RefLowercase: true}, "RC 1", "rc-1"},
+		{"своя замена", Config{RefReplacement: "_"}, "1 2/3", "1_2_3"},
+		{"только @", Config{}, "@", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanonicalVersion(tt.config, tt.version); got != tt.want {
+				t.Errorf("CanonicalVersion(%q) = %q, ожидалось %q", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRefReplacement(t *testing.T) {
+	for _, replacement := range []string{"", "-", "_", "+", "--"} {
+		if err := validateRefReplacement(replacement); err != nil {
+			t.Errorf("замена %q отклонена: %v", replacement, err)
+		}
+		name := CanonicalVersion(Config{RefReplacement: replacement}, "1.0 beta/2")
+		if err := plumbing.NewTagReferenceName(name).Validate(); err != nil {
+			t.Errorf("замена %q дает недопустимое имя %q", replacement, name)
+		}
+	}
+	for _, replacement := range []string{"/", "~", ".", "..", "@{", " ", "a:b", "\x01"} {
+		if err := validateRefReplacement(replacement); err == nil {
+			t.Errorf("замена %q принята", replacement)
+		}
+	}
+}
+
+func TestNewRejectsRefReplacement(t *testing.T) {
+	_, err := New(Config{SourceDir: "src", TargetDir: "dst", RefReplacement: "/"})
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("New вернул %v, ожидалась ErrInvalidConfig", err)
+	}
+}
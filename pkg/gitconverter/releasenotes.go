@@ -0,0 +1,189 @@
+package gitconverter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
+)
+
+// ReleaseNotes формирует в Markdown заметки к выпуску version: какие файлы
+// добавлены, удалены и изменены по сравнению с предыдущей версией с тегом.
+// Нужны теги версий (AnnotatedTags)
+func ReleaseNotes(config Config, version string) (string, error) {
+	repo, err := openRepository(config.TargetDir)
+	if err != nil {
+		return "", fmt.Errorf("ошибка открытия репозитория: %v", err)
+	}
+	tags, err := versionTags(repo)
+	if err != nil {
+		return "", err
+	}
+
+	name := versionTagName(config, version)
+	for hash, tag := range tags {
+		if tag == name {
+			return releaseNotes(repo, hash, tags, config.TagFileListLimit)
+		}
+	}
+	return "", fmt.Errorf("тег версии %s не найден", name)
+}
+
+// AllReleaseNotes формирует заметки ко всем версиям с тегами в истории
+// текущей ветки, от новых к старым, например для RELEASES.md
+func AllReleaseNotes(config Config) (string, error) {
+	repo, err := openRepository(config.TargetDir)
+	if err != nil {
+		return "", fmt.Errorf("ошибка открытия репозитория: %v", err)
+	}
+	tags, err := versionTags(repo)
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("ошибка чтения HEAD: %v", err)
+	}
+
+	var b strings.Builder
+	b.WriteString("# Выпуски\n")
+	for hash := head.Hash(); !hash.IsZero(); {
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return "", err
+		}
+		if _, ok := tags[hash]; ok {
+			notes, err := releaseNotes(repo, hash, tags, config.TagFileListLimit)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString("\n")
+			b.WriteString(notes)
+		}
+		hash = firstParent(commit)
+	}
+	return b.String(), nil
+}
+
+// versionTags сопоставляет коммиты с именами указывающих на них тегов
+func versionTags(repo *git.Repository) (map[plumbing.Hash]string, error) {
+	tags := make(map[plumbing.Hash]string)
+	iter, err := repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения тегов: %v", err)
+	}
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		hash := ref.Hash()
+		// Аннотированный тег указывает на объект тега, а не на коммит
+		if tag, err := repo.TagObject(hash); err == nil {
+			if tag.TargetType != plumbing.CommitObject {
+				return nil
+			}
+			hash = tag.Target
+		}
+		tags[hash] = ref.Name().Short()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения тегов: %v", err)
+	}
+	return tags, nil
+}
+
+// firstParent возвращает первого родителя коммита или нулевой хеш
+func firstParent(commit *object.Commit) plumbing.Hash {
+	if len(commit.ParentHashes) == 0 {
+		return plumbing.ZeroHash
+	}
+	return commit.ParentHashes[0]
+}
+
+// releaseNotes сравнивает коммит версии с ближайшим предыдущим коммитом с тегом
+func releaseNotes(repo *git.Repository, hash plumbing.Hash, tags map[plumbing.Hash]string, limit int) (string, error) {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return "", err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return "", err
+	}
+
+	// Промежуточные коммиты (части большой версии) пропускаем
+	var prevTree *object.Tree
+	prevName := ""
+	for parent := firstParent(commit); !parent.IsZero(); {
+		prev, err := repo.CommitObject(parent)
+		if err != nil {
+			return "", err
+		}
+		if name, ok := tags[parent]; ok {
+			if prevTree, err = prev.Tree(); err != nil {
+				return "", err
+			}
+			prevName = name
+			break
+		}
+		parent = firstParent(prev)
+	}
+
+	changes, err := object.DiffTree(prevTree, tree)
+	if err != nil {
+		return "", fmt.Errorf("ошибка сравнения версий: %v", err)
+	}
+
+	var added, removed, modified []string
+	for _, change := range changes {
+		action, err := change.Action()
+		if err != nil {
+			return "", err
+		}
+		switch action {
+		case merkletrie.Insert:
+			added = append(added, change.To.Name)
+		case merkletrie.Delete:
+			removed = append(removed, change.From.Name)
+		case merkletrie.Modify:
+			modified = append(modified, change.To.Name)
+		}
+	}
+
+	if limit <= 0 {
+		limit = defaultTagFileListLimit
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", tags[hash])
+	fmt.Fprintf(&b, "Дата: %s", commit.Author.When.Format("2006-01-02"))
+	if prevName != "" {
+		fmt.Fprintf(&b, ", предыдущая версия: %s", prevName)
+	} else {
+		b.WriteString(", первая версия")
+	}
+	fmt.Fprintf(&b, "\n\nДобавлено: %d, удалено: %d, изменено: %d\n", len(added), len(removed), len(modified))
+
+	writeFileSection(&b, "Добавлено", added, limit)
+	writeFileSection(&b, "Удалено", removed, limit)
+	writeFileSection(&b, "Изменено", modified, limit)
+	return b.String(), nil
+}
+
+// writeFileSection выводит список файлов раздела, не больше limit строк
+func writeFileSection(b *strings.Builder, title string, files []string, limit int) {
+	if len(files) == 0 {
+		return
+	}
+	sort.Strings(files)
+	fmt.Fprintf(b, "\n### %s\n\n", title)
+	for i, name := range files {
+		if i == limit {
+			fmt.Fprintf(b, "- ... и еще %d\n", len(files)-limit)
+			break
+		}
+		fmt.Fprintf(b, "- `%s`\n", name)
+	}
+}
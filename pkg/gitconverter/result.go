@@ -0,0 +1,81 @@
+package gitconverter
+
+import "encoding/json"
+
+// SchemaVersion — версия формата JSON-вывода (итогов миграции и событий).
+// Увеличивается при несовместимых изменениях, чтобы потребители могли
+// отказаться разбирать незнакомый формат
+const SchemaVersion = 1
+
+// ScanResult содержит итоги поиска папок с версиями
+type ScanResult struct {
+	Folders    []FolderInfo
+	Unmatched  []string           // Папки, подходящие под шаблон, но без версии в имени
+	Inversions []VersionInversion // Пары папок, порядок дат которых противоречит номерам версий
+	Unreadable []string           // Папки, пропущенные из-за отсутствия прав доступа
+	Order      []OrderDecision    // Объяснение места каждой папки в порядке Folders
+}
+
+// MigrationResult содержит итоги миграции
+type MigrationResult struct {
+	Layout          []LayoutEntry    `json:"layout,omitempty"`          // Папки, разложенные в режиме NoGit
+	Failed          []FolderFailure  `json:"failed,omitempty"`          // Папки, обработка которых завершилась ошибкой
+	Vanished        []FolderFailure  `json:"vanished,omitempty"`        // Папки, исчезнувшие после сканирования (этап StageVanished) и пропущенные
+	Unchanged       []string         `json:"unchanged,omitempty"`       // Версии без изменений, для которых коммит не создан
+	SkippedVersions []string         `json:"skippedVersions,omitempty"` // Версии, которые уже есть в репозитории (режим добавления)
+	Collapsed       []string         `json:"collapsed,omitempty"`       // Версии снимков, свернутых GroupBy в коммит более позднего снимка периода
+	Commits         []CommitRecord   `json:"commits,omitempty"`         // Коммиты, созданные в этом запуске, в порядке версий
+	CommitsCreated  int              `json:"commitsCreated"`            // Сколько коммитов создано, с учетом частей больших версий
+	Pushed          bool             `json:"pushed"`                    // Репозиторий отправлен в удаленный (PushAfterMigrate)
+	RepoCreated     bool             `json:"repoCreated"`               // Репозиторий инициализирован в этом запуске, а не открыт существующий
+	Filesystem      *FilesystemProbe `json:"filesystem,omitempty"`      // Возможности файловой системы TargetDir
+	Growth          []VersionGrowth  `json:"growth,omitempty"`          // Оценка роста репозитория по версиям, только в режиме DryRun
+	Excluded        []string         `json:"excluded,omitempty"`        // Версии, пропущенные по исключениям пользователя
+	Plan            []PlannedCommit  `json:"plan,omitempty"`            // Коммиты, которые создаст миграция, только в режиме DryRun
+	Warnings        []FolderWarning  `json:"warnings,omitempty"`        // Предупреждения, относящиеся к отдельным папкам
+	Order           []OrderDecision  `json:"order,omitempty"`           // Порядок переданных папок и его причины
+}
+
+// MarshalJSON добавляет к итогам миграции поле schemaVersion
+func (r MigrationResult) MarshalJSON() ([]byte, error) {
+	type plain MigrationResult
+	return json.Marshal(struct {
+		SchemaVersion int `json:"schemaVersion"`
+		plain
+	}{SchemaVersion, plain(r)})
+}
+
+// FolderFailure описывает папку, которую не удалось перенести в репозиторий
+type FolderFailure struct {
+	Version  string `json:"version"`
+	Path     string `json:"path"`
+	Stage    string `json:"stage"` // Этап, на котором произошла ошибка
+	Err      string `json:"error"`
+	TimedOut bool   `json:"timedOut"` // Обработка прервана по FolderTimeout
+}
+
+// CommitRecord описывает коммит, созданный для версии. Версия, разбитая по
+// MaxCommitSize на несколько коммитов, описывается последним из них
+type CommitRecord struct {
+	Version   string `json:"version"`
+	Path      string `json:"path"`
+	Hash      string `json:"hash"`
+	FileCount int    `json:"fileCount"` // Сколько файлов перенесено, в режиме Incremental — сколько изменено
+	Parts     int    `json:"parts"`     // Сколько коммитов занимает версия
+}
+
+// FolderWarning — предупреждение при обработке папки версии, например о
+// пропущенном файле или несовпадении коммита с папкой
+type FolderWarning struct {
+	Version string `json:"version"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// LayoutEntry описывает папку версии, разложенную в режиме NoGit
+type LayoutEntry struct {
+	Version   string `json:"version"`
+	Source    string `json:"source"` // Исходная папка
+	Target    string `json:"target"` // Папка в TargetDir
+	FileCount int    `json:"fileCount"`
+}
@@ -0,0 +1,232 @@
+package gitconverter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// RewrittenCommit описывает коммит, переписанный с новым автором
+type RewrittenCommit struct {
+	Version string `json:"version"`
+	Old     string `json:"old"`
+	New     string `json:"new"`
+	Author  string `json:"author"` // Новый автор "Имя <почта>"
+}
+
+// AuthorRewrite содержит итоги переписывания авторов
+type AuthorRewrite struct {
+	Branch    string            `json:"branch"`    // Ветка с переписанной историей
+	Head      string            `json:"head"`      // Новая вершина ветки
+	Rewritten []RewrittenCommit `json:"rewritten"` // Коммиты, у которых сменился автор
+	Unmapped  int               `json:"unmapped"`  // Коммиты без версии, перенесенные как есть
+}
+
+// RewriteOptions задает параметры переписывания авторов
+type RewriteOptions struct {
+	Branch  string // Ветка для новой истории (по умолчанию <текущая>-authors)
+	InPlace bool   // Перенести текущую ветку на новую историю
+	Force   bool   // Переписывать историю, даже если в ней есть коммиты без версии
+}
+
+// RewriteAuthors переписывает авторов коммитов истории HEAD по файлу
+// config.AuthorsFile. Версия коммита определяется, как в RetrofitTags, по
+// трейлерам или сообщению "Version X: ...". Если коммитер совпадал со старым
+// автором, он меняется тоже. Новая история записывается в отдельную ветку,
+// исходная ветка и теги не трогаются, если не задан InPlace.
+//
+// Коммиты без версии нельзя сопоставить с файлом авторов, поэтому при их
+// наличии переписывание отклоняется, пока не задан Force; тогда такие коммиты
+// переносятся с прежним автором
+func RewriteAuthors(config Config, opts RewriteOptions) (*AuthorRewrite, error) {
+	if config.AuthorsFile == "" {
+		return nil, fmt.Errorf("не указан файл авторов")
+	}
+	if _, err := os.Stat(config.AuthorsFile); err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла авторов: %v", err)
+	}
+
+	repo, err := openRepository(config.TargetDir)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия репозитория: %v", err)
+	}
+	head, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения HEAD: %v", err)
+	}
+	if head.Type() != plumbing.SymbolicReference {
+		return nil, fmt.Errorf("HEAD не указывает на ветку")
+	}
+	current := head.Target()
+	tip, err := repo.Reference(current, true)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения ветки %s: %v", current.Short(), err)
+	}
+
+	target := current
+	if !opts.InPlace {
+		name := opts.Branch
+		if name == "" {
+			name = current.Short() + "-authors"
+		}
+		target = plumbing.NewBranchReferenceName(name)
+		if err := target.Validate(); err != nil {
+			return nil, fmt.Errorf("недопустимое имя ветки %q: %v", name, err)
+		}
+		if _, err := repo.Reference(target, false); err == nil {
+			return nil, fmt.Errorf("ветка %s уже существует", name)
+		}
+	}
+
+	order, err := commitsOldestFirst(repo, tip.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for key, value := range config.CommitTrailers {
+		if strings.TrimSpace(value) == "{version}" {
+			keys = append(keys, key)
+		}
+	}
+
+	var unmapped []string
+	for _, commit := range order {
+		if _, _, ok := commitVersion(commit.Message, keys); !ok {
+			unmapped = append(unmapped, commit.Hash.String()[:7])
+		}
+	}
+	if len(unmapped) > 0 && !opts.Force {
+		return nil, fmt.Errorf("в истории %d коммитов без версии (%s), их нельзя сопоставить с файлом авторов; используйте принудительный режим, чтобы перенести их как есть",
+			len(unmapped), strings.Join(firstN(unmapped, verifyExamples), ", "))
+	}
+
+	result := &AuthorRewrite{Branch: target.Short(), Unmapped: len(unmapped)}
+	mapped := make(map[plumbing.Hash]plumbing.Hash, len(order))
+	for _, commit := range order {
+		oldHash := commit.Hash
+		changed := false
+		for i, parent := range commit.ParentHashes {
+			if newParent, ok := mapped[parent]; ok && newParent != parent {
+				commit.ParentHashes[i] = newParent
+				changed = true
+			}
+		}
+
+		version, _, ok := commitVersion(commit.Message, keys)
+		var name, email string
+		if ok {
+			name, email, _ = getAuthorInfo(version, config.AuthorsFile)
+		}
+		rewritten := false
+		if name != "" && email != "" {
+			// Имя в коммите записано в его кодировке, и сравнивать нужно с ней
+			encodedName, err := commitAuthorName(commit, name)
+			if err != nil {
+				return nil, fmt.Errorf("коммит %s: %v", oldHash.String()[:7], err)
+			}
+			if encodedName != commit.Author.Name || email != commit.Author.Email {
+				setCommitAuthor(commit, encodedName, email)
+				rewritten, changed = true, true
+			}
+		}
+
+		if !changed {
+			mapped[oldHash] = oldHash
+			continue
+		}
+
+		// Подпись относится к старому содержимому коммита
+		commit.PGPSignature = ""
+		obj := repo.Storer.NewEncodedObject()
+		if err := commit.Encode(obj); err != nil {
+			return nil, err
+		}
+		newHash, err := repo.Storer.SetEncodedObject(obj)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка записи коммита: %v", err)
+		}
+		mapped[oldHash] = newHash
+		if rewritten {
+			result.Rewritten = append(result.Rewritten, RewrittenCommit{
+				Version: version,
+				Old:     oldHash.String(),
+				New:     newHash.String(),
+				Author:  fmt.Sprintf("%s <%s>", name, email),
+			})
+		}
+	}
+
+	newTip := mapped[tip.Hash()]
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(target, newTip)); err != nil {
+		return nil, fmt.Errorf("ошибка записи ветки %s: %v", target.Short(), err)
+	}
+	result.Head = newTip.String()
+	if opts.InPlace && newTip != tip.Hash() {
+		config.logger().Printf("Ветка %s перенесена с %s на %s, теги указывают на прежние коммиты", target.Short(), tip.Hash().String()[:7], newTip.String()[:7])
+	}
+	return result, nil
+}
+
+// commitAuthorName возвращает имя name в кодировке коммита commit, в которой
+// записаны его автор и коммитер
+func commitAuthorName(commit *object.Commit, name string) (string, error) {
+	if isUTF8Encoding(string(commit.Encoding)) {
+		return name, nil
+	}
+	enc, err := lookupEncoding(string(commit.Encoding))
+	if err != nil {
+		return "", err
+	}
+	encoded, err := enc.NewEncoder().String(name)
+	if err != nil {
+		return "", fmt.Errorf("имя автора не представимо в кодировке %s: %v", commit.Encoding, err)
+	}
+	return encoded, nil
+}
+
+// setCommitAuthor меняет автора коммита, а также коммитера, если он совпадал
+// с автором. Имя name уже должно быть в кодировке коммита
+func setCommitAuthor(commit *object.Commit, name, email string) {
+	if commit.Committer.Name == commit.Author.Name && commit.Committer.Email == commit.Author.Email {
+		commit.Committer.Name, commit.Committer.Email = name, email
+	}
+	commit.Author.Name, commit.Author.Email = name, email
+}
+
+// commitsOldestFirst возвращает коммиты, достижимые из hash, так, что
+// родители идут раньше потомков
+func commitsOldestFirst(repo *git.Repository, hash plumbing.Hash) ([]*object.Commit, error) {
+	var order []*object.Commit
+	visited := make(map[plumbing.Hash]bool)
+	stack := []plumbing.Hash{hash}
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		if visited[top] {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		commit, err := repo.CommitObject(top)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения коммита %s: %v", top, err)
+		}
+		pending := false
+		for _, parent := range commit.ParentHashes {
+			if !visited[parent] {
+				stack = append(stack, parent)
+				pending = true
+			}
+		}
+		if pending {
+			continue
+		}
+		visited[top] = true
+		order = append(order, commit)
+		stack = stack[:len(stack)-1]
+	}
+	return order, nil
+}
@@ -0,0 +1,63 @@
+package gitconverter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestRewriteAuthorsInLegacyEncoding(t *testing.T) {
+	src := versionSource(t,
+		map[string]string{"a.txt": "1"},
+		map[string]string{"a.txt": "2"},
+	)
+	dst := t.TempDir()
+	authors := filepath.Join(t.TempDir(), "authors.txt")
+	if err := os.WriteFile(authors, []byte("1:Иван Петров:ivan@example.com\n2:Иван Петров:ivan@example.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := testConfig(src, dst)
+	config.AuthorsFile = authors
+	config.MessageEncoding = "windows-1251"
+	mustRun(t, config)
+	tip := history(t, dst)[1].Hash.String()
+
+	// Авторы уже совпадают с файлом, хотя имена записаны в windows-1251
+	result, err := RewriteAuthors(config, RewriteOptions{Branch: "same"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Rewritten) != 0 || result.Head != tip {
+		t.Errorf("переписаны коммиты %+v, ожидалось ни одного", result.Rewritten)
+	}
+
+	if err := os.WriteFile(authors, []byte("2:Мария Сидорова:maria@example.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err = RewriteAuthors(config, RewriteOptions{Branch: "changed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Rewritten) != 1 || result.Rewritten[0].Version != "2" {
+		t.Fatalf("переписаны коммиты %+v, ожидалась только версия 2", result.Rewritten)
+	}
+	repo, err := git.PlainOpen(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := repo.CommitObject(plumbing.NewHash(result.Head))
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := lookupEncoding("windows-1251")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := enc.NewDecoder().String(commit.Author.Name)
+	if err != nil || name != "Мария Сидорова" {
+		t.Errorf("автор %q (%v), ожидалось имя в windows-1251", name, err)
+	}
+}
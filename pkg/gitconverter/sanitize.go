@@ -0,0 +1,54 @@
+package gitconverter
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxPrintableLength ограничивает длину имени папки или версии в логе и
+// сообщениях, чтобы одно имя не растягивалось на экран
+const maxPrintableLength = 200
+
+// escapeControl заменяет управляющие символы и символы смены направления
+// текста их экранированной записью (\n, \x1b, \u202e). Такие символы
+// встречаются в именах папок после распаковки старых архивов и ломают лог,
+// сообщения коммитов и отображение в интерфейсе
+func escapeControl(s string) string {
+	if strings.IndexFunc(s, isUnsafeRune) < 0 {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if !isUnsafeRune(r) {
+			b.WriteRune(r)
+			continue
+		}
+		quoted := strconv.QuoteRune(r)
+		b.WriteString(quoted[1 : len(quoted)-1])
+	}
+	return b.String()
+}
+
+// Printable готовит непроверенную строку (имя папки, версию, значение из
+// файла авторов) для лога и сообщений: экранирует управляющие символы и
+// обрезает до maxPrintableLength символов. Исходное значение остается только
+// в структурах и манифесте, поэтому вызывающей стороне стоит пропускать
+// через Printable поля MigrationResult перед выводом
+func Printable(s string) string {
+	s = escapeControl(s)
+	if utf8.RuneCountInString(s) <= maxPrintableLength {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:maxPrintableLength-1]) + "…"
+}
+
+// isUnsafeRune проверяет, что символ управляющий или меняет направление текста
+func isUnsafeRune(r rune) bool {
+	if r == utf8.RuneError || unicode.IsControl(r) {
+		return true
+	}
+	return unicode.Is(unicode.Bidi_Control, r)
+}
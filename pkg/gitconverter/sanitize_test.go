@@ -0,0 +1,138 @@
+package gitconverter
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// hostileName — имя папки с переводом строки, escape-последовательностью
+// терминала и сменой направления текста
+const hostileName = "v1\nINJECT\x1b[31m\u202eevil"
+
+func TestPrintable(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"v1.2", "v1.2"},
+		{"Версия 1", "Версия 1"},
+		{"a\nb", `a\nb`},
+		{"a\r\tb", `a\r\tb`},
+		{"\x1b[31mred", `\x1b[31mred`},
+		{"abc\u202eed", `abc\u202eed`},
+		{"\u2066x\u2069", `\u2066x\u2069`},
+		{"\x00", `\x00`},
+		{"bad\xffbyte", "bad\ufffdbyte"},
+		{strings.Repeat("я", maxPrintableLength), strings.Repeat("я", maxPrintableLength)},
+		{strings.Repeat("я", maxPrintableLength+1), strings.Repeat("я", maxPrintableLength-1) + "…"},
+	}
+	for _, tt := range tests {
+		if got := Printable(tt.in); got != tt.want {
+			t.Errorf("Printable(%q) = %q, ожидалось %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// assertSafe проверяет, что в s нет управляющих символов, кроме разрешенных
+// переводов строки, и нет строки, начатой внедренным текстом
+func assertSafe(t *testing.T, what, s string) {
+	t.Helper()
+	for _, r := range s {
+		if r != '\n' && isUnsafeRune(r) {
+			t.Errorf("%s содержит символ %U: %q", what, r, s)
+			return
+		}
+	}
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(line, "INJECT") {
+			t.Errorf("%s содержит внедренную строку: %q", what, s)
+		}
+	}
+}
+
+func TestHostileFolderName(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows не допускает управляющих символов в именах файлов")
+	}
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{hostileName + "/a.txt": "1\n"})
+	setTreeTime(t, src, testEpoch)
+
+	dst := t.TempDir()
+	config := testConfig(src, dst)
+	// Версия захватывает все имя вместе с переводом строки
+	config.ExtractPattern = `(?s)[0-9].*`
+	config.AnnotatedTags = true
+	config.CommitTrailers = map[string]string{"Source-Folder": "{folder}", "Version": "{version}"}
+	result := mustRun(t, config)
+
+	if len(result.Commits) != 1 || result.Commits[0].Version != hostileName[1:] {
+		t.Fatalf("итоги: %+v", result.Commits)
+	}
+
+	logger := config.Logger.(*testLogger)
+	for _, line := range logger.lines {
+		assertSafe(t, "строка лога", line)
+	}
+
+	commits := history(t, dst)
+	msg := commits[0].Message
+	assertSafe(t, "сообщение коммита", msg)
+	for _, want := range []string{
+		`Source-Folder: v1\nINJECT\x1b[31m\u202eevil`,
+		`Version: 1\nINJECT\x1b[31m\u202eevil`,
+	} {
+		if !strings.Contains(msg, want+"\n") {
+			t.Errorf("трейлер %q не найден одной строкой в %q", want, msg)
+		}
+	}
+
+	// Имя тега проходит проверку Git, сообщение тега экранировано
+	names := tagNames(t, dst)
+	if len(names) != 1 {
+		t.Fatalf("теги: %q", names)
+	}
+	assertSafe(t, "имя тега", names[0])
+	if err := plumbing.NewTagReferenceName(names[0]).Validate(); err != nil {
+		t.Errorf("недопустимое имя тега %q: %v", names[0], err)
+	}
+	repo, err := git.PlainOpen(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSafe(t, "сообщение тега", annotatedTag(t, repo, names[0]).Message)
+
+	// Файлы папки переносятся без изменений
+	if files := treeFiles(t, commits[0]); files["a.txt"].Content != "1\n" {
+		t.Errorf("содержимое a.txt: %q", files["a.txt"].Content)
+	}
+}
+
+func TestHostileAuthor(t *testing.T) {
+	src := versionSource(t, map[string]string{"a.txt": "1\n"})
+	authors := filepath.Join(t.TempDir(), "authors.txt")
+	if err := os.WriteFile(authors, []byte("1:Evil\x1b[31m\u202eName:evil@example.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := t.TempDir()
+	config := testConfig(src, dst)
+	config.AuthorsFile = authors
+	config.MessageTemplate = "Версия {version} от {author}"
+	mustRun(t, config)
+
+	commit := history(t, dst)[0]
+	if want := `Evil\x1b[31m\u202eName`; commit.Author.Name != want {
+		t.Errorf("автор %q, ожидался %q", commit.Author.Name, want)
+	}
+	assertSafe(t, "сообщение коммита", commit.Message)
+	if !utf8.ValidString(commit.Message) {
+		t.Errorf("сообщение не в UTF-8: %q", commit.Message)
+	}
+}
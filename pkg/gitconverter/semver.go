@@ -0,0 +1,21 @@
+package gitconverter
+
+import "fmt"
+
+// SortMode определяет порядок, в котором папки попадают в историю
+type SortMode string
+
+const (
+	SortByTime    SortMode = "time"    // По времени создания (по умолчанию)
+	SortBySemver  SortMode = "semver"  // По семантической версии, при равенстве по времени
+	SortByNatural SortMode = "natural" // По номерам в версии (v1.2 < v1.10), при равенстве по времени
+)
+
+// validateSortMode проверяет значение SortMode
+func validateSortMode(mode SortMode) error {
+	switch mode {
+	case "", SortByTime, SortBySemver, SortByNatural:
+		return nil
+	}
+	return fmt.Errorf("неизвестный порядок сортировки %q", mode)
+}
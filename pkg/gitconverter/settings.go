@@ -0,0 +1,135 @@
+package gitconverter
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// RunSettings — настройки запуска, от которых зависит вид истории. Снимок
+// сохраняется в манифесте репозитория, чтобы при следующем добавлении версий
+// использовать те же шаблоны, правила пропуска и порядок. Имена полей
+// совпадают с полями Config; секреты и адреса в снимок не попадают
+type RunSettings struct {
+	Pattern              string            `json:"pattern,omitempty"`
+	ExtractPattern       string            `json:"extractPattern,omitempty"`
+	Recursive            bool              `json:"recursive,omitempty"`
+	MaxDepth             int               `json:"maxDepth,omitempty"`
+	VersionOverrides     map[string]string `json:"versionOverrides,omitempty"`
+	MergeSameVersion     bool              `json:"mergeSameVersion,omitempty"`
+	GroupBy              GroupPeriod       `json:"groupBy,omitempty"`
+	SkipUnchanged        bool              `json:"skipUnchanged,omitempty"`
+	SkipEmptyCommits     bool              `json:"skipEmptyCommits,omitempty"`
+	IgnoreDirs           []string          `json:"ignoreDirs,omitempty"`
+	IgnoreFiles          []string          `json:"ignoreFiles,omitempty"`
+	RespectGitignore     bool              `json:"respectGitignore,omitempty"`
+	KeepGitignore        bool              `json:"keepGitignore,omitempty"`
+	Author               string            `json:"author,omitempty"`
+	Email                string            `json:"email,omitempty"`
+	AuthorsFile          string            `json:"authorsFile,omitempty"`
+	CommitterName        string            `json:"committerName,omitempty"`
+	Committer            string            `json:"committer,omitempty"`
+	CommitterEmail       string            `json:"committerEmail,omitempty"`
+	MessageTemplate      string            `json:"messageTemplate,omitempty"`
+	SubjectLimit         int               `json:"subjectLimit,omitempty"`
+	RefLowercase         bool              `json:"refLowercase,omitempty"`
+	RefReplacement       string            `json:"refReplacement,omitempty"`
+	Incremental          bool              `json:"incremental,omitempty"`
+	MessageEncoding      string            `json:"messageEncoding,omitempty"`
+	SourceEncoding       string            `json:"sourceEncoding,omitempty"`
+	Anonymize            bool              `json:"anonymize,omitempty"`
+	AnonymousAuthor      string            `json:"anonymousAuthor,omitempty"`
+	AnonymousEmail       string            `json:"anonymousEmail,omitempty"`
+	AnnotatedTags        bool              `json:"annotatedTags,omitempty"`
+	CreateTags           bool              `json:"createTags,omitempty"`
+	TagPrefix            string            `json:"tagPrefix,omitempty"`
+	TimestampStrategy    TimestampStrategy `json:"timestampStrategy,omitempty"`
+	KeepVersionDir       bool              `json:"keepVersionDir,omitempty"`
+	CommitTrailers       map[string]string `json:"commitTrailers,omitempty"`
+	CommitDateSource     CommitDateSource  `json:"commitDateSource,omitempty"`
+	TimeSampleExtensions []string          `json:"timeSampleExtensions,omitempty"`
+	TimeSourceFile       string            `json:"timeSourceFile,omitempty"`
+	WriteMetadataFile    bool              `json:"writeMetadataFile,omitempty"`
+	MetadataFilePath     string            `json:"metadataFilePath,omitempty"`
+	Branch               string            `json:"branch,omitempty"`
+	MaxCommitSize        int64             `json:"maxCommitSize,omitempty"`
+	WarnCommitSize       int64             `json:"warnCommitSize,omitempty"`
+	LFSPatterns          []string          `json:"lfsPatterns,omitempty"`
+	LFSThresholdBytes    int64             `json:"lfsThresholdBytes,omitempty"`
+	WriteGitignore       bool              `json:"writeGitignore,omitempty"`
+	NormalizeEOL         EOLMode           `json:"normalizeEOL,omitempty"`
+	WriteEOLAttributes   bool              `json:"writeEOLAttributes,omitempty"`
+	PreferVersionOrder   bool              `json:"preferVersionOrder,omitempty"`
+	SortMode             SortMode          `json:"sortMode,omitempty"`
+	SkipContentTypes     []string          `json:"skipContentTypes,omitempty"`
+	KeepAppleDouble      bool              `json:"keepAppleDouble,omitempty"`
+	IgnoreModeChanges    bool              `json:"ignoreModeChanges,omitempty"`
+}
+
+// SettingChange описывает параметр, заданный иначе, чем в прошлом запуске
+type SettingChange struct {
+	Field    string
+	Previous interface{}
+	Current  interface{}
+}
+
+// settingsOf снимает с config настройки, которые сохраняются в манифесте
+func settingsOf(config Config) *RunSettings {
+	settings := &RunSettings{}
+	src := reflect.ValueOf(config)
+	dst := reflect.ValueOf(settings).Elem()
+	for i := 0; i < dst.NumField(); i++ {
+		dst.Field(i).Set(src.FieldByName(dst.Type().Field(i).Name))
+	}
+	return settings
+}
+
+// LoadPreviousSettings читает настройки прошлого запуска из манифеста
+// репозитория targetDir. Если их нет, возвращается nil без ошибки
+func LoadPreviousSettings(targetDir string) (*RunSettings, error) {
+	manifest, err := readManifest(targetDir)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения манифеста миграции: %v", err)
+	}
+	return manifest.Settings, nil
+}
+
+// ApplyTo подставляет в config сохраненные значения вместо незаданных
+// (нулевых) полей. Поля, заданные иначе, чем в прошлом запуске, остаются
+// как есть и возвращаются списком изменений
+func (s *RunSettings) ApplyTo(config Config) (Config, []SettingChange) {
+	var changes []SettingChange
+	src := reflect.ValueOf(s).Elem()
+	dst := reflect.ValueOf(&config).Elem()
+	for i := 0; i < src.NumField(); i++ {
+		name := src.Type().Field(i).Name
+		previous := src.Field(i)
+		current := dst.FieldByName(name)
+		switch {
+		case previous.IsZero():
+		case current.IsZero():
+			current.Set(previous)
+		case !reflect.DeepEqual(previous.Interface(), current.Interface()):
+			changes = append(changes, SettingChange{Field: name, Previous: previous.Interface(), Current: current.Interface()})
+		}
+	}
+	return config, changes
+}
+
+// applyPreviousSettings дополняет настройки режима добавления значениями
+// прошлого запуска и предупреждает об измененных параметрах
+func applyPreviousSettings(config Config) (Config, error) {
+	if !config.Append || config.IgnorePreviousConfig || config.TargetDir == "" {
+		return config, nil
+	}
+	previous, err := LoadPreviousSettings(config.TargetDir)
+	if err != nil || previous == nil {
+		return config, err
+	}
+
+	config, changes := previous.ApplyTo(config)
+	for _, change := range changes {
+		config.logger().Printf("Предупреждение: параметр %s отличается от прошлого запуска (было %v, стало %v), история может получиться несогласованной",
+			change.Field, change.Previous, change.Current)
+	}
+	return config, nil
+}
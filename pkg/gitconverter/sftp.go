@@ -0,0 +1,619 @@
+package gitconverter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	osuser "os/user"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sftpScheme — схема адреса удаленного источника: sftp://пользователь@сервер:порт/путь
+const sftpScheme = "sftp"
+
+// Параметры SFTP по умолчанию
+const (
+	defaultSFTPConcurrency = 4
+	defaultSFTPRetries     = 3
+	defaultSFTPRetryDelay  = 2 * time.Second
+	sftpDialTimeout        = 30 * time.Second
+	sftpCopyBuffer         = 1 << 20
+	sftpDirPrefix          = "folder-to-git-sftp-"
+)
+
+// sftpPath возвращает путь к папке с версиями на сервере. Путь в адресе
+// отсчитывается от корня сервера, без пути поиск идет в домашней папке
+func sftpPath(u *url.URL) string {
+	if u.Path == "" {
+		return "."
+	}
+	return path.Clean(u.Path)
+}
+
+// sftpSource — источник версий на сервере SFTP. Запросы к серверу идут через
+// do: их число ограничено SFTPConcurrency, а при обрыве соединения запрос
+// повторяется после переподключения с нарастающей паузой
+type sftpSource struct {
+	config     Config
+	addr       string
+	ssh        *ssh.ClientConfig
+	slots      chan struct{}
+	retries    int
+	retryDelay time.Duration
+
+	mu     sync.Mutex
+	conn   *ssh.Client
+	client *sftp.Client
+}
+
+// dialSFTP подключается к серверу из адреса u. Подключение проверяется
+// сразу, чтобы ошибки входа и ключа сервера были видны до сканирования
+func dialSFTP(config Config, u *url.URL) (*sftpSource, error) {
+	if _, ok := u.User.Password(); ok {
+		return nil, fmt.Errorf("пароль в адресе источника не поддерживается: он попал бы в лог и манифест, используйте SSH-ключ")
+	}
+	port := u.Port()
+	if port == "" {
+		port = "22"
+	}
+	addr := net.JoinHostPort(u.Hostname(), port)
+	clientConfig, err := sftpClientConfig(config, u, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &sftpSource{
+		config:     config,
+		addr:       addr,
+		ssh:        clientConfig,
+		slots:      make(chan struct{}, sftpConcurrency(config)),
+		retries:    config.SFTPRetries,
+		retryDelay: config.SFTPRetryDelay,
+	}
+	if s.retries <= 0 {
+		s.retries = defaultSFTPRetries
+	}
+	if s.retryDelay <= 0 {
+		s.retryDelay = defaultSFTPRetryDelay
+	}
+
+	if err := s.do(func(*sftp.Client) error { return nil }); err != nil {
+		return nil, fmt.Errorf("ошибка подключения к %s: %v", s.addr, err)
+	}
+	config.logger().Printf("Подключение к %s по SFTP установлено", s.addr)
+	return s, nil
+}
+
+// sftpConcurrency возвращает наибольшее число одновременных запросов к серверу
+func sftpConcurrency(config Config) int {
+	if config.SFTPConcurrency > 0 {
+		return config.SFTPConcurrency
+	}
+	return defaultSFTPConcurrency
+}
+
+// sftpClientConfig собирает настройки SSH: пользователя из адреса (по
+// умолчанию текущего), способы входа и проверку ключа сервера addr
+func sftpClientConfig(config Config, u *url.URL, addr string) (*ssh.ClientConfig, error) {
+	user := u.User.Username()
+	if user == "" {
+		current, err := osuser.Current()
+		if err != nil {
+			return nil, fmt.Errorf("не указан пользователь SFTP: %v", err)
+		}
+		// В Windows имя содержит домен: DOMAIN\user
+		user = current.Username[strings.LastIndex(current.Username, `\`)+1:]
+	}
+
+	auth, err := sftpAuth(config)
+	if err != nil {
+		return nil, err
+	}
+	hostKey, algorithms, err := sftpHostKeyCheck(config, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &ssh.ClientConfig{
+		User:              user,
+		Auth:              auth,
+		HostKeyCallback:   hostKey,
+		HostKeyAlgorithms: algorithms,
+		Timeout:           sftpDialTimeout,
+	}, nil
+}
+
+// sftpAuth выбирает способы входа: ключ SFTPKeyPath или, если он не задан,
+// SSH-агент и стандартные ключи ~/.ssh/id_ed25519, id_ecdsa и id_rsa
+func sftpAuth(config Config) ([]ssh.AuthMethod, error) {
+	if config.SFTPKeyPath != "" {
+		signer, err := loadSSHKey(config.SFTPKeyPath, config.SFTPKeyPassphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, nil
+	}
+
+	var methods []ssh.AuthMethod
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+	var signers []ssh.Signer
+	if home, err := os.UserHomeDir(); err == nil {
+		for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+			path := filepath.Join(home, ".ssh", name)
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			signer, err := loadSSHKey(path, config.SFTPKeyPassphrase)
+			if err != nil {
+				config.logger().Printf("Предупреждение: %v", err)
+				continue
+			}
+			signers = append(signers, signer)
+		}
+	}
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("не найден SSH-ключ для подключения по SFTP: укажите его в SFTPKeyPath или запустите SSH-агент")
+	}
+	return methods, nil
+}
+
+// loadSSHKey читает закрытый SSH-ключ, зашифрованный ключ — с паролем passphrase
+func loadSSHKey(path, passphrase string) (ssh.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения SSH-ключа %s: %v", path, err)
+	}
+	signer, err := ssh.ParsePrivateKey(data)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		if passphrase == "" {
+			return nil, fmt.Errorf("SSH-ключ %s зашифрован, укажите пароль в SFTPKeyPassphrase", path)
+		}
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(data, []byte(passphrase))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения SSH-ключа %s: %v", path, err)
+	}
+	return signer, nil
+}
+
+// sftpHostKeyCheck возвращает проверку ключа сервера по файлу known_hosts
+// (SFTPKnownHosts, по умолчанию ~/.ssh/known_hosts) и типы ключей, записанных
+// для сервера: без них сервер может предложить ключ другого типа, и проверка
+// откажет, хотя сервер известен. SFTPIgnoreHostKey отключает проверку
+func sftpHostKeyCheck(config Config, addr string) (ssh.HostKeyCallback, []string, error) {
+	if config.SFTPIgnoreHostKey {
+		config.logger().Printf("Предупреждение: ключ сервера %s не проверяется, подключение не защищено от подмены сервера", addr)
+		return ssh.InsecureIgnoreHostKey(), nil, nil
+	}
+
+	file := config.SFTPKnownHosts
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil, fmt.Errorf("не найдена домашняя папка для known_hosts: %v", err)
+		}
+		file = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	check, err := knownhosts.New(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка чтения списка известных серверов %s: %v", file, err)
+	}
+
+	callback := func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := check(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) {
+			if len(keyErr.Want) == 0 {
+				return fmt.Errorf("сервер %s отсутствует в %s: добавьте его ключ, например командой ssh-keyscan, "+
+					"или подключитесь к нему один раз через ssh", hostname, file)
+			}
+			return fmt.Errorf("ключ сервера %s не совпадает с записью в %s: возможна подмена сервера", hostname, file)
+		}
+		return err
+	}
+	return callback, knownKeyTypes(check, addr), nil
+}
+
+// knownKeyTypes возвращает алгоритмы ключей, записанных в known_hosts для
+// сервера addr. Проверка заведомо чужого ключа перечисляет в ошибке все
+// известные ключи сервера
+func knownKeyTypes(check ssh.HostKeyCallback, addr string) []string {
+	err := check(addr, &net.TCPAddr{IP: net.IPv4zero, Port: 22}, noKey{})
+	var keyErr *knownhosts.KeyError
+	if !errors.As(err, &keyErr) {
+		return nil
+	}
+	var algorithms []string
+	for _, known := range keyErr.Want {
+		switch known.Key.Type() {
+		case ssh.KeyAlgoRSA:
+			algorithms = append(algorithms, ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA)
+		default:
+			algorithms = append(algorithms, known.Key.Type())
+		}
+	}
+	return algorithms
+}
+
+// noKey — ключ, которого нет ни в одном known_hosts
+type noKey struct{}
+
+func (noKey) Type() string                        { return "none" }
+func (noKey) Marshal() []byte                     { return []byte("none") }
+func (noKey) Verify([]byte, *ssh.Signature) error { return errors.New("none") }
+
+// connect возвращает текущее соединение с сервером или открывает новое
+func (s *sftpSource) connect() (*sftp.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client != nil {
+		return s.client, nil
+	}
+	conn, err := ssh.Dial("tcp", s.addr, s.ssh)
+	if err != nil {
+		return nil, err
+	}
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	s.conn, s.client = conn, client
+	return client, nil
+}
+
+// drop закрывает оборванное соединение client, чтобы следующий запрос
+// подключился заново. Соединение, уже замененное другим запросом, не трогается
+func (s *sftpSource) drop(client *sftp.Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if client == nil || s.client != client {
+		return
+	}
+	s.client.Close()
+	s.conn.Close()
+	s.client, s.conn = nil, nil
+}
+
+// do выполняет запрос fn, занимая один из SFTPConcurrency слотов. Сетевые
+// сбои повторяются SFTPRetries раз с удваивающейся паузой SFTPRetryDelay
+func (s *sftpSource) do(fn func(*sftp.Client) error) error {
+	s.slots <- struct{}{}
+	defer func() { <-s.slots }()
+
+	delay := s.retryDelay
+	for attempt := 1; ; attempt++ {
+		client, err := s.connect()
+		if err == nil {
+			if err = fn(client); err == nil {
+				return nil
+			}
+		}
+		if attempt > s.retries || !isRetryableSFTPError(err) {
+			return err
+		}
+		s.config.logger().Printf("Ошибка SFTP (попытка %d из %d): %v. Повтор через %s", attempt, s.retries+1, err, delay)
+		s.drop(client)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// isRetryableSFTPError отделяет сетевые сбои от ошибок, которые повтор не
+// исправит: отсутствующих файлов, прав доступа, отказа во входе
+func isRetryableSFTPError(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, sftp.ErrSSHFxConnectionLost) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.As(err, &netErr)
+}
+
+func (s *sftpSource) Stat(name string) (os.FileInfo, error) {
+	var info os.FileInfo
+	err := s.do(func(c *sftp.Client) (err error) {
+		info, err = c.Stat(name)
+		return err
+	})
+	return info, err
+}
+
+func (s *sftpSource) Glob(pattern string) ([]string, error) {
+	var matches []string
+	err := s.do(func(c *sftp.Client) (err error) {
+		matches, err = c.Glob(pattern)
+		return err
+	})
+	return matches, err
+}
+
+func (s *sftpSource) ProbeDir(name string) error {
+	_, err := s.readDir(name)
+	return err
+}
+
+func (s *sftpSource) Join(elem ...string) string { return path.Join(elem...) }
+
+func (s *sftpSource) Rel(base, target string) (string, error) {
+	rel, err := filepath.Rel(filepath.FromSlash(base), filepath.FromSlash(target))
+	return filepath.ToSlash(rel), err
+}
+
+func (s *sftpSource) Close() error {
+	s.mu.Lock()
+	client := s.client
+	s.mu.Unlock()
+	s.drop(client)
+	return nil
+}
+
+// readDir читает директорию, записи отсортированы по имени, как у os.ReadDir
+func (s *sftpSource) readDir(name string) ([]os.FileInfo, error) {
+	var entries []os.FileInfo
+	err := s.do(func(c *sftp.Client) (err error) {
+		entries, err = c.ReadDir(name)
+		return err
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, err
+}
+
+// WalkDir обходит дерево на сервере по правилам filepath.WalkDir: в
+// лексическом порядке, не раскрывая символические ссылки, с SkipDir и SkipAll
+func (s *sftpSource) WalkDir(root string, fn fs.WalkDirFunc) error {
+	var info os.FileInfo
+	err := s.do(func(c *sftp.Client) (err error) {
+		info, err = c.Lstat(root)
+		return err
+	})
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = s.walkDir(root, fs.FileInfoToDirEntry(info), fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (s *sftpSource) walkDir(name string, d fs.DirEntry, fn fs.WalkDirFunc) error {
+	if err := fn(name, d, nil); err != nil || !d.IsDir() {
+		if err == filepath.SkipDir && d.IsDir() {
+			err = nil
+		}
+		return err
+	}
+
+	entries, err := s.readDir(name)
+	if err != nil {
+		// Как и filepath.WalkDir, второй вызов сообщает об ошибке чтения
+		if err = fn(name, d, err); err != nil {
+			if err == filepath.SkipDir {
+				err = nil
+			}
+			return err
+		}
+	}
+	for _, info := range entries {
+		if err := s.walkDir(path.Join(name, info.Name()), fs.FileInfoToDirEntry(info), fn); err != nil {
+			if err == filepath.SkipDir {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// sftpFile — файл версии, который нужно загрузить
+type sftpFile struct {
+	remote string
+	local  string
+	info   os.FileInfo
+}
+
+// fetchFolder загружает папки версии с сервера SFTP во временную папку и
+// возвращает описание версии с локальными путями: дальше версия переносится
+// как обычная. На диске одновременно лежит только одна версия, cleanup
+// удаляет ее после коммита. Для локального источника папка возвращается как есть
+func fetchFolder(ctx context.Context, src sourceFS, config Config, folder FolderInfo) (FolderInfo, func(), error) {
+	remote, ok := src.(*sftpSource)
+	if !ok {
+		return folder, func() {}, nil
+	}
+
+	dir, err := makeTempDir(config, sftpDirPrefix)
+	if err != nil {
+		return folder, nil, fmt.Errorf("ошибка создания временной директории: %v", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	paths := folder.MergedPaths
+	if len(paths) == 0 {
+		paths = []string{folder.Path}
+	}
+	local := folder
+	local.MergedPaths = nil
+	started := time.Now()
+	var count int
+	var size int64
+	for i, remotePath := range paths {
+		// Части объединенной версии могут называться одинаково
+		localPath := filepath.Join(dir, strconv.Itoa(i), path.Base(remotePath))
+		n, bytes, err := remote.download(ctx, remotePath, localPath)
+		if err != nil {
+			cleanup()
+			return folder, nil, fmt.Errorf("ошибка загрузки %s: %v", remotePath, err)
+		}
+		count += n
+		size += bytes
+		if remotePath == folder.Path {
+			local.Path = localPath
+		}
+		if len(folder.MergedPaths) > 0 {
+			local.MergedPaths = append(local.MergedPaths, localPath)
+		}
+	}
+	config.logger().Printf("Версия %s загружена с %s: %d файлов, %s за %s", Printable(folder.Version), remote.addr,
+		count, FormatSize(size), time.Since(started).Round(time.Millisecond))
+	return local, cleanup, nil
+}
+
+// download загружает дерево root с сервера в локальную папку dst в
+// SFTPConcurrency потоков, сохраняя права и время изменения. Директории,
+// которые копирование все равно пропустит, не загружаются. Ссылки на файлы
+// загружаются как файлы, как их читает копирование из локальной папки
+func (s *sftpSource) download(ctx context.Context, root, dst string) (int, int64, error) {
+	rules, err := newIgnoreRules(s.config)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var files []sftpFile
+	dirTimes := make(map[string]time.Time)
+	err = s.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := s.Rel(root, name)
+		if err != nil {
+			return err
+		}
+		local := filepath.Join(dst, filepath.FromSlash(rel))
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			if name != root && rules.dir(d.Name()) {
+				return filepath.SkipDir
+			}
+			dirTimes[local] = info.ModTime()
+			return os.MkdirAll(local, 0755)
+
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := s.Stat(name)
+			if err != nil || !target.Mode().IsRegular() {
+				s.config.logger().Printf("Предупреждение: пропущена ссылка %s, она не указывает на файл", name)
+				return nil
+			}
+			info = target
+
+		case !d.Type().IsRegular():
+			return nil
+		}
+
+		// .gitignore нужен правилам RespectGitignore, даже если сам не копируется
+		if d.Name() != gitignoreFile {
+			if ignored, err := rules.file(d.Name()); err != nil || ignored {
+				return err
+			}
+		}
+		files = append(files, sftpFile{remote: name, local: local, info: info})
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs := make(chan sftpFile)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for w := 0; w < min(cap(s.slots), len(files)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range jobs {
+				err := s.do(func(c *sftp.Client) error { return getFile(ctx, c, file) })
+				if err != nil {
+					once.Do(func() {
+						firstErr = fmt.Errorf("%s: %v", file.remote, err)
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+	var size int64
+	for _, file := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- file
+		size += file.info.Size()
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return 0, 0, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	// Время директорий выставляется в конце: запись файлов его меняет
+	for dir, modTime := range dirTimes {
+		os.Chtimes(dir, modTime, modTime)
+	}
+	return len(files), size, nil
+}
+
+// getFile загружает один файл. Повтор после обрыва начинает файл заново
+func getFile(ctx context.Context, c *sftp.Client, file sftpFile) error {
+	in, err := c.Open(file.remote)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(file.local, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, file.info.Mode().Perm()|0200)
+	if err != nil {
+		return err
+	}
+	// Большой буфер позволяет клиенту SFTP запрашивать части файла
+	// параллельно. Обертка скрывает ReadFrom файла, который буфер не использует
+	writer := struct{ io.Writer }{out}
+	if _, err := io.CopyBuffer(writer, contextReader{ctx: ctx, r: in}, make([]byte, sftpCopyBuffer)); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(file.local, file.info.ModTime(), file.info.ModTime())
+}
@@ -0,0 +1,355 @@
+package gitconverter
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sftpServer — сервер SFTP в процессе теста, отдающий локальную файловую систему
+type sftpServer struct {
+	addr        string
+	keyPath     string // Закрытый ключ клиента
+	knownHosts  string // known_hosts с ключом сервера
+	connections atomic.Int32
+}
+
+// startSFTPServer запускает сервер, пускающий только клиента с ключом keyPath
+func startSFTPServer(t *testing.T) *sftpServer {
+	t.Helper()
+	dir := t.TempDir()
+
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientPub, clientKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(clientKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(dir, "id_ed25519")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	authorized, err := ssh.NewPublicKey(clientPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("ключ %s не разрешен", meta.User())
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &sftpServer{addr: listener.Addr().String(), keyPath: keyPath, knownHosts: filepath.Join(dir, "known_hosts")}
+	line := knownhosts.Line([]string{knownhosts.Normalize(server.addr)}, hostSigner.PublicKey())
+	if err := os.WriteFile(server.knownHosts, []byte(line+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.connections.Add(1)
+			go serveSFTP(conn, config)
+		}
+	}()
+	return server
+}
+
+// serveSFTP обслуживает одно SSH-соединение с подсистемой sftp
+func serveSFTP(conn net.Conn, config *ssh.ServerConfig) {
+	defer conn.Close()
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+				req.Reply(ok, nil)
+				if !ok {
+					continue
+				}
+				server, err := sftp.NewServer(channel)
+				if err != nil {
+					channel.Close()
+					return
+				}
+				server.Serve()
+				server.Close()
+				return
+			}
+		}()
+	}
+}
+
+// url возвращает адрес источника dir на сервере
+func (s *sftpServer) url(dir string) string {
+	return "sftp://test@" + s.addr + filepath.ToSlash(dir)
+}
+
+// config возвращает настройки переноса из dir на сервере в dst
+func (s *sftpServer) config(dir, dst string) Config {
+	config := testConfig(s.url(dir), dst)
+	config.SFTPKeyPath = s.keyPath
+	config.SFTPKnownHosts = s.knownHosts
+	config.SFTPRetryDelay = 1
+	return config
+}
+
+func skipSFTPOnWindows(t *testing.T) {
+	// Путь источника на сервере совпадает с локальным, а C:\ в адресе не передать
+	if runtime.GOOS == "windows" {
+		t.Skip("тестовый сервер отдает пути Unix")
+	}
+}
+
+func TestMigrateFromSFTP(t *testing.T) {
+	skipSFTPOnWindows(t)
+	server := startSFTPServer(t)
+	src := versionSource(t,
+		map[string]string{"a.txt": "1\n", "run.sh": script, "node_modules/x.js": "x"},
+		map[string]string{"a.txt": "2\n", "run.sh": script, "sub/b.txt": "b\n"},
+	)
+	if err := os.Chmod(filepath.Join(src, "v2", "run.sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("a.txt", filepath.Join(src, "v2", "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+	setTreeTime(t, filepath.Join(src, "v2"), testEpoch.AddDate(0, 0, 1))
+
+	tmp := t.TempDir()
+	dst := t.TempDir()
+	config := server.config(src, dst)
+	config.TempDir = tmp
+	config.SFTPConcurrency = 2
+	mustRun(t, config)
+
+	commits := history(t, dst)
+	if len(commits) != 2 {
+		t.Fatalf("коммитов %d, ожидалось 2", len(commits))
+	}
+	for i, commit := range commits {
+		// Время версии определено по времени файлов на сервере
+		if want := testEpoch.AddDate(0, 0, i); !commit.Author.When.Equal(want) {
+			t.Errorf("версия %d: дата %v, ожидалась %v", i+1, commit.Author.When, want)
+		}
+	}
+	v1, v2 := treeFiles(t, commits[0]), treeFiles(t, commits[1])
+	if _, ok := v1["node_modules/x.js"]; ok {
+		t.Error("пропускаемая директория перенесена")
+	}
+	if v2["a.txt"].Content != "2\n" || v2["sub/b.txt"].Content != "b\n" || v2["link.txt"].Content != "2\n" {
+		t.Errorf("содержимое второй версии: %v", v2)
+	}
+	if v1["run.sh"].Mode != filemode.Regular.String() || v2["run.sh"].Mode != filemode.Executable.String() {
+		t.Errorf("режимы run.sh: %s, %s", v1["run.sh"].Mode, v2["run.sh"].Mode)
+	}
+
+	// Загруженные версии удаляются после своих коммитов
+	if left := listTree(t, tmp); len(left) != 0 {
+		t.Errorf("во временной папке остались файлы: %v", left)
+	}
+}
+
+func TestLayoutFromSFTP(t *testing.T) {
+	skipSFTPOnWindows(t)
+	server := startSFTPServer(t)
+	src := versionSource(t,
+		map[string]string{"a.txt": "1\n", "node_modules/x.js": "x"},
+		map[string]string{"a.txt": "2\n", "sub/b.txt": "b\n"},
+	)
+
+	tmp := t.TempDir()
+	dst := t.TempDir()
+	config := server.config(src, dst)
+	config.TempDir = tmp
+	config.NoGit = true
+	result := mustRun(t, config)
+
+	if len(result.Layout) != 2 {
+		t.Fatalf("разложено папок %d, ожидалось 2", len(result.Layout))
+	}
+	if got := listTree(t, result.Layout[0].Target); !reflect.DeepEqual(got, []string{"a.txt"}) {
+		t.Errorf("в папке версии 1 файлы %v", got)
+	}
+	if got := listTree(t, result.Layout[1].Target); !reflect.DeepEqual(got, []string{"a.txt", "sub/b.txt"}) {
+		t.Errorf("в папке версии 2 файлы %v", got)
+	}
+	if left := listTree(t, tmp); len(left) != 0 {
+		t.Errorf("во временной папке остались файлы: %v", left)
+	}
+}
+
+func TestSFTPRecursiveScan(t *testing.T) {
+	skipSFTPOnWindows(t)
+	server := startSFTPServer(t)
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"2020/v1/a.txt":      "1",
+		"2021/v2/a.txt":      "2",
+		"skip/.git/v9/a.txt": "9",
+	})
+	setTreeTime(t, src, testEpoch)
+
+	config := server.config(src, "")
+	config.Recursive = true
+	config.TimestampStrategy = TimestampFolderMtime
+	scan, err := ScanVersionedFolders(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions []string
+	for _, folder := range scan.Folders {
+		versions = append(versions, folder.Version)
+		if folder.CreationTime != testEpoch.Unix() {
+			t.Errorf("версия %s: время %d, ожидалось время папки на сервере", folder.Version, folder.CreationTime)
+		}
+	}
+	if strings.Join(versions, ",") != "1,2" {
+		t.Errorf("найдены версии %v, ожидались 1 и 2", versions)
+	}
+}
+
+func TestSFTPRejectsUnknownHost(t *testing.T) {
+	skipSFTPOnWindows(t)
+	server := startSFTPServer(t)
+	config := server.config(t.TempDir(), "")
+
+	config.SFTPKnownHosts = filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(config.SFTPKnownHosts, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ScanVersionedFolders(config); err == nil || !strings.Contains(err.Error(), "отсутствует") {
+		t.Errorf("подключение к неизвестному серверу: %v", err)
+	}
+
+	// Чужой ключ сервера — возможная подмена
+	_, other, _ := ed25519.GenerateKey(rand.Reader)
+	signer, _ := ssh.NewSignerFromKey(other)
+	line := knownhosts.Line([]string{knownhosts.Normalize(server.addr)}, signer.PublicKey())
+	if err := os.WriteFile(config.SFTPKnownHosts, []byte(line+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ScanVersionedFolders(config); err == nil || !strings.Contains(err.Error(), "подмена") {
+		t.Errorf("подключение к серверу с чужим ключом: %v", err)
+	}
+}
+
+func TestSFTPRetriesAfterDisconnect(t *testing.T) {
+	skipSFTPOnWindows(t)
+	server := startSFTPServer(t)
+	config := server.config(t.TempDir(), "")
+	u, _ := url.Parse(config.SourceDir)
+	src, err := dialSFTP(config, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	calls := 0
+	err = src.do(func(*sftp.Client) error {
+		calls++
+		if calls == 1 {
+			return sftp.ErrSSHFxConnectionLost
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("запрос после обрыва: %v, попыток %d", err, calls)
+	}
+	if got := server.connections.Load(); got != 2 {
+		t.Errorf("подключений %d, ожидалось переподключение", got)
+	}
+	if !config.Logger.(*testLogger).contains("попытка 1") {
+		t.Error("о повторе не сообщено")
+	}
+
+	// Отсутствующий файл повтором не исправить
+	calls = 0
+	err = src.do(func(c *sftp.Client) error {
+		calls++
+		_, err := c.Stat("/nonexistent/file")
+		return err
+	})
+	if !errors.Is(err, os.ErrNotExist) || calls != 1 {
+		t.Errorf("отсутствующий файл: %v, попыток %d", err, calls)
+	}
+}
+
+func TestRemoteSourceValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		ok     bool
+	}{
+		{"локальный путь", Config{SourceDir: "/srv/versions"}, true},
+		{"диск Windows", Config{SourceDir: `C:\versions`}, true},
+		{"sftp", Config{SourceDir: "sftp://user@host/srv"}, true},
+		{"ssh", Config{SourceDir: "ssh://user@host/srv"}, false},
+		{"dry-run", Config{SourceDir: "sftp://host/srv", DryRun: true}, false},
+		{"без Git", Config{SourceDir: "sftp://host/srv", NoGit: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.config)
+			if tt.ok && err != nil {
+				t.Errorf("настройки отклонены: %v", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("ошибка %v, ожидалась ErrInvalidConfig", err)
+			}
+		})
+	}
+}
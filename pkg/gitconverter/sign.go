@@ -0,0 +1,78 @@
+package gitconverter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// loadSignKey читает закрытый ключ OpenPGP из файла SignKeyPath и
+// расшифровывает его паролем SignKeyPassphrase. Без SignKeyPath возвращает
+// nil: коммиты не подписываются
+func loadSignKey(config Config) (*openpgp.Entity, error) {
+	if config.SignKeyPath == "" {
+		return nil, nil
+	}
+	f, err := os.Open(config.SignKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия ключа подписи: %v", err)
+	}
+	defer f.Close()
+
+	entities, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения ключа подписи %s: %v", config.SignKeyPath, err)
+	}
+	var key *openpgp.Entity
+	for _, entity := range entities {
+		if entity.PrivateKey != nil {
+			key = entity
+			break
+		}
+	}
+	if key == nil {
+		return nil, fmt.Errorf("в файле %s нет закрытого ключа OpenPGP", config.SignKeyPath)
+	}
+
+	if key.PrivateKey.Encrypted {
+		if config.SignKeyPassphrase == "" {
+			return nil, fmt.Errorf("ключ подписи %s защищен паролем, задайте SignKeyPassphrase", config.SignKeyPath)
+		}
+		if err := key.DecryptPrivateKeys([]byte(config.SignKeyPassphrase)); err != nil {
+			return nil, fmt.Errorf("не удалось расшифровать ключ подписи %s: %v", config.SignKeyPath, err)
+		}
+	}
+	if _, ok := key.SigningKey(time.Now()); !ok {
+		return nil, fmt.Errorf("ключ %s не подходит для подписи (истек, отозван или без права подписи)", config.SignKeyPath)
+	}
+	return key, nil
+}
+
+// signCommit заново подписывает измененный коммит ключом key. Без ключа
+// прежняя подпись просто убирается: она относится к старому содержимому
+func signCommit(commit *object.Commit, key *openpgp.Entity) error {
+	commit.PGPSignature = ""
+	if key == nil {
+		return nil
+	}
+
+	obj := &plumbing.MemoryObject{}
+	if err := commit.EncodeWithoutSignature(obj); err != nil {
+		return err
+	}
+	r, err := obj.Reader()
+	if err != nil {
+		return err
+	}
+	var signature bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&signature, key, r, nil); err != nil {
+		return fmt.Errorf("ошибка подписи коммита: %v", err)
+	}
+	commit.PGPSignature = signature.String()
+	return nil
+}
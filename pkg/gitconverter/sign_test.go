@@ -0,0 +1,156 @@
+package gitconverter
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/go-git/go-git/v5"
+)
+
+// testSignKey создает одноразовый ключ OpenPGP и записывает его закрытую
+// часть в файл, зашифровав паролем passphrase, если он задан. Возвращает
+// путь к файлу и открытый ключ в ASCII armor для проверки подписей
+func testSignKey(t *testing.T, passphrase string) (string, string) {
+	t.Helper()
+	config := &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA}
+	entity, err := openpgp.NewEntity("Test", "", "test@example.com", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var public bytes.Buffer
+	w, err := armor.Encode(&public, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	if passphrase != "" {
+		if err := entity.EncryptPrivateKeys([]byte(passphrase), config); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var private bytes.Buffer
+	w, err = armor.Encode(&private, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.SerializePrivateWithoutSigning(w, config); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	path := filepath.Join(t.TempDir(), "key.asc")
+	if err := os.WriteFile(path, private.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path, public.String()
+}
+
+func TestSignedCommitsAndTags(t *testing.T) {
+	keyPath, public := testSignKey(t, "secret")
+	src := versionSource(t, map[string]string{"a.txt": "1\n"}, map[string]string{"a.txt": "2\n"})
+
+	tests := []struct {
+		name     string
+		encoding string
+	}{
+		{"UTF-8", ""},
+		// Перекодированный коммит подписывается заново
+		{"windows-1251", "windows-1251"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := t.TempDir()
+			config := testConfig(src, dst)
+			config.SignKeyPath = keyPath
+			config.SignKeyPassphrase = "secret"
+			config.AnnotatedTags = true
+			config.MessageEncoding = tt.encoding
+			mustRun(t, config)
+
+			commits := history(t, dst)
+			if len(commits) != 2 {
+				t.Fatalf("коммитов %d, ожидалось 2", len(commits))
+			}
+			for _, commit := range commits {
+				if commit.PGPSignature == "" {
+					t.Fatalf("коммит %s не подписан", commit.Hash)
+				}
+				if _, err := commit.Verify(public); err != nil {
+					t.Errorf("подпись коммита %s не проверяется: %v", commit.Hash, err)
+				}
+				if tt.encoding != "" && commit.Encoding != "windows-1251" {
+					t.Errorf("кодировка коммита %q", commit.Encoding)
+				}
+			}
+
+			repo, err := git.PlainOpen(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := tagNames(t, dst)
+			if len(names) != 2 {
+				t.Fatalf("теги %v, ожидалось 2", names)
+			}
+			for _, name := range names {
+				tag := annotatedTag(t, repo, name)
+				if _, err := tag.Verify(public); err != nil {
+					t.Errorf("подпись тега %s не проверяется: %v", name, err)
+				}
+			}
+		})
+	}
+
+	// Подпись чужим ключом не проходит проверку
+	_, other := testSignKey(t, "")
+	dst := t.TempDir()
+	config := testConfig(src, dst)
+	config.SignKeyPath = keyPath
+	config.SignKeyPassphrase = "secret"
+	mustRun(t, config)
+	if _, err := history(t, dst)[0].Verify(other); err == nil {
+		t.Error("подпись прошла проверку чужим ключом")
+	}
+}
+
+func TestSignKeyErrors(t *testing.T) {
+	encrypted, public := testSignKey(t, "secret")
+	publicOnly := filepath.Join(t.TempDir(), "public.asc")
+	if err := os.WriteFile(publicOnly, []byte(public), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		passphrase string
+		want       string
+	}{
+		{"без пароля", encrypted, "", "защищен паролем"},
+		{"неверный пароль", encrypted, "wrong", "не удалось расшифровать"},
+		{"только открытый ключ", publicOnly, "", "нет закрытого ключа"},
+		{"нет файла", filepath.Join(t.TempDir(), "missing.asc"), "", "ошибка открытия"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig(t.TempDir(), "")
+			config.SignKeyPath = tt.path
+			config.SignKeyPassphrase = tt.passphrase
+			_, err := New(config)
+			if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ошибка %v, ожидалась ErrInvalidConfig с %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package gitconverter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// MigrateSingle переносит одну папку folderPath следующим коммитом в
+// репозиторий TargetDir. Шаблон имен и ExtractPattern не используются:
+// версия задается явно, а если version пуста, версией считается имя папки.
+//
+// Если репозиторий уже есть, папка добавляется как в режиме добавления:
+// подставляются настройки прошлого запуска, версия, которая уже есть в
+// истории, не переносится повторно, а рабочая директория не должна содержать
+// незакоммиченных изменений. Содержимое коммита совпадает с папкой: файлы,
+// которых в ней нет, удаляются из репозитория
+func MigrateSingle(config Config, folderPath, version string) (*MigrationResult, error) {
+	return MigrateSingleContext(context.Background(), config, folderPath, version)
+}
+
+// MigrateSingleContext выполняет MigrateSingle, которую можно прервать через ctx
+func MigrateSingleContext(ctx context.Context, config Config, folderPath, version string) (*MigrationResult, error) {
+	if config.TargetDir == "" {
+		return &MigrationResult{}, fmt.Errorf("%w: не указана директория репозитория", ErrInvalidConfig)
+	}
+	info, err := os.Stat(folderPath)
+	if err != nil {
+		return &MigrationResult{}, fmt.Errorf("ошибка чтения папки версии: %v", err)
+	}
+	if !info.IsDir() {
+		return &MigrationResult{}, fmt.Errorf("%s не является папкой", folderPath)
+	}
+	if version == "" {
+		version = filepath.Base(folderPath)
+	}
+
+	config.Append = false
+	if _, err := os.Stat(filepath.Join(config.TargetDir, ".git")); err == nil || isBareRepository(config.TargetDir) {
+		config.Append = true
+	}
+	config, err = applyPreviousSettings(config)
+	if err != nil {
+		return &MigrationResult{}, err
+	}
+	if config.SourceDir == "" {
+		config.SourceDir = filepath.Dir(folderPath)
+	}
+	// Поверх прошлой версии копируются только отличия, иначе измененные и
+	// удаленные файлы в режиме добавления не попадут в коммит
+	if !config.KeepVersionDir {
+		config.Incremental = true
+	}
+
+	if config.Append {
+		repo, err := openRepository(config.TargetDir)
+		if err != nil {
+			return &MigrationResult{}, fmt.Errorf("ошибка открытия репозитория: %v", err)
+		}
+		existing, err := repoVersions(repo)
+		if err != nil {
+			return &MigrationResult{}, err
+		}
+		if existing[version] {
+			return &MigrationResult{}, fmt.Errorf("версия %s уже есть в репозитории %s", Printable(version), config.TargetDir)
+		}
+	}
+
+	times, err := loadVersionTimes(config)
+	if err != nil {
+		return &MigrationResult{}, err
+	}
+	folder := FolderInfo{Path: folderPath, Version: version}
+	times.apply(localSource{}, config, &folder, info)
+	return migrate(ctx, config, []FolderInfo{folder})
+}
@@ -0,0 +1,119 @@
+package gitconverter
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// sourceFS — файловая система, в которой лежат папки версий: локальная или
+// удаленная (SFTP). Разделитель путей у них разный, поэтому пути внутри
+// источника собираются только через Join и Rel
+type sourceFS interface {
+	Stat(name string) (os.FileInfo, error)
+	Glob(pattern string) ([]string, error)
+	WalkDir(root string, fn fs.WalkDirFunc) error
+	// ProbeDir проверяет, что директорию можно прочитать
+	ProbeDir(name string) error
+	Join(elem ...string) string
+	Rel(base, target string) (string, error)
+	Close() error
+}
+
+// localSource — источник на локальной файловой системе
+type localSource struct{}
+
+func (localSource) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (localSource) Glob(pattern string) ([]string, error)        { return filepath.Glob(pattern) }
+func (localSource) WalkDir(root string, fn fs.WalkDirFunc) error { return filepath.WalkDir(root, fn) }
+func (localSource) Join(elem ...string) string                   { return filepath.Join(elem...) }
+func (localSource) Rel(base, target string) (string, error)      { return filepath.Rel(base, target) }
+func (localSource) Close() error                                 { return nil }
+
+func (localSource) ProbeDir(name string) error {
+	dir, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	return dir.Close()
+}
+
+// sourceURL разбирает адрес удаленного источника. Для локального пути
+// возвращает nil: однобуквенная схема — это диск Windows (C:\versions)
+func sourceURL(dir string) *url.URL {
+	u, err := url.Parse(dir)
+	if err != nil || len(u.Scheme) < 2 || u.Host == "" {
+		return nil
+	}
+	return u
+}
+
+// isRemoteSource проверяет, что SourceDir — адрес удаленного источника
+func isRemoteSource(dir string) bool {
+	return sourceURL(dir) != nil
+}
+
+// validateSource проверяет адрес удаленного источника и режимы, которые с
+// ним несовместимы: тестовый режим читает файлы версий напрямую, а удаленная
+// версия загружается только на время своего коммита или раскладки
+func validateSource(config Config) error {
+	u := sourceURL(config.SourceDir)
+	if u == nil {
+		return nil
+	}
+	if u.Scheme != sftpScheme {
+		return fmt.Errorf("удаленные источники %s:// не поддерживаются, укажите адрес sftp://пользователь@сервер/путь", u.Scheme)
+	}
+	if config.DryRun {
+		return fmt.Errorf("тестовый режим (dry-run) не поддерживается для источника %s://", u.Scheme)
+	}
+	return nil
+}
+
+// openSource открывает источник версий SourceDir: локальную папку или
+// сервер SFTP. Источник закрывает вызывающий код
+func openSource(config Config) (sourceFS, error) {
+	if err := validateSource(config); err != nil {
+		return nil, err
+	}
+	if u := sourceURL(config.SourceDir); u != nil {
+		return dialSFTP(config, u)
+	}
+	return localSource{}, nil
+}
+
+// sourceRoot возвращает корень поиска версий внутри источника: путь из
+// адреса для удаленного источника или сам SourceDir
+func sourceRoot(config Config) string {
+	if u := sourceURL(config.SourceDir); u != nil {
+		return sftpPath(u)
+	}
+	return config.SourceDir
+}
+
+// StageVanished — этап FolderFailure для папки версии, которая исчезла между
+// сканированием и миграцией
+const StageVanished = "источник исчез"
+
+// checkSourceFolder перед переносом проверяет, что папки версии остались на
+// месте: между сканированием и миграцией (наблюдение за папкой, перенос из
+// окна программы после предпросмотра) их могли удалить или переименовать.
+// Прочие ошибки доступа здесь не проверяются — о них сообщит копирование
+func checkSourceFolder(src sourceFS, folder FolderInfo) error {
+	paths := folder.MergedPaths
+	if len(paths) == 0 {
+		paths = []string{folder.Path}
+	}
+	for _, path := range paths {
+		info, err := src.Stat(path)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("папка %s удалена или переименована после сканирования", path)
+		}
+		if err == nil && !info.IsDir() {
+			return fmt.Errorf("%s больше не является папкой", path)
+		}
+	}
+	return nil
+}
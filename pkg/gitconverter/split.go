@@ -0,0 +1,101 @@
+package gitconverter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// splitCommitParts разбивает файлы версии на части, суммарный размер каждой
+// из которых не превышает limit. Файлы по возможности группируются по
+// директории верхнего уровня; группа больше лимита делится по файлам, а файл
+// больше лимита попадает в отдельную часть. Если все файлы укладываются в
+// лимит, возвращается одна часть
+func splitCommitParts(root string, paths []string, limit int64) ([][]string, error) {
+	sizes := make(map[string]int64, len(paths))
+	groups := make(map[string][]string)
+	groupSizes := make(map[string]int64)
+	var total int64
+
+	for _, rel := range paths {
+		info, err := os.Lstat(filepath.Join(root, rel))
+		if err != nil {
+			return nil, err
+		}
+		sizes[rel] = info.Size()
+		total += info.Size()
+
+		top := topLevelDir(rel)
+		groups[top] = append(groups[top], rel)
+		groupSizes[top] += info.Size()
+	}
+
+	if limit <= 0 || total <= limit {
+		return [][]string{paths}, nil
+	}
+
+	tops := make([]string, 0, len(groups))
+	for top := range groups {
+		tops = append(tops, top)
+	}
+	sort.Strings(tops)
+
+	var parts [][]string
+	var current []string
+	var currentSize int64
+	flush := func() {
+		if len(current) > 0 {
+			parts = append(parts, current)
+			current, currentSize = nil, 0
+		}
+	}
+	add := func(files []string, size int64) {
+		if currentSize+size > limit {
+			flush()
+		}
+		current = append(current, files...)
+		currentSize += size
+	}
+
+	for _, top := range tops {
+		files := groups[top]
+		if groupSizes[top] <= limit {
+			add(files, groupSizes[top])
+			continue
+		}
+		sort.Strings(files)
+		for _, rel := range files {
+			add([]string{rel}, sizes[rel])
+		}
+	}
+	flush()
+
+	return parts, nil
+}
+
+// topLevelDir возвращает первый компонент пути или "" для файлов в корне
+func topLevelDir(rel string) string {
+	rel = filepath.ToSlash(rel)
+	if i := strings.IndexByte(rel, '/'); i >= 0 {
+		return rel[:i]
+	}
+	return ""
+}
+
+// partMessage формирует сообщение промежуточной части версии. Заголовок
+// намеренно отличается от "Version X:", чтобы незавершенная версия не
+// считалась перенесенной в режиме добавления
+func partMessage(config Config, folder FolderInfo, folderName, commitMsg string, part, total int) string {
+	if config.MessageTemplate == "" {
+		return fmt.Sprintf("Version %s (part %d/%d): %s", Printable(folder.Version), part, total, Printable(folderName))
+	}
+
+	subject, body, _ := strings.Cut(commitMsg, "\n")
+	subject = fmt.Sprintf("%s (part %d/%d)", subject, part, total)
+	if body == "" {
+		return subject
+	}
+	return subject + "\n" + body
+}
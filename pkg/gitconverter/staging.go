@@ -0,0 +1,251 @@
+package gitconverter
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+)
+
+// indexStager добавляет файлы в индекс пачкой. worktree.Add на каждый файл
+// заново считает статус всей рабочей директории и перезаписывает индекс,
+// что на сотнях тысяч файлов дает квадратичное время. Здесь индекс читается
+// один раз, блобы пишутся напрямую в хранилище, а индекс сохраняется в flush.
+// Права файлов берутся из modes, если они там есть, иначе с диска
+type indexStager struct {
+	repo    *git.Repository
+	root    string
+	modes   fileModes
+	idx     *index.Index
+	entries map[string]*index.Entry
+	removed map[string]bool
+
+	// Источники файлов и кэш их хешей: неизменившийся файл, блоб которого
+	// уже есть в хранилище, не читается повторно (см. reuseFrom)
+	hashes  *hashCache
+	sources map[string]string
+}
+
+// newIndexStager загружает индекс репозитория для пакетного обновления
+func newIndexStager(repo *git.Repository, root string, modes fileModes) (*indexStager, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]*index.Entry, len(idx.Entries))
+	for _, e := range idx.Entries {
+		entries[e.Name] = e
+	}
+
+	return &indexStager{
+		repo:    repo,
+		root:    root,
+		modes:   modes,
+		idx:     idx,
+		entries: entries,
+		removed: make(map[string]bool),
+	}, nil
+}
+
+// add записывает содержимое файла как блоб и обновляет его запись в индексе
+func (s *indexStager) add(rel string) error {
+	path := filepath.Join(s.root, rel)
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	name := filepath.ToSlash(rel)
+	hash, ok := s.reusedBlob(name, info)
+	if !ok {
+		if hash, err = s.writeBlob(path, info); err != nil {
+			return err
+		}
+		s.rememberSource(name, info, hash)
+	}
+
+	osMode := info.Mode()
+	if srcMode, ok := s.modes[name]; ok && osMode.IsRegular() {
+		osMode = srcMode
+	}
+	mode, err := filemode.NewFromOSFileMode(osMode)
+	if err != nil {
+		return err
+	}
+
+	e, ok := s.entries[name]
+	if !ok {
+		e = s.idx.Add(name)
+		s.entries[name] = e
+	}
+	delete(s.removed, name)
+
+	e.Hash = hash
+	e.Mode = mode
+	e.ModifiedAt = info.ModTime()
+	e.Size = uint32(info.Size())
+	return nil
+}
+
+// remove исключает файл из индекса; файл на диске не трогается.
+// Возвращает false, если файл не отслеживался
+func (s *indexStager) remove(rel string) bool {
+	name := filepath.ToSlash(rel)
+	if _, ok := s.entries[name]; !ok {
+		return false
+	}
+	delete(s.entries, name)
+	s.removed[name] = true
+	return true
+}
+
+// removeMissing исключает из индекса файлы, которых больше нет в рабочей
+// директории, и возвращает их пути. Файлы конвертера (managed) не трогаются
+func (s *indexStager) removeMissing(managed toolPaths) []string {
+	var missing []string
+	for _, e := range s.idx.Entries {
+		if s.removed[e.Name] || managed.has(e.Name) {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(s.root, filepath.FromSlash(e.Name))); os.IsNotExist(err) {
+			missing = append(missing, e.Name)
+		}
+	}
+	for _, name := range missing {
+		s.remove(name)
+	}
+	return missing
+}
+
+// flush сохраняет обновленный индекс
+func (s *indexStager) flush() error {
+	if len(s.removed) > 0 {
+		kept := s.idx.Entries[:0]
+		for _, e := range s.idx.Entries {
+			if !s.removed[e.Name] {
+				kept = append(kept, e)
+			}
+		}
+		s.idx.Entries = kept
+		s.removed = make(map[string]bool)
+	}
+	return s.repo.Storer.SetIndex(s.idx)
+}
+
+// reuseFrom разрешает брать хеши файлов из кэша hashes по их источникам:
+// sources сопоставляет путь в репозитории (через "/") и файл папки версии,
+// копией которого он является
+func (s *indexStager) reuseFrom(hashes *hashCache, sources map[string]string) {
+	s.hashes = hashes
+	s.sources = sources
+}
+
+// reusedBlob возвращает хеш файла name, не читая его, если кэш хешей знает
+// блоб его источника (см. hashCache.reusableBlob), копия совпадает с
+// источником по размеру, а блоб уже есть в хранилище
+func (s *indexStager) reusedBlob(name string, info os.FileInfo) (plumbing.Hash, bool) {
+	src, ok := s.sources[name]
+	if !ok || !info.Mode().IsRegular() {
+		return plumbing.ZeroHash, false
+	}
+	srcInfo, err := os.Stat(src)
+	if err != nil || srcInfo.Size() != info.Size() {
+		return plumbing.ZeroHash, false
+	}
+	hash, ok := s.hashes.reusableBlob(src, name, srcInfo)
+	if !ok || s.repo.Storer.HasEncodedObject(hash) != nil {
+		return plumbing.ZeroHash, false
+	}
+	// Следующая версия найдет блоб и по этому источнику
+	s.hashes.rememberBlob(src, name, srcInfo, hash)
+	return hash, true
+}
+
+// rememberSource записывает в кэш хеш источника файла name, только что
+// сохраненного как блоб. Источник, измененный после копирования, не
+// записывается: его содержимое уже может отличаться от копии
+func (s *indexStager) rememberSource(name string, info os.FileInfo, hash plumbing.Hash) {
+	src, ok := s.sources[name]
+	if !ok || !info.Mode().IsRegular() {
+		return
+	}
+	srcInfo, err := os.Stat(src)
+	if err != nil || srcInfo.Size() != info.Size() || srcInfo.ModTime().After(info.ModTime()) {
+		return
+	}
+	s.hashes.rememberBlob(src, name, srcInfo, hash)
+}
+
+// versionSources сопоставляет пути файлов папки версии src в репозитории
+// (через "/", под префиксом prefix) с самими файлами. Файлы конвертера и
+// файлы Git LFS не включаются: в репозитории их содержимое не совпадает с
+// источником
+func versionSources(src, prefix string, names *nameDecoder, filter *contentFilter, managed toolPaths, lfs *lfsRules) (map[string]string, error) {
+	files, err := listFiles(src, false, filter)
+	if err != nil {
+		return nil, err
+	}
+	sources := make(map[string]string, len(files))
+	for rel := range files {
+		name := filepath.ToSlash(filepath.Join(prefix, names.repoPath(rel)))
+		path := filepath.Join(src, rel)
+		if managed.has(name) {
+			continue
+		}
+		if lfs != nil {
+			if info, err := os.Lstat(path); err != nil || lfs.tracks(name, info.Size()) {
+				continue
+			}
+		}
+		sources[name] = path
+	}
+	return sources, nil
+}
+
+// writeBlob сохраняет содержимое файла (или цель символической ссылки) как блоб
+func (s *indexStager) writeBlob(path string, info os.FileInfo) (plumbing.Hash, error) {
+	obj := s.repo.Storer.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+
+	w, err := obj.Writer()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			w.Close()
+			return plumbing.ZeroHash, err
+		}
+		obj.SetSize(int64(len(target)))
+		_, err = io.WriteString(w, target)
+		if err != nil {
+			w.Close()
+			return plumbing.ZeroHash, err
+		}
+	} else {
+		obj.SetSize(info.Size())
+		f, err := os.Open(path)
+		if err != nil {
+			w.Close()
+			return plumbing.ZeroHash, err
+		}
+		_, err = io.Copy(w, f)
+		f.Close()
+		if err != nil {
+			w.Close()
+			return plumbing.ZeroHash, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return s.repo.Storer.SetEncodedObject(obj)
+}
@@ -0,0 +1,151 @@
+package gitconverter
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// FolderSummary содержит сводку по найденным папкам
+type FolderSummary struct {
+	Folders   int
+	Size      int64
+	FileCount int
+}
+
+// EnrichFolders подсчитывает размер и количество файлов каждой папки так же,
+// как их отберет копирование по настройкам config: со списками
+// игнорирования, .gitignore источника и пропуском по содержимому.
+// onProgress, если задан, вызывается после каждой папки. При отмене ctx
+// возвращает ctx.Err(), уже посчитанные папки остаются заполненными
+func EnrichFolders(ctx context.Context, config Config, folders []FolderInfo, onProgress func(done, total int)) error {
+	filter, err := newContentFilter(config)
+	if err != nil {
+		return err
+	}
+	src, err := openSource(config)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	for i := range folders {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		size, count, err := folderStats(ctx, src, folders[i].Path, filter)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка подсчета размера %s: %v", folders[i].Path, err)
+		}
+		folders[i].Size = size
+		folders[i].FileCount = count
+
+		if onProgress != nil {
+			onProgress(i+1, len(folders))
+		}
+	}
+	return nil
+}
+
+// SummarizeFolders суммирует размеры и количество файлов папок
+func SummarizeFolders(folders []FolderInfo) FolderSummary {
+	summary := FolderSummary{Folders: len(folders)}
+	for _, folder := range folders {
+		summary.Size += folder.Size
+		summary.FileCount += folder.FileCount
+	}
+	return summary
+}
+
+// FormatSize форматирует размер в байтах в читаемый вид (например, 12.4 GB)
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// folderStats обходит папку источника src так же, как копирование с
+// фильтром filter, и считает файлы и их размер. Папки на сервере SFTP
+// фильтруются только по спискам игнорирования: .gitignore и содержимое
+// файлов проверяются уже после загрузки версии
+func folderStats(ctx context.Context, src sourceFS, root string, filter *contentFilter) (int64, int, error) {
+	var size int64
+	count := 0
+	rules := filter.ignore()
+	_, local := src.(localSource)
+
+	err := src.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+
+		rel, err := src.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if rules.dir(d.Name()) {
+				return filepath.SkipDir
+			}
+			if local {
+				ignored, err := filter.gitignored(root, rel, true)
+				if err != nil {
+					return err
+				}
+				if ignored {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+
+		ignored, err := rules.file(d.Name())
+		if err == nil && !ignored && local {
+			ignored, err = filter.gitignored(root, rel, false)
+		}
+		if err != nil {
+			return err
+		}
+		if ignored {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if local {
+			excluded, err := filter.excludes(path, info.Size())
+			if err != nil {
+				return err
+			}
+			if excluded {
+				return nil
+			}
+		}
+		size += info.Size()
+		count++
+		return nil
+	})
+
+	return size, count, err
+}
@@ -0,0 +1,92 @@
+package gitconverter
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEnrichFoldersMatchesCopy(t *testing.T) {
+	src := versionSource(t, map[string]string{
+		"a.txt":             "text\n",
+		"b.tmp":             "temporary\n",
+		"cache/c.pyc":       "bytecode",
+		"node_modules/x.js": "x",
+		".gitignore":        "secret/\n",
+		"secret/key.txt":    "key\n",
+		"bundle.bin":        "PK\x03\x04archive",
+	})
+	dst := t.TempDir()
+	config := testConfig(src, dst)
+	// Заданный список заменяет шаблоны по умолчанию, так что *.pyc копируется
+	config.IgnoreFiles = []string{"*.tmp"}
+	config.IgnoreDirs = []string{"node_modules"}
+	config.RespectGitignore = true
+	config.SkipContentTypes = []string{ContentArchive}
+	config.ContentSniffMinSize = 1
+
+	c, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scan, err := c.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := EnrichFolders(context.Background(), config, scan.Folders, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Migrate(scan.Folders); err != nil {
+		t.Fatal(err)
+	}
+
+	files := treeFiles(t, history(t, dst)[0])
+	for _, name := range []string{"b.tmp", "node_modules/x.js", "secret/key.txt", "bundle.bin"} {
+		if _, ok := files[name]; ok {
+			t.Errorf("%s перенесен", name)
+		}
+	}
+	if _, ok := files["cache/c.pyc"]; !ok {
+		t.Error("cache/c.pyc не перенесен: действуют шаблоны по умолчанию")
+	}
+
+	// Подсчет совпадает с тем, что попало в коммит
+	var size int64
+	for _, file := range files {
+		size += int64(len(file.Content))
+	}
+	folder := scan.Folders[0]
+	if folder.FileCount != len(files) || folder.Size != size {
+		t.Errorf("EnrichFolders: %d файлов, %d байт; в коммите %d файлов, %d байт", folder.FileCount, folder.Size, len(files), size)
+	}
+
+	// Оценка по заполненным папкам дает тот же объем
+	estimate, err := c.Estimate(scan.Folders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.Files != len(files) || estimate.Bytes != size {
+		t.Errorf("оценка: %d файлов, %d байт", estimate.Files, estimate.Bytes)
+	}
+}
+
+func TestEstimateWithoutEnrichUsesConfig(t *testing.T) {
+	src := versionSource(t, map[string]string{"a.txt": "1234", "b.tmp": "tmp", "c.pyc": "pyc"})
+	config := testConfig(src, "")
+	config.IgnoreFiles = []string{"*.tmp"}
+
+	c, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scan, err := c.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	estimate, err := c.Estimate(scan.Folders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.Files != 2 || estimate.Bytes != 7 {
+		t.Errorf("оценка %+v, ожидались a.txt и c.pyc", estimate)
+	}
+}
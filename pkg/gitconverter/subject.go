@@ -0,0 +1,66 @@
+package gitconverter
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Длины строк сообщения коммита по соглашению 50/72
+const (
+	defaultSubjectLimit = 72 // Первая строка длиннее обрезается большинством интерфейсов Git
+	bodyWidth           = 72 // Ширина строк, перенесенных в тело сообщения
+)
+
+// subjectLimit возвращает допустимую длину первой строки или 0, если
+// ограничение выключено
+func subjectLimit(config Config) int {
+	switch {
+	case config.SubjectLimit < 0:
+		return 0
+	case config.SubjectLimit == 0:
+		return defaultSubjectLimit
+	}
+	return config.SubjectLimit
+}
+
+// wrapSubject укорачивает первую строку сообщения до limit символов. Остаток
+// не теряется: он переносится в начало тела сообщения после пустой строки
+func wrapSubject(msg string, limit int) string {
+	subject, body, _ := strings.Cut(msg, "\n")
+	if limit <= 0 || utf8.RuneCountInString(subject) <= limit {
+		return msg
+	}
+
+	head, rest := splitLine(subject, limit)
+	lines := []string{head, ""}
+	for rest != "" {
+		var line string
+		line, rest = splitLine(rest, bodyWidth)
+		lines = append(lines, line)
+	}
+
+	if body = strings.TrimLeft(body, "\n"); body != "" {
+		lines = append(lines, "", body)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splitLine отделяет от строки начало не длиннее width символов, по
+// возможности по границе слова. Слово длиннее width разрезается
+func splitLine(s string, width int) (string, string) {
+	s = strings.TrimSpace(s)
+	if utf8.RuneCountInString(s) <= width {
+		return s, ""
+	}
+
+	// Байтовая позиция символа с номером width
+	cut := 0
+	for i := 0; i < width; i++ {
+		_, size := utf8.DecodeRuneInString(s[cut:])
+		cut += size
+	}
+	if space := strings.LastIndexByte(s[:cut+1], ' '); space > 0 {
+		cut = space
+	}
+	return strings.TrimSpace(s[:cut]), strings.TrimSpace(s[cut:])
+}
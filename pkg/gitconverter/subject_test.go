@@ -0,0 +1,91 @@
+package gitconverter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWrapSubject(t *testing.T) {
+	tests := []struct {
+		name  string
+		msg   string
+		limit int
+		want  string
+	}{
+		{"короткая строка", "Версия 1", 72, "Версия 1"},
+		{"ровно по пределу", "один два", 8, "один два"},
+		{"ограничение выключено", "один два три четыре", 0, "один два три четыре"},
+		{"перенос по границе слова", "один два три четыре", 10, "один два\n\nтри четыре"},
+		{"слово длиннее предела", "абвгдеёжзи", 4, "абвг\n\nдеёжзи"},
+		{"тело шаблона после остатка", "один два три четыре\n\nтело", 10, "один два\n\nтри четыре\n\nтело"},
+		{"лишние пустые строки перед телом", "один два три\n\n\n\nтело", 8, "один два\n\nтри\n\nтело"},
+		{"длинное тело не трогается", "Версия 1\n" + strings.Repeat("слово ", 30), 72, "Версия 1\n" + strings.Repeat("слово ", 30)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapSubject(tt.msg, tt.limit); got != tt.want {
+				t.Errorf("wrapSubject(%q, %d) = %q, ожидалось %q", tt.msg, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapSubjectKeepsText(t *testing.T) {
+	subject := "Версия 1: " + strings.Repeat("очень длинное имя папки с версией ", 8)
+	got := wrapSubject(subject, defaultSubjectLimit)
+
+	lines := strings.Split(got, "\n")
+	if n := utf8.RuneCountInString(lines[0]); n > defaultSubjectLimit {
+		t.Errorf("первая строка из %d символов", n)
+	}
+	if len(lines) < 3 || lines[1] != "" {
+		t.Fatalf("остаток не отделен пустой строкой: %q", got)
+	}
+	for _, line := range lines[2:] {
+		if n := utf8.RuneCountInString(line); n > bodyWidth {
+			t.Errorf("строка тела из %d символов: %q", n, line)
+		}
+	}
+	// Перенос не теряет и не склеивает слова
+	if strings.Join(strings.Fields(got), " ") != strings.Join(strings.Fields(subject), " ") {
+		t.Errorf("текст изменился при переносе: %q", got)
+	}
+}
+
+func TestLongSubjectKeepsTrailersLast(t *testing.T) {
+	src := versionSource(t, map[string]string{"a.txt": "1\n"})
+	// Имя папки ограничено 255 байтами, а кириллица занимает по два
+	folder := "v1 " + strings.TrimSpace(strings.Repeat("очень длинное имя папки ", 3))
+	if err := os.Rename(filepath.Join(src, "v1"), filepath.Join(src, folder)); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := t.TempDir()
+	config := testConfig(src, dst)
+	config.MessageTemplate = "Версия {version}: {folder}\n\nОписание версии"
+	config.CommitTrailers = map[string]string{"Source-Folder": "{folder}", "Version": "{version}"}
+	mustRun(t, config)
+
+	commits := history(t, dst)
+	if len(commits) != 1 {
+		t.Fatalf("коммитов %d, ожидался 1", len(commits))
+	}
+	msg := strings.TrimRight(commits[0].Message, "\n")
+	lines := strings.Split(msg, "\n")
+	if n := utf8.RuneCountInString(lines[0]); n > defaultSubjectLimit {
+		t.Errorf("первая строка из %d символов: %q", n, lines[0])
+	}
+
+	// Трейлеры — последний абзац, тело шаблона стоит перед ними
+	paragraphs := strings.Split(msg, "\n\n")
+	want := "Source-Folder: " + strings.Join(strings.Fields(folder), " ") + "\nVersion: 1"
+	if last := paragraphs[len(paragraphs)-1]; last != want {
+		t.Errorf("последний абзац %q, ожидались трейлеры %q", last, want)
+	}
+	if len(paragraphs) < 4 || paragraphs[len(paragraphs)-2] != "Описание версии" {
+		t.Errorf("тело шаблона не перед трейлерами: %q", msg)
+	}
+}
@@ -0,0 +1,182 @@
+package gitconverter
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// numberPattern — число с необязательными частями через точку (1, 1.2, 1.2.3)
+const numberPattern = `[0-9]+(\.[0-9]+)*`
+
+// PatternSuggestion — шаблон версии, предложенный по именам папок
+type PatternSuggestion struct {
+	Pattern     string            // Регулярное выражение для Config.ExtractPattern
+	Description string            // Что именно шаблон находит в имени
+	Versions    map[string]string // Имя папки → извлеченная версия, только совпавшие имена
+	Matched     int               // Сколько имен совпало с шаблоном
+	Distinct    int               // Сколько среди извлеченных версий разных
+}
+
+// patternCandidate — шаблон-кандидат и его описание
+type patternCandidate struct {
+	pattern     string
+	description string
+}
+
+// baseCandidates — шаблоны, которые проверяются на любых именах. Более
+// точные идут первыми: при одинаковом результате остается первый
+var baseCandidates = []patternCandidate{
+	{`[0-9]{4}-[0-9]{2}-[0-9]{2}`, "дата ГГГГ-ММ-ДД"},
+	{`[0-9]{2}\.[0-9]{2}\.[0-9]{4}`, "дата ДД.ММ.ГГГГ"},
+	{`(19|20)[0-9]{6}`, "дата ГГГГММДД"},
+	{`[0-9]+\.[0-9]+\.[0-9]+`, "номер вида 1.2.3"},
+	{`[0-9]+\.[0-9]+`, "номер вида 1.2"},
+	{numberPattern, "первое число в имени"},
+}
+
+// SuggestExtractPattern предлагает шаблоны версии для имен папок
+// sampleNames. Имена разбираются на числа, номера вида 1.2.3, даты и общие
+// для всех имен начало и окончание; каждый кандидат проверяется на всех
+// именах. В ответе только шаблоны, которые совпали хотя бы с одним именем и
+// различают версии; лучшие — различающие больше всего имен — идут первыми.
+// Шаблоны, дающие одинаковые версии, объединяются
+func SuggestExtractPattern(sampleNames []string) []PatternSuggestion {
+	if len(sampleNames) == 0 {
+		return nil
+	}
+
+	candidates := append([]patternCandidate(nil), baseCandidates...)
+	prefix, suffix := commonAffixes(sampleNames)
+
+	// Номер в конце имени, если первое число входит в общее начало (app2_15)
+	if suffix == "" {
+		candidates = append(candidates, patternCandidate{numberPattern + `$`, "последнее число в имени"})
+	} else if r, _ := utf8.DecodeRuneInString(suffix); !unicode.IsDigit(r) {
+		candidates = append(candidates, patternCandidate{numberPattern + regexp.QuoteMeta(suffix) + `$`,
+			"число перед общим окончанием «" + suffix + "» (окончание войдет в версию)"})
+	}
+	// Номер после слова из общего начала (build_v2_r15 → r15)
+	if word := trailingLetters(prefix); word != "" && strings.ContainsAny(prefix, "0123456789") {
+		candidates = append(candidates, patternCandidate{regexp.QuoteMeta(word) + numberPattern,
+			"число после «" + word + "» (вместе с ним)"})
+	}
+
+	var suggestions []PatternSuggestion
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		s := evaluatePattern(c, sampleNames)
+		if s.Matched == 0 || (s.Distinct < 2 && len(sampleNames) > 1) {
+			continue
+		}
+		key := versionsKey(s.Versions, sampleNames)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		suggestions = append(suggestions, s)
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Distinct != suggestions[j].Distinct {
+			return suggestions[i].Distinct > suggestions[j].Distinct
+		}
+		return suggestions[i].Matched > suggestions[j].Matched
+	})
+	return suggestions
+}
+
+// evaluatePattern извлекает версии шаблоном кандидата так же, как сканирование
+func evaluatePattern(c patternCandidate, names []string) PatternSuggestion {
+	re := regexp.MustCompile(c.pattern)
+	s := PatternSuggestion{Pattern: c.pattern, Description: c.description, Versions: make(map[string]string)}
+	distinct := make(map[string]bool)
+	for _, name := range names {
+		if version := re.FindString(name); version != "" {
+			s.Versions[name] = version
+			distinct[version] = true
+		}
+	}
+	s.Matched = len(s.Versions)
+	s.Distinct = len(distinct)
+	return s
+}
+
+// versionsKey описывает результат шаблона строкой, чтобы найти шаблоны,
+// извлекающие одно и то же
+func versionsKey(versions map[string]string, names []string) string {
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(versions[name])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// commonAffixes возвращает общее начало и общее окончание имен. Граница не
+// проходит внутри числа: цифры на краю отбрасываются, ведь номер версии
+// отличается от имени к имени
+func commonAffixes(names []string) (prefix, suffix string) {
+	prefix, suffix = names[0], names[0]
+	for _, name := range names[1:] {
+		for !strings.HasPrefix(name, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+		for !strings.HasSuffix(name, suffix) {
+			_, size := utf8.DecodeRuneInString(suffix)
+			suffix = suffix[size:]
+		}
+	}
+	if len(names) == 1 {
+		return "", ""
+	}
+	prefix = strings.TrimRightFunc(prefix, unicode.IsDigit)
+	suffix = strings.TrimLeftFunc(suffix, unicode.IsDigit)
+	return prefix, suffix
+}
+
+// trailingLetters возвращает буквы в конце s (build_v → v)
+func trailingLetters(s string) string {
+	return s[len(strings.TrimRightFunc(s, unicode.IsLetter)):]
+}
+
+// SuggestPatterns предлагает шаблоны версии по итогам сканирования, если
+// текущий шаблон не дал отдельной версии большинству подходящих папок: не
+// нашел ее в имени или извлек одну и ту же у нескольких папок. Возвращаются
+// только шаблоны, различающие больше версий, чем текущий; если текущий
+// справляется, ответ пуст
+func (s *ScanResult) SuggestPatterns() []PatternSuggestion {
+	names := s.SampleNames()
+	distinct := make(map[string]bool)
+	for _, folder := range s.Folders {
+		distinct[folder.Version] = true
+	}
+	if len(distinct)*2 > len(names) {
+		return nil
+	}
+
+	var better []PatternSuggestion
+	for _, suggestion := range SuggestExtractPattern(names) {
+		if suggestion.Distinct > len(distinct) {
+			better = append(better, suggestion)
+		}
+	}
+	return better
+}
+
+// SampleNames возвращает имена всех папок, подходящих под шаблон поиска:
+// с версией и без нее, в порядке Folders и затем Unmatched
+func (s *ScanResult) SampleNames() []string {
+	var names []string
+	for _, folder := range s.Folders {
+		names = append(names, filepath.Base(folder.Path))
+	}
+	for _, path := range s.Unmatched {
+		names = append(names, filepath.Base(path))
+	}
+	return names
+}
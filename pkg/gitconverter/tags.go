@@ -0,0 +1,209 @@
+package gitconverter
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// TagConflictPolicy определяет, что делать, если тег версии уже существует,
+// например при повторном запуске в режиме добавления
+type TagConflictPolicy string
+
+const (
+	TagConflictSkip TagConflictPolicy = "skip" // Оставить прежний тег и предупредить (по умолчанию)
+	TagConflictFail TagConflictPolicy = "fail" // Считать это ошибкой обработки папки
+)
+
+// defaultTagFileListLimit ограничивает список файлов в сообщении тега,
+// чтобы теги больших версий оставались читаемыми в git tag -n
+const defaultTagFileListLimit = 100
+
+// createVersionTag создает аннотированный тег версии, сообщение которого
+// содержит версию, дату и список файлов снимка
+func createVersionTag(repo *git.Repository, config Config, folder FolderInfo, hash plumbing.Hash, tagger object.Signature, signKey *openpgp.Entity) error {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return err
+	}
+
+	var files []string
+	iter, err := commit.Files()
+	if err != nil {
+		return err
+	}
+	err = iter.ForEach(func(f *object.File) error {
+		files = append(files, f.Name)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	limit := config.TagFileListLimit
+	if limit <= 0 {
+		limit = defaultTagFileListLimit
+	}
+
+	_, err = repo.CreateTag(versionTagName(config, folder.Version), hash, &git.CreateTagOptions{
+		Tagger:  &tagger,
+		Message: tagMessage(folder, files, limit),
+		SignKey: signKey,
+	})
+	return err
+}
+
+// createLightweightTag создает легковесный тег версии — ссылку на коммит
+// без автора и сообщения
+func createLightweightTag(repo *git.Repository, config Config, folder FolderInfo, hash plumbing.Hash) error {
+	_, err := repo.CreateTag(versionTagName(config, folder.Version), hash, nil)
+	return err
+}
+
+// versionTagName возвращает имя тега версии с учетом префикса TagPrefix
+func versionTagName(config Config, version string) string {
+	return config.TagPrefix + CanonicalVersion(config, version)
+}
+
+// tagMessage формирует сообщение тега: версия, имя папки, дата и не более
+// limit файлов. Для тегов, поставленных по истории, папка неизвестна
+func tagMessage(folder FolderInfo, files []string, limit int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Version %s", Printable(folder.Version))
+	if folder.Path != "" {
+		fmt.Fprintf(&b, ": %s", Printable(filepath.Base(folder.Path)))
+	}
+	fmt.Fprintf(&b, " (created: %s)\n\n", time.Unix(folder.CreationTime, 0).Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "Files (%d):\n", len(files))
+
+	for i, name := range files {
+		if i == limit {
+			fmt.Fprintf(&b, "... and %d more\n", len(files)-limit)
+			break
+		}
+		b.WriteString(escapeControl(name))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// VersionTag описывает тег версии, найденной в истории репозитория
+type VersionTag struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+}
+
+// TagReport содержит итоги расстановки тегов в готовом репозитории
+type TagReport struct {
+	Created []VersionTag `json:"created"` // Созданные теги (в режиме DryRun — которые будут созданы)
+	Present []VersionTag `json:"present"` // Теги, которые уже были в репозитории
+}
+
+// RetrofitTags ставит недостающие аннотированные теги версий в уже
+// перенесенном репозитории. Версия определяется по трейлерам из
+// CommitTrailers, значение которых — {version}, или по сообщению
+// "Version X: ...". Тег получает автора и дату коммита. В режиме DryRun
+// теги не создаются, а только перечисляются
+func RetrofitTags(config Config) (*TagReport, error) {
+	signKey, err := loadSignKey(config)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := openRepository(config.TargetDir)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия репозитория: %v", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения HEAD: %v", err)
+	}
+
+	var keys []string
+	for key, value := range config.CommitTrailers {
+		if strings.TrimSpace(value) == "{version}" {
+			keys = append(keys, key)
+		}
+	}
+
+	// Идем от HEAD к началу истории, тег получает самый ранний коммит версии,
+	// как при миграции, когда повторная версия не может занять тот же тег
+	var order []string
+	commits := make(map[string]*object.Commit)
+	for hash := head.Hash(); !hash.IsZero(); {
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return nil, err
+		}
+		if version, part, ok := commitVersion(commit.Message, keys); ok {
+			_, seen := commits[version]
+			if !seen {
+				order = append(order, version)
+			}
+			// Большая версия разбита на части, тег ставится на последнюю
+			if !seen || !part {
+				commits[version] = commit
+			}
+		}
+		hash = firstParent(commit)
+	}
+
+	report := &TagReport{}
+	for i := len(order) - 1; i >= 0; i-- {
+		version := order[i]
+		commit := commits[version]
+		tag := VersionTag{Name: versionTagName(config, version), Version: version, Commit: commit.Hash.String()}
+
+		if _, err := repo.Tag(tag.Name); err == nil {
+			report.Present = append(report.Present, tag)
+			continue
+		} else if err != git.ErrTagNotFound {
+			return report, fmt.Errorf("ошибка чтения тега %s: %v", tag.Name, err)
+		}
+
+		if !config.DryRun {
+			folder := FolderInfo{Version: version, CreationTime: commit.Author.When.Unix()}
+			if err := createVersionTag(repo, config, folder, commit.Hash, commit.Author, signKey); err != nil {
+				return report, fmt.Errorf("ошибка создания тега %s: %v", tag.Name, err)
+			}
+		}
+		report.Created = append(report.Created, tag)
+	}
+	return report, nil
+}
+
+// commitVersion извлекает версию из трейлера с ключом из keys или из
+// заголовка "Version X: ...". part сообщает, что это коммит части большой
+// версии ("Version X (part i/n): ...")
+func commitVersion(msg string, keys []string) (version string, part bool, ok bool) {
+	subject, _, _ := strings.Cut(msg, "\n")
+	part = strings.Contains(subject, " (part ")
+
+	for _, line := range strings.Split(msg, "\n") {
+		for _, key := range keys {
+			if value, found := strings.CutPrefix(line, key+": "); found && strings.TrimSpace(value) != "" {
+				return strings.TrimSpace(value), part, true
+			}
+		}
+	}
+
+	rest, found := strings.CutPrefix(subject, "Version ")
+	if !found {
+		return "", false, false
+	}
+	version, _, found = strings.Cut(rest, ":")
+	if !found {
+		return "", false, false
+	}
+	if i := strings.Index(version, " (part "); i >= 0 {
+		version = version[:i]
+	}
+	version = strings.TrimSpace(version)
+	return version, part, version != ""
+}
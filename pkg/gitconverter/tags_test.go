@@ -0,0 +1,123 @@
+package gitconverter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestTagMessage(t *testing.T) {
+	folder := FolderInfo{Path: "/src/v1", Version: "1", CreationTime: testEpoch.Unix()}
+	date := time.Unix(folder.CreationTime, 0).Format("2006-01-02 15:04:05")
+	files := []string{"a.txt", "b.txt", "c.txt"}
+
+	tests := []struct {
+		name   string
+		folder FolderInfo
+		limit  int
+		want   string
+	}{
+		{"все файлы", folder, 3, "Version 1: v1 (created: " + date + ")\n\nFiles (3):\na.txt\nb.txt\nc.txt\n"},
+		{"список обрезан", folder, 2, "Version 1: v1 (created: " + date + ")\n\nFiles (3):\na.txt\nb.txt\n... and 1 more\n"},
+		{"тег по истории без папки", FolderInfo{Version: "1", CreationTime: folder.CreationTime}, 1,
+			"Version 1 (created: " + date + ")\n\nFiles (3):\na.txt\n... and 2 more\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tagMessage(tt.folder, files, tt.limit); got != tt.want {
+				t.Errorf("сообщение\n%q\nожидалось\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnnotatedVersionTags(t *testing.T) {
+	src := versionSource(t,
+		map[string]string{"a.txt": "1", "b.txt": "1", "c.txt": "1"},
+		map[string]string{"a.txt": "2"},
+	)
+	dst := t.TempDir()
+	config := testConfig(src, dst)
+	config.AnnotatedTags = true
+	config.TagPrefix = "v"
+	config.TagFileListLimit = 2
+	mustRun(t, config)
+
+	if names := tagNames(t, dst); !reflect.DeepEqual(names, []string{"v1", "v2"}) {
+		t.Fatalf("теги %v, ожидались v1 и v2", names)
+	}
+	repo, err := git.PlainOpen(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commits := history(t, dst)
+	for i, want := range []struct{ name, subject, files string }{
+		{"v1", "Version 1: v1 (created: ", "Files (3):\na.txt\nb.txt\n... and 1 more\n"},
+		{"v2", "Version 2: v2 (created: ", "Files (1):\na.txt\n"},
+	} {
+		tag := annotatedTag(t, repo, want.name)
+		if tag.Target != commits[i].Hash {
+			t.Errorf("тег %s указывает на %s, а не на коммит версии", want.name, tag.Target)
+		}
+		if !strings.HasPrefix(tag.Message, want.subject) {
+			t.Errorf("заголовок тега %s: %q", want.name, tag.Message)
+		}
+		if !strings.HasSuffix(tag.Message, want.files) {
+			t.Errorf("список файлов тега %s: %q, ожидался %q", want.name, tag.Message, want.files)
+		}
+		if tag.Tagger.Name != "Test" || !tag.Tagger.When.Equal(commits[i].Author.When) {
+			t.Errorf("автор тега %s: %s, %v", want.name, tag.Tagger.Name, tag.Tagger.When)
+		}
+	}
+}
+
+func TestCreateTagsLightweightWithoutAuthor(t *testing.T) {
+	src := versionSource(t, map[string]string{"a.txt": "1"}, map[string]string{"a.txt": "2"})
+	authors := filepath.Join(t.TempDir(), "authors.txt")
+	if err := os.WriteFile(authors, []byte("2:Anna:anna@example.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := t.TempDir()
+	config := testConfig(src, dst)
+	config.CreateTags = true
+	config.AuthorsFile = authors
+	mustRun(t, config)
+
+	repo, err := git.PlainOpen(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Версия без автора в файле получает легковесный тег, с автором — аннотированный
+	ref, err := repo.Tag("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.TagObject(ref.Hash()); err != plumbing.ErrObjectNotFound {
+		t.Errorf("тег версии 1 не легковесный: %v", err)
+	}
+	if tag := annotatedTag(t, repo, "2"); tag.Tagger.Name != "Anna" {
+		t.Errorf("автор тега версии 2: %s", tag.Tagger.Name)
+	}
+}
+
+// annotatedTag возвращает объект аннотированного тега name
+func annotatedTag(t *testing.T, repo *git.Repository, name string) *object.Tag {
+	t.Helper()
+	ref, err := repo.Tag(name)
+	if err != nil {
+		t.Fatalf("тег %s: %v", name, err)
+	}
+	tag, err := repo.TagObject(ref.Hash())
+	if err != nil {
+		t.Fatalf("тег %s не аннотированный: %v", name, err)
+	}
+	return tag
+}
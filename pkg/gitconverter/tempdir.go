@@ -0,0 +1,84 @@
+package gitconverter
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"folder_to_git/internal/diskspace"
+)
+
+// tempRoot возвращает директорию для промежуточных файлов миграции
+func tempRoot(config Config) string {
+	if config.TempDir != "" {
+		return config.TempDir
+	}
+	return os.TempDir()
+}
+
+// makeTempDir создает временную директорию внутри tempRoot.
+// Удалять ее должен вызывающий код
+func makeTempDir(config Config, pattern string) (string, error) {
+	return os.MkdirTemp(tempRoot(config), pattern)
+}
+
+// checkTempDir проверяет заданную пользователем TempDir: директория должна
+// существовать, быть доступной для записи и вмещать самую большую версию
+func checkTempDir(src sourceFS, config Config, folders []FolderInfo) error {
+	if config.TempDir == "" {
+		return nil
+	}
+
+	info, err := os.Stat(config.TempDir)
+	if err != nil {
+		return fmt.Errorf("временная директория недоступна: %v", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("временная директория %s не является директорией", config.TempDir)
+	}
+
+	tmp, err := makeTempDir(config, ".folder-to-git-check-")
+	if err != nil {
+		return fmt.Errorf("нет прав на запись во временную директорию: %v", err)
+	}
+	os.RemoveAll(tmp)
+
+	// Промежуточные данные одновременно содержат не больше одной версии
+	filter, err := newContentFilter(config)
+	if err != nil {
+		return err
+	}
+	var largest int64
+	for _, folder := range folders {
+		size := folder.Size
+		if size == 0 && folder.FileCount == 0 {
+			size, _, err = folderStats(context.Background(), src, folder.Path, filter)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("ошибка подсчета размера %s: %v", folder.Path, err)
+			}
+		}
+		if size > largest {
+			largest = size
+		}
+	}
+
+	free, err := diskspace.Free(config.TempDir)
+	if err != nil {
+		config.logger().Printf("Предупреждение: не удалось определить свободное место во временной директории: %v", err)
+		return nil
+	}
+	if uint64(largest) <= free {
+		return nil
+	}
+
+	msg := fmt.Sprintf("недостаточно места во временной директории %s: требуется около %s, свободно %s",
+		config.TempDir, FormatSize(largest), FormatSize(int64(free)))
+	if config.IgnoreDiskSpace {
+		config.logger().Printf("Предупреждение: %s", msg)
+		return nil
+	}
+	return fmt.Errorf("%s", msg)
+}
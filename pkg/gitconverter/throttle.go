@@ -0,0 +1,116 @@
+package gitconverter
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"folder_to_git/internal/ratelimit"
+)
+
+// idlePause — пауза между папками в режиме IdlePriority
+const idlePause = 500 * time.Millisecond
+
+// throttle ограничивает копирование по байтам и файлам в секунду и
+// считает скопированное, чтобы показать фактическую скорость
+type throttle struct {
+	bytes *ratelimit.Bucket // nil — без ограничения
+	files *ratelimit.Bucket
+
+	mu          sync.Mutex
+	copiedBytes int64
+	copiedFiles int
+}
+
+// newThrottle создает ограничитель по настройкам или возвращает nil, если ограничений нет
+func newThrottle(config Config) *throttle {
+	if config.ThrottleMBps <= 0 && config.ThrottleFilesPerSec <= 0 {
+		return nil
+	}
+	t := &throttle{}
+	if config.ThrottleMBps > 0 {
+		t.bytes = ratelimit.New(config.ThrottleMBps * 1024 * 1024)
+	}
+	if config.ThrottleFilesPerSec > 0 {
+		t.files = ratelimit.New(config.ThrottleFilesPerSec)
+	}
+	return t
+}
+
+// file ждет разрешения на копирование очередного файла
+func (t *throttle) file(ctx context.Context) error {
+	t.mu.Lock()
+	t.copiedFiles++
+	t.mu.Unlock()
+	if t.files == nil {
+		return nil
+	}
+	return t.files.Wait(ctx, 1)
+}
+
+// reader ограничивает скорость чтения r
+func (t *throttle) reader(ctx context.Context, r io.Reader) io.Reader {
+	return throttledReader{ctx: ctx, r: r, t: t}
+}
+
+// take возвращает скопированное с прошлого вызова и обнуляет счетчики
+func (t *throttle) take() (int64, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	bytes, files := t.copiedBytes, t.copiedFiles
+	t.copiedBytes, t.copiedFiles = 0, 0
+	return bytes, files
+}
+
+// throttledReader читает не быстрее, чем разрешает ограничитель
+type throttledReader struct {
+	ctx context.Context
+	r   io.Reader
+	t   *throttle
+}
+
+func (r throttledReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.t.mu.Lock()
+		r.t.copiedBytes += int64(n)
+		r.t.mu.Unlock()
+		if r.t.bytes != nil {
+			if waitErr := r.t.bytes.Wait(r.ctx, float64(n)); waitErr != nil {
+				return n, waitErr
+			}
+		}
+	}
+	return n, err
+}
+
+// throttleKey — ключ ограничителя в контексте обработки папки
+type throttleKey struct{}
+
+// withThrottle передает ограничитель копированию через контекст
+func withThrottle(ctx context.Context, t *throttle) context.Context {
+	if t == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, throttleKey{}, t)
+}
+
+// throttleFrom возвращает ограничитель из контекста или nil
+func throttleFrom(ctx context.Context) *throttle {
+	t, _ := ctx.Value(throttleKey{}).(*throttle)
+	return t
+}
+
+// logThroughput выводит фактическую скорость копирования папки, чтобы по
+// ней можно было подобрать ограничения
+func (m *migration) logThroughput(elapsed time.Duration) {
+	bytes, files := m.throttle.take()
+	seconds := elapsed.Seconds()
+	if files == 0 || seconds <= 0 {
+		return
+	}
+	m.config.logger().Printf("Скопировано %d файлов, %s за %s: %s/с, %.1f файлов/с",
+		files, FormatSize(bytes), elapsed.Round(time.Millisecond),
+		FormatSize(int64(float64(bytes)/seconds)), float64(files)/seconds)
+}
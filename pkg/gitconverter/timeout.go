@@ -0,0 +1,97 @@
+package gitconverter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrFolderTimeout возвращается, если обработка папки не уложилась в FolderTimeout
+var ErrFolderTimeout = errors.New("превышено время обработки папки")
+
+// folderTask отслеживает этап обработки папки, чтобы при таймауте
+// было видно, что именно прервано
+type folderTask struct {
+	mu     sync.Mutex
+	stage  string
+	commit CommitRecord // Созданный коммит, заполняется при успешной обработке
+}
+
+// setStage отмечает начало нового этапа обработки
+func (t *folderTask) setStage(stage string) {
+	t.mu.Lock()
+	t.stage = stage
+	t.mu.Unlock()
+}
+
+// currentStage возвращает текущий этап обработки
+func (t *folderTask) currentStage() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stage
+}
+
+// setCommit запоминает коммит, созданный для папки
+func (t *folderTask) setCommit(record CommitRecord) {
+	t.mu.Lock()
+	t.commit = record
+	t.mu.Unlock()
+}
+
+// createdCommit возвращает коммит, созданный для папки, или пустую запись
+func (t *folderTask) createdCommit() CommitRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.commit
+}
+
+// runFolder выполняет обработку папки, ограничивая ее время timeout.
+// Зависшее чтение с диска прервать нельзя, поэтому обработка идет в отдельной
+// горутине: по истечении времени управление сразу возвращается вызывающему,
+// а горутина завершится на ближайшей проверке контекста.
+// Отмена parent прерывает обработку так же, как истечение времени.
+// Возвращает этап, на котором обработка остановилась
+func runFolder(parent context.Context, timeout time.Duration, fn func(ctx context.Context, task *folderTask) error) (string, error) {
+	task := &folderTask{}
+	if timeout <= 0 {
+		err := fn(parent, task)
+		return task.currentStage(), err
+	}
+
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx, task)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	stage := task.currentStage()
+	if errors.Is(err, context.DeadlineExceeded) {
+		return stage, fmt.Errorf("%w: %s (этап: %s)", ErrFolderTimeout, timeout, stage)
+	}
+	return stage, err
+}
+
+// contextReader прекращает чтение после отмены контекста
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
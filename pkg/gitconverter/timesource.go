@@ -0,0 +1,77 @@
+package gitconverter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// TimestampTimeFile — время версии взято из TimeSourceFile. Стратегией в
+// настройках не задается: так отмечаются папки в объяснении порядка
+const TimestampTimeFile TimestampStrategy = "time-file"
+
+// LoadVersionTimes читает файл со временем создания версий в формате
+// "версия=время". Время задается датой ГГГГ-ММ-ДД (полночь по местному
+// времени) или в RFC3339. Пустые строки и комментарии (#) пропускаются
+func LoadVersionTimes(path string) (map[string]time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	times := make(map[string]time.Time)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		version, value, ok := strings.Cut(line, "=")
+		version, value = strings.TrimSpace(version), strings.TrimSpace(value)
+		if !ok || version == "" || value == "" {
+			return nil, fmt.Errorf("строка %d: ожидается версия=время", i+1)
+		}
+		t, err := parseVersionTime(value)
+		if err != nil {
+			return nil, fmt.Errorf("строка %d: %v", i+1, err)
+		}
+		times[version] = t
+	}
+	return times, nil
+}
+
+// parseVersionTime разбирает время в формате ГГГГ-ММ-ДД или RFC3339
+func parseVersionTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("время %q не в формате ГГГГ-ММ-ДД или RFC3339", value)
+}
+
+// versionTimes — время создания версий из TimeSourceFile
+type versionTimes map[string]time.Time
+
+// loadVersionTimes читает TimeSourceFile, если он задан
+func loadVersionTimes(config Config) (versionTimes, error) {
+	if config.TimeSourceFile == "" {
+		return nil, nil
+	}
+	times, err := LoadVersionTimes(config.TimeSourceFile)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла времени версий: %v", err)
+	}
+	return times, nil
+}
+
+// apply заполняет время создания папки: из файла, если версия в нем есть,
+// иначе по стратегии TimestampStrategy
+func (t versionTimes) apply(src sourceFS, config Config, folder *FolderInfo, info os.FileInfo) {
+	if when, ok := t[folder.Version]; ok {
+		folder.CreationTime, folder.TimeFromFile = when.Unix(), true
+		return
+	}
+	folder.CreationTime, folder.TimeFallback = folderCreationTime(src, config, folder.Path, info)
+}
@@ -0,0 +1,153 @@
+package gitconverter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TimestampStrategy определяет, откуда берется время создания версии
+type TimestampStrategy string
+
+const (
+	TimestampFileMtime   TimestampStrategy = "file-mtime"   // Медиана времени изменения файлов (по умолчанию)
+	TimestampFolderName  TimestampStrategy = "folder-name"  // Дата из имени папки
+	TimestampFolderMtime TimestampStrategy = "folder-mtime" // Время изменения самой папки
+)
+
+// Даты в именах папок: 2023-05-17, 2023_05_17, 20230517 и 17.05.2023
+var (
+	folderDateISO = regexp.MustCompile(`(\d{4})[-_.]?(\d{2})[-_.]?(\d{2})`)
+	folderDateRU  = regexp.MustCompile(`(\d{2})\.(\d{2})\.(\d{4})`)
+)
+
+// validateTimestampStrategy проверяет, что стратегия известна
+func validateTimestampStrategy(strategy TimestampStrategy) error {
+	switch strategy {
+	case "", TimestampFileMtime, TimestampFolderName, TimestampFolderMtime:
+		return nil
+	}
+	return fmt.Errorf("неизвестная стратегия времени создания %q", strategy)
+}
+
+// folderCreationTime определяет время создания версии по выбранной стратегии.
+// Обход файлов выполняется только для стратегии file-mtime. Второе значение
+// сообщает, что стратегия не сработала и использовано запасное время
+func folderCreationTime(src sourceFS, config Config, path string, info os.FileInfo) (int64, bool) {
+	switch config.TimestampStrategy {
+	case TimestampFolderMtime:
+		return info.ModTime().Unix(), false
+
+	case TimestampFolderName:
+		if t, ok := dateFromFolderName(filepath.Base(path)); ok {
+			return t.Unix(), false
+		}
+		config.logger().Printf("Предупреждение: в имени папки %s нет даты, используется время ее изменения", Printable(filepath.Base(path)))
+		return info.ModTime().Unix(), true
+	}
+
+	start := time.Now()
+	creationTime, visited, ok := getFolderCreationTime(src, path, config.TimeSampleExtensions)
+	if config.Verbose {
+		config.logger().Printf("Время создания %s: просмотрено файлов %d за %s",
+			filepath.Base(path), visited, time.Since(start).Round(time.Millisecond))
+	}
+	if !ok {
+		config.logger().Printf("Предупреждение: не удалось определить время создания папки %s по файлам, используется текущее время",
+			filepath.Base(path))
+	}
+	return creationTime, !ok
+}
+
+// dateFromFolderName ищет в имени папки дату в одном из распространенных форматов
+func dateFromFolderName(name string) (time.Time, bool) {
+	if m := folderDateISO.FindStringSubmatch(name); m != nil {
+		if t, ok := makeDate(m[1], m[2], m[3]); ok {
+			return t, true
+		}
+	}
+	if m := folderDateRU.FindStringSubmatch(name); m != nil {
+		if t, ok := makeDate(m[3], m[2], m[1]); ok {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// makeDate собирает дату и отбрасывает несуществующие (2023-13-45)
+func makeDate(year, month, day string) (time.Time, bool) {
+	y, _ := strconv.Atoi(year)
+	m, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(day)
+
+	t := time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.Local)
+	if t.Year() != y || int(t.Month()) != m || t.Day() != d {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// scanCandidate — папка с версией, для которой еще не определено время создания
+type scanCandidate struct {
+	folder FolderInfo
+	info   os.FileInfo
+}
+
+// scanConcurrency возвращает число одновременно проверяемых папок
+func scanConcurrency(config Config) int {
+	if config.Concurrency > 0 {
+		return config.Concurrency
+	}
+	return runtime.NumCPU()
+}
+
+// scanCreationTimes определяет время создания папок в нескольких потоках.
+// Версии, время которых есть в times, файлы не обходят. Порядок результата
+// совпадает с порядком candidates, а OnScanProgress вызывается по мере
+// готовности папок, но никогда не одновременно
+func scanCreationTimes(src sourceFS, config Config, times versionTimes, candidates []scanCandidate) []FolderInfo {
+	folders := make([]FolderInfo, len(candidates))
+	total := len(candidates)
+
+	var mu sync.Mutex
+	done := 0
+	progress := func() {
+		if config.OnScanProgress == nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		config.OnScanProgress(done, total)
+	}
+	if config.OnScanProgress != nil {
+		config.OnScanProgress(0, total)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(scanConcurrency(config), total); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				folder := candidates[i].folder
+				times.apply(src, config, &folder, candidates[i].info)
+				folders[i] = folder
+				progress()
+			}
+		}()
+	}
+	for i := range candidates {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return folders
+}
@@ -0,0 +1,48 @@
+package gitconverter
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// toolPaths — пути в репозитории, которые создает сам конвертер. Очистка
+// рабочей директории, удаление исчезнувших файлов и проверка версии на
+// отсутствие изменений их не учитывают
+type toolPaths map[string]bool
+
+// toolManagedPaths возвращает пути, которыми конвертер управляет при данных
+// настройках. Новый генерируемый файл достаточно зарегистрировать здесь
+func toolManagedPaths(config Config) toolPaths {
+	paths := make(toolPaths)
+	if config.WriteMetadataFile {
+		paths.add(metadataPath(config))
+	}
+	if config.WriteGitignore {
+		paths.add(gitignoreFile)
+	}
+	if len(config.LFSPatterns) > 0 || config.LFSThresholdBytes > 0 || config.WriteEOLAttributes {
+		paths.add(attributesFile)
+	}
+	return paths
+}
+
+// add регистрирует путь относительно корня репозитория
+func (p toolPaths) add(rel string) {
+	p[filepath.ToSlash(filepath.Clean(rel))] = true
+}
+
+// has проверяет, что путь создан конвертером
+func (p toolPaths) has(rel string) bool {
+	return p[filepath.ToSlash(filepath.Clean(rel))]
+}
+
+// containsUnder проверяет, что внутри директории dir есть пути конвертера
+func (p toolPaths) containsUnder(dir string) bool {
+	prefix := filepath.ToSlash(filepath.Clean(dir)) + "/"
+	for path := range p {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
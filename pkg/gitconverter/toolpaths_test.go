@@ -0,0 +1,138 @@
+package gitconverter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func TestToolManagedPaths(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   []string
+	}{
+		{"ничего", Config{}, nil},
+		{"метаданные", Config{WriteMetadataFile: true}, []string{defaultMetadataFile}},
+		{"свой путь метаданных", Config{WriteMetadataFile: true, MetadataFilePath: "meta/./version.yaml"}, []string{"meta/version.yaml"}},
+		{".gitignore", Config{WriteGitignore: true}, []string{gitignoreFile}},
+		{"LFS", Config{LFSPatterns: []string{"*.iso"}}, []string{attributesFile}},
+		{"концы строк", Config{NormalizeEOL: EOLLF, WriteEOLAttributes: true}, []string{attributesFile}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths := toolManagedPaths(tt.config)
+			if len(paths) != len(tt.want) {
+				t.Errorf("путей %d, ожидалось %d: %v", len(paths), len(tt.want), paths)
+			}
+			for _, path := range tt.want {
+				if !paths.has(path) {
+					t.Errorf("нет пути %s", path)
+				}
+			}
+		})
+	}
+
+	paths := make(toolPaths)
+	paths.add(filepath.Join("meta", "version.yaml"))
+	if !paths.has("meta/version.yaml") || !paths.has("./meta//version.yaml") {
+		t.Error("путь не найден в другой записи")
+	}
+	if !paths.containsUnder("meta") || paths.containsUnder("met") || paths.containsUnder("meta/version.yaml") {
+		t.Error("containsUnder считает вложенность неверно")
+	}
+}
+
+func TestClearDirectoryKeepsManagedPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"user.txt":             "user",
+		"src/main.go":          "package main",
+		defaultMetadataFile:    "version: 1",
+		"meta/release.yaml":    "version: 1",
+		"meta/stale.txt":       "old",
+		".git/HEAD":            "ref: refs/heads/master",
+		"deep/nested/file.txt": "x",
+	})
+	managed := toolManagedPaths(Config{WriteMetadataFile: true})
+	managed.add("meta/release.yaml")
+
+	if err := clearDirectory(dir, managed, &testLogger{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var left []string
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			left = append(left, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	want := []string{".folder-to-git.yaml", ".git/HEAD", "meta/release.yaml"}
+	if !reflect.DeepEqual(left, want) {
+		t.Errorf("остались файлы %v, ожидались %v", left, want)
+	}
+	for _, gone := range []string{"src", "deep"} {
+		if _, err := os.Stat(filepath.Join(dir, gone)); !os.IsNotExist(err) {
+			t.Errorf("директория %s не удалена", gone)
+		}
+	}
+}
+
+// Файл метаданных меняется с каждой версией, но не делает версию
+// измененной и не удаляется как исчезнувший из источника
+func TestManagedFileIsNotAUserChange(t *testing.T) {
+	same := map[string]string{"a.txt": "a\n", "dir/b.txt": "b\n"}
+	src := versionSource(t, same, same, map[string]string{"a.txt": "a\n"})
+
+	for _, incremental := range []bool{false, true} {
+		dst := t.TempDir()
+		config := testConfig(src, dst)
+		config.WriteMetadataFile = true
+		config.SkipUnchanged = true
+		config.Incremental = incremental
+		result := mustRun(t, config)
+
+		if !reflect.DeepEqual(result.Unchanged, []string{"2"}) {
+			t.Errorf("incremental=%v: без изменений %v, ожидалась версия 2", incremental, result.Unchanged)
+		}
+		commits := history(t, dst)
+		if len(commits) != 2 {
+			t.Fatalf("incremental=%v: коммитов %d, ожидалось 2", incremental, len(commits))
+		}
+		want := []string{defaultMetadataFile, "a.txt"}
+		if got := treeNames(t, commits[1]); !reflect.DeepEqual(got, want) {
+			t.Errorf("incremental=%v: в последнем коммите %v, ожидалось %v", incremental, got, want)
+		}
+	}
+}
+
+func TestCheckCleanWorktreeIgnoresManagedFiles(t *testing.T) {
+	src := versionSource(t, map[string]string{"a.txt": "a\n"})
+	dst := t.TempDir()
+	config := testConfig(src, dst)
+	config.WriteMetadataFile = true
+	mustRun(t, config)
+
+	repo, err := git.PlainOpen(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, dst, map[string]string{defaultMetadataFile: "changed by hand"})
+	if err := checkCleanWorktree(worktree, toolManagedPaths(config)); err != nil {
+		t.Errorf("измененный файл конвертера считается изменением: %v", err)
+	}
+
+	writeFiles(t, dst, map[string]string{"a.txt": "changed by hand"})
+	if err := checkCleanWorktree(worktree, toolManagedPaths(config)); err == nil {
+		t.Error("изменение файла пользователя не обнаружено")
+	}
+}
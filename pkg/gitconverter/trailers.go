@@ -0,0 +1,55 @@
+package gitconverter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// expandPlaceholders подставляет в шаблон сведения о версии: {version},
+// {folder}, {date}, {files} и {author}. Управляющие символы в подставленных
+// значениях экранируются
+func expandPlaceholders(tmpl string, folder FolderInfo, folderName string, fileCount int, authorName string) string {
+	s := strings.ReplaceAll(tmpl, "{version}", Printable(folder.Version))
+	s = strings.ReplaceAll(s, "{folder}", Printable(folderName))
+	s = strings.ReplaceAll(s, "{date}", time.Unix(folder.CreationTime, 0).Format("2006-01-02 15:04:05"))
+	s = strings.ReplaceAll(s, "{files}", fmt.Sprintf("%d", fileCount))
+	s = strings.ReplaceAll(s, "{author}", Printable(authorName))
+	return s
+}
+
+// validateTrailers проверяет ключи CommitTrailers: git interpret-trailers
+// не распознает ключи с пробелами и двоеточиями
+func validateTrailers(trailers map[string]string) error {
+	for key := range trailers {
+		if key == "" || strings.ContainsAny(key, ": \t\r\n") {
+			return fmt.Errorf("недопустимый ключ трейлера коммита: %q", key)
+		}
+	}
+	return nil
+}
+
+// appendTrailers дописывает к сообщению блок трейлеров "Ключ: значение",
+// отделенный пустой строкой. Ключи идут по алфавиту, значения
+// записываются в одну строку, чтобы внутри блока не было пустых строк
+func appendTrailers(msg string, trailers map[string]string, expand func(string) string) string {
+	if len(trailers) == 0 {
+		return msg
+	}
+
+	keys := make([]string, 0, len(trailers))
+	for key := range trailers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(msg, "\n"))
+	b.WriteString("\n\n")
+	for _, key := range keys {
+		value := strings.Join(strings.Fields(expand(trailers[key])), " ")
+		fmt.Fprintf(&b, "%s: %s\n", key, value)
+	}
+	return b.String()
+}
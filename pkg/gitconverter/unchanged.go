@@ -0,0 +1,159 @@
+package gitconverter
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// folderMatchesHead проверяет, что файлы папки совпадают с деревом последнего
+// коммита побайтно и по исполняемому биту. Файлы сравниваются в том виде, в
+// каком попали бы в коммит: с концами строк eol и указателями LFS по правилам
+// lfs. Файлы конвертера (managed) в сравнении не участвуют. Хеши файлов папки
+// берутся из кэша hashes
+func folderMatchesHead(repo *git.Repository, src string, names *nameDecoder, filter *contentFilter, managed toolPaths, eol EOLMode, lfs *lfsRules, hashes *hashCache) (bool, error) {
+	ref, err := repo.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return false, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return false, err
+	}
+
+	headFiles := make(map[string]*object.File)
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if !managed.has(f.Name) {
+			headFiles[f.Name] = f
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	srcFiles, err := listFiles(src, false, filter)
+	if err != nil {
+		return false, err
+	}
+	if len(srcFiles) != len(headFiles) {
+		return false, nil
+	}
+
+	for rel := range srcFiles {
+		decoded, _ := names.decode(rel)
+		name := filepath.ToSlash(decoded)
+		want, ok := headFiles[name]
+		if !ok {
+			return false, nil
+		}
+		path := filepath.Join(src, rel)
+		info, err := os.Lstat(path)
+		if err != nil {
+			return false, err
+		}
+		hash, err := storedBlobHash(eol, lfs, hashes, name, path, info)
+		if err != nil {
+			return false, err
+		}
+		if hash != want.Hash {
+			return false, nil
+		}
+
+		// Смена исполняемого бита — тоже изменение версии
+		if want.Mode == filemode.Regular || want.Mode == filemode.Executable {
+			wantMode, err := want.Mode.ToOSFileMode()
+			if err != nil {
+				return false, err
+			}
+			if execChanged(sourceFileMode(path, info.Mode()), wantMode) {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// filesMissingInSource возвращает файлы последнего коммита, которых нет в
+// папке src. Файлы конвертера (managed) не учитываются
+func filesMissingInSource(repo *git.Repository, src string, names *nameDecoder, filter *contentFilter, managed toolPaths) ([]string, error) {
+	ref, err := repo.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	srcFiles, err := listFiles(src, false, filter)
+	if err != nil {
+		return nil, err
+	}
+	present := make(map[string]bool, len(srcFiles))
+	for rel := range srcFiles {
+		decoded, _ := names.decode(rel)
+		present[filepath.ToSlash(decoded)] = true
+	}
+
+	var missing []string
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if !managed.has(f.Name) && !present[f.Name] {
+			missing = append(missing, f.Name)
+		}
+		return nil
+	})
+	return missing, err
+}
+
+// storedBlobHash возвращает хеш объекта blob, которым файл path станет в
+// дереве коммита под именем name: указатель для файла из Git LFS, содержимое
+// с концами строк eol или сам файл, хеш которого берется из кэша hashes
+func storedBlobHash(eol EOLMode, lfs *lfsRules, hashes *hashCache, name, path string, info os.FileInfo) (plumbing.Hash, error) {
+	switch {
+	case info.Mode().IsRegular() && lfs.tracks(name, info.Size()):
+		return lfsPointerHash(path)
+	case info.Mode().IsRegular() && normalizesEOL(eol):
+		return normalizedBlobHash(path, eol)
+	}
+	return hashes.blobHash(path)
+}
+
+// blobHash вычисляет хеш файла как объекта blob, не сохраняя его в репозиторий
+func blobHash(path string) (plumbing.Hash, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	hasher := plumbing.NewHasher(plumbing.BlobObject, info.Size())
+	if _, err := io.Copy(hasher, f); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return hasher.Sum(), nil
+}
@@ -0,0 +1,60 @@
+package gitconverter
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Повторяющиеся версии пропускаются, а первая попадает в историю, хотя HEAD
+// у нее еще нет
+func TestSkipEmptyCommits(t *testing.T) {
+	same := map[string]string{"a.txt": "a\n"}
+	src := versionSource(t, same, same, map[string]string{"a.txt": "b\n"})
+	dst := t.TempDir()
+	config := testConfig(src, dst)
+	config.SkipEmptyCommits = true
+	result := mustRun(t, config)
+
+	if !reflect.DeepEqual(result.Unchanged, []string{"2"}) {
+		t.Errorf("без изменений %v, ожидалась версия 2", result.Unchanged)
+	}
+	if commits := history(t, dst); len(commits) != 2 {
+		t.Errorf("коммитов %d, ожидалось 2", len(commits))
+	}
+	if !config.Logger.(*testLogger).contains("Пропуск версии 2: нет изменений") {
+		t.Error("пропуск версии не записан в лог")
+	}
+}
+
+// Версия сравнивается с последним коммитом в том виде, в каком попала бы в
+// него: после приведения концов строк и замены файлов LFS указателями
+func TestUnchangedAfterTransforms(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string
+		modify func(*Config)
+	}{
+		{"концы строк", map[string]string{"a.txt": "a\r\nb\r\n"},
+			func(c *Config) { c.NormalizeEOL = EOLLF }},
+		{"LFS", map[string]string{"a.txt": "a\n", "data.bin": "\x00\x01\x02"},
+			func(c *Config) { c.LFSPatterns = []string{"*.bin"} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := versionSource(t, tt.files, tt.files)
+			dst := t.TempDir()
+			config := testConfig(src, dst)
+			config.SkipUnchanged = true
+			config.WriteMetadataFile = true
+			tt.modify(&config)
+			result := mustRun(t, config)
+
+			if !reflect.DeepEqual(result.Unchanged, []string{"2"}) {
+				t.Errorf("без изменений %v, ожидалась версия 2", result.Unchanged)
+			}
+			if commits := history(t, dst); len(commits) != 1 {
+				t.Errorf("коммитов %d, ожидался 1", len(commits))
+			}
+		})
+	}
+}
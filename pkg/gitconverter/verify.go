@@ -0,0 +1,117 @@
+package gitconverter
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// verifyExamples — сколько расхождений перечисляется в сообщении об ошибке
+const verifyExamples = 5
+
+// commitCheck описывает расхождение дерева коммита с папкой версии
+type commitCheck struct {
+	Expected      int      // Файлов в папке версии с учетом правил пропуска
+	ExpectedBytes int64    // Их суммарный размер
+	Missing       []string // Файлы папки, которых нет в коммите
+	MissingBytes  int64
+	Resized       []string // Файлы, размер которых в коммите другой
+	Collisions    []string // Файлы, имена которых совпадают без учета регистра
+}
+
+// problems возвращает общее число расхождений
+func (c commitCheck) problems() int {
+	return len(c.Missing) + len(c.Resized)
+}
+
+// String описывает расхождение для лога и сообщения об ошибке
+func (c commitCheck) String() string {
+	var parts []string
+	if len(c.Missing) > 0 {
+		parts = append(parts, fmt.Sprintf("в коммите нет %d из %d файлов (%s из %s), например: %s",
+			len(c.Missing), c.Expected, FormatSize(c.MissingBytes), FormatSize(c.ExpectedBytes),
+			strings.Join(firstN(c.Missing, verifyExamples), ", ")))
+	}
+	if len(c.Resized) > 0 {
+		parts = append(parts, fmt.Sprintf("у %d файлов другой размер, например: %s",
+			len(c.Resized), strings.Join(firstN(c.Resized, verifyExamples), ", ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// verifyCommit сверяет дерево коммита с файлами папки src, собранными с теми же
+// правилами пропуска, что и при копировании. Файлы папки ищутся в дереве под
+// префиксом prefix. Размеры сверяются только при checkSizes: в режиме
+// добавления существующие файлы не перезаписываются. Файлы, которые по
+// правилам lfs хранятся в Git LFS, в дереве представлены указателями, и их
+// размер не сверяется
+func verifyCommit(repo *git.Repository, commit plumbing.Hash, src, prefix string, names *nameDecoder, filter *contentFilter, lfs *lfsRules, checkSizes bool) (commitCheck, error) {
+	var check commitCheck
+
+	commitObj, err := repo.CommitObject(commit)
+	if err != nil {
+		return check, err
+	}
+	tree, err := commitObj.Tree()
+	if err != nil {
+		return check, err
+	}
+	treeFiles := make(map[string]int64)
+	err = tree.Files().ForEach(func(f *object.File) error {
+		treeFiles[f.Name] = f.Size
+		return nil
+	})
+	if err != nil {
+		return check, err
+	}
+
+	srcFiles, err := listFiles(src, false, filter)
+	if err != nil {
+		return check, err
+	}
+	folded := make(map[string]string, len(srcFiles))
+	for _, rel := range sortedKeys(srcFiles) {
+		lower := strings.ToLower(rel)
+		if first, ok := folded[lower]; ok {
+			check.Collisions = append(check.Collisions, first+" / "+rel)
+		} else {
+			folded[lower] = rel
+		}
+
+		info, err := os.Lstat(filepath.Join(src, rel))
+		if err != nil {
+			return check, err
+		}
+		check.Expected++
+		check.ExpectedBytes += info.Size()
+
+		name := filepath.ToSlash(names.repoPath(rel))
+		if prefix != "" {
+			name = path.Join(filepath.ToSlash(prefix), name)
+		}
+		size, ok := treeFiles[name]
+		if !ok {
+			check.Missing = append(check.Missing, name)
+			check.MissingBytes += info.Size()
+			continue
+		}
+		if checkSizes && info.Mode().IsRegular() && size != info.Size() && !lfs.tracks(name, info.Size()) {
+			check.Resized = append(check.Resized, name)
+		}
+	}
+	return check, nil
+}
+
+// firstN возвращает не больше n первых элементов списка
+func firstN(items []string, n int) []string {
+	if len(items) > n {
+		return items[:n]
+	}
+	return items
+}
@@ -0,0 +1,159 @@
+package gitconverter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"folder_to_git/internal/versionorder"
+)
+
+// LoadVersionOverrides читает файл с версиями, заданными вручную, в формате
+// "имя_папки=версия". Пустые строки и комментарии (#) пропускаются
+func LoadVersionOverrides(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[string]string)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, version, ok := strings.Cut(line, "=")
+		name, version = strings.TrimSpace(name), strings.TrimSpace(version)
+		if !ok || name == "" || version == "" {
+			return nil, fmt.Errorf("строка %d: ожидается имя_папки=версия", i+1)
+		}
+		overrides[name] = version
+	}
+	return overrides, nil
+}
+
+// SortFolders упорядочивает папки по времени создания и возвращает пары, в
+// которых даты противоречат номерам версий. При PreferVersionOrder такие папки
+// переставляются по номерам. Вызывается заново после ручной правки версий.
+// В режимах SortMode semver и natural папки упорядочиваются по версиям,
+// и противоречивых пар не бывает
+func SortFolders(config Config, folders []FolderInfo) []VersionInversion {
+	inversions, _ := sortFolders(config, folders)
+	return inversions
+}
+
+// sortFolders выполняет SortFolders и объясняет место каждой папки в
+// получившемся порядке
+func sortFolders(config Config, folders []FolderInfo) ([]VersionInversion, []OrderDecision) {
+	switch config.SortMode {
+	case SortBySemver:
+		parsed := sortBySemver(config, folders)
+		return nil, explainOrder(config, folders, func(i int) OrderRule {
+			a, ok := parsed[folders[i].Version]
+			if !ok {
+				return OrderNotSemver
+			}
+			if sameNeighbour(folders, i, func(other FolderInfo) bool {
+				b, ok := parsed[other.Version]
+				return ok && versionorder.CompareSemver(a, b) == 0
+			}) {
+				return OrderVersionTie
+			}
+			return OrderByVersion
+		})
+	case SortByNatural:
+		sort.SliceStable(folders, func(i, j int) bool {
+			if c := versionorder.Natural(folders[i].Version, folders[j].Version); c != 0 {
+				return c < 0
+			}
+			return folders[i].CreationTime < folders[j].CreationTime
+		})
+		return nil, explainOrder(config, folders, func(i int) OrderRule {
+			if sameNeighbour(folders, i, func(other FolderInfo) bool {
+				return versionorder.Natural(folders[i].Version, other.Version) == 0
+			}) {
+				return OrderVersionTie
+			}
+			return OrderByVersion
+		})
+	}
+
+	sort.SliceStable(folders, func(i, j int) bool {
+		return folders[i].CreationTime < folders[j].CreationTime
+	})
+
+	// Даты могли сбиться, например при восстановлении из резервной копии
+	inversions := findInversions(folders)
+	if len(inversions) > 0 && config.PreferVersionOrder {
+		preferVersionOrder(folders, inversions)
+	}
+
+	involved := make(map[string]bool)
+	for _, inv := range inversions {
+		involved[inv.EarlierPath] = true
+		involved[inv.LaterPath] = true
+	}
+	return inversions, explainOrder(config, folders, func(i int) OrderRule {
+		switch {
+		case involved[folders[i].Path] && config.PreferVersionOrder:
+			return OrderPreferVersion
+		case involved[folders[i].Path]:
+			return OrderInversion
+		case sameNeighbour(folders, i, func(other FolderInfo) bool {
+			return other.CreationTime == folders[i].CreationTime
+		}):
+			return OrderTimeTie
+		}
+		return OrderByTime
+	})
+}
+
+// sortBySemver упорядочивает папки по семантическим версиям, при равных
+// версиях — по времени. Версии, которые не удалось разобрать, идут в конце.
+// Возвращает разобранные версии
+func sortBySemver(config Config, folders []FolderInfo) map[string]versionorder.Semver {
+	parsed := make(map[string]versionorder.Semver, len(folders))
+	for _, folder := range folders {
+		if v, ok := versionorder.ParseSemver(folder.Version); ok {
+			parsed[folder.Version] = v
+		} else if config.Verbose {
+			config.logger().Printf("Предупреждение: версия %s папки %s не соответствует SemVer и будет перенесена в конце",
+				Printable(folder.Version), Printable(filepath.Base(folder.Path)))
+		}
+	}
+
+	sort.SliceStable(folders, func(i, j int) bool {
+		a, okA := parsed[folders[i].Version]
+		b, okB := parsed[folders[j].Version]
+		if okA != okB {
+			return okA
+		}
+		if okA {
+			if c := versionorder.CompareSemver(a, b); c != 0 {
+				return c < 0
+			}
+		}
+		return folders[i].CreationTime < folders[j].CreationTime
+	})
+	return parsed
+}
+
+// logDuplicateVersions предупреждает о папках с одинаковой версией: их теги
+// совпадут, а режим добавления пропустит все, кроме первой
+func logDuplicateVersions(folders []FolderInfo, logger Logger) {
+	paths := make(map[string][]string)
+	var order []string
+	for _, folder := range folders {
+		if _, ok := paths[folder.Version]; !ok {
+			order = append(order, folder.Version)
+		}
+		paths[folder.Version] = append(paths[folder.Version], Printable(filepath.Base(folder.Path)))
+	}
+	for _, version := range order {
+		if names := paths[version]; len(names) > 1 {
+			logger.Printf("Предупреждение: версия %s у нескольких папок: %s", Printable(version), strings.Join(names, ", "))
+		}
+	}
+}
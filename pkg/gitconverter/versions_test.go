@@ -0,0 +1,49 @@
+package gitconverter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortFoldersByMode(t *testing.T) {
+	// Даты перепутаны с версиями, чтобы порядок по времени отличался
+	versions := []string{"v1.10", "v1.2", "v1.0", "nightly", "v1.0-rc1"}
+	tests := []struct {
+		mode SortMode
+		want []string
+	}{
+		{SortByTime, []string{"v1.10", "v1.2", "v1.0", "nightly", "v1.0-rc1"}},
+		{SortBySemver, []string{"v1.0-rc1", "v1.0", "v1.2", "v1.10", "nightly"}},
+		// Буквенные части сравниваются как строки, поэтому nightly раньше v1.0
+		{SortByNatural, []string{"nightly", "v1.0", "v1.0-rc1", "v1.2", "v1.10"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			var folders []FolderInfo
+			for i, version := range versions {
+				folders = append(folders, FolderInfo{Path: "/src/" + version, Version: version, CreationTime: int64(i)})
+			}
+			SortFolders(Config{SortMode: tt.mode, Logger: &testLogger{}}, folders)
+
+			var got []string
+			for _, folder := range folders {
+				got = append(got, folder.Version)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("порядок %v, ожидался %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortFoldersTieByTime(t *testing.T) {
+	// Равные версии остаются в порядке дат
+	folders := []FolderInfo{
+		{Path: "/src/b", Version: "v1.2.0", CreationTime: 20},
+		{Path: "/src/a", Version: "1.2", CreationTime: 10},
+	}
+	SortFolders(Config{SortMode: SortBySemver, Logger: &testLogger{}}, folders)
+	if folders[0].Path != "/src/a" {
+		t.Errorf("равные версии упорядочены не по дате: %+v", folders)
+	}
+}
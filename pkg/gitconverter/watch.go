@@ -0,0 +1,185 @@
+package gitconverter
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Параметры режима наблюдения по умолчанию
+const (
+	defaultWatchDebounce = 2 * time.Second
+	defaultWatchSettle   = 10 * time.Second
+)
+
+// Watch следит за SourceDir и переносит в репозиторий новые папки с версиями
+// по мере их появления. Сначала выполняется обычный перенос, затем каждая
+// новая версия добавляется в режимах Append и Incremental. Папка переносится,
+// только когда в ней WatchSettle ничего не менялось, чтобы не захватить ее
+// недописанной. Работает до отмены ctx
+func Watch(ctx context.Context, config Config) error {
+	debounce := config.WatchDebounce
+	if debounce <= 0 {
+		debounce = defaultWatchDebounce
+	}
+	settle := config.WatchSettle
+	if settle <= 0 {
+		settle = defaultWatchSettle
+	}
+
+	// Изменения на сервере SFTP не приходят как события файловой системы
+	if isRemoteSource(config.SourceDir) {
+		return fmt.Errorf("наблюдение за удаленным источником %s не поддерживается", config.SourceDir)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("ошибка запуска наблюдения: %v", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(config.SourceDir); err != nil {
+		return fmt.Errorf("ошибка наблюдения за %s: %v", config.SourceDir, err)
+	}
+
+	w := &folderWatch{config: config, settle: settle, known: make(map[string]bool)}
+	config.logger().Printf("Наблюдение за %s запущено", config.SourceDir)
+
+	// Первый проход выполняется сразу, не дожидаясь событий
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			config.logger().Printf("Наблюдение за %s остановлено", config.SourceDir)
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if w.config.Verbose {
+				config.logger().Printf("Изменение в источнике: %s", event)
+			}
+			// Серия быстрых изменений обрабатывается одним проходом
+			resetTimer(timer, debounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			config.logger().Printf("Предупреждение: ошибка наблюдения: %v", err)
+
+		case <-timer.C:
+			pending, err := w.importNew(ctx)
+			if err != nil {
+				config.logger().Printf("Ошибка переноса новых версий: %v", err)
+			}
+			// Недописанные папки проверяются повторно после паузы
+			if pending > 0 {
+				resetTimer(timer, settle)
+			}
+		}
+	}
+}
+
+// folderWatch хранит состояние режима наблюдения между проходами
+type folderWatch struct {
+	config   Config
+	settle   time.Duration
+	known    map[string]bool // Версии, уже переданные на перенос
+	imported bool            // Был ли выполнен первый перенос
+}
+
+// importNew переносит новые устоявшиеся папки и возвращает число папок,
+// которые еще изменяются и ждут следующего прохода
+func (w *folderWatch) importNew(ctx context.Context) (int, error) {
+	folders, err := FindVersionedFolders(w.config)
+	if err != nil {
+		return 0, err
+	}
+
+	var ready []FolderInfo
+	pending := 0
+	for _, folder := range folders {
+		if w.known[folder.Version] {
+			continue
+		}
+		settled, err := folderSettled(folder.Path, w.settle)
+		if err != nil {
+			return pending, fmt.Errorf("ошибка проверки папки %s: %v", folder.Path, err)
+		}
+		if !settled {
+			if w.config.Verbose {
+				w.config.logger().Printf("Папка %s еще изменяется, перенос отложен", Printable(filepath.Base(folder.Path)))
+			}
+			pending++
+			continue
+		}
+		ready = append(ready, folder)
+	}
+	if len(ready) == 0 {
+		return pending, nil
+	}
+
+	// Новые версии накладываются на историю как изменения, а не только новые файлы
+	config := w.config
+	if w.imported {
+		config.Append = true
+		config.Incremental = true
+	}
+
+	// Версии запоминаются и при ошибке, чтобы не повторять ее на каждом проходе
+	for _, folder := range ready {
+		w.known[folder.Version] = true
+	}
+	w.config.logger().Printf("Перенос новых версий: %d", len(ready))
+
+	result, err := migrate(ctx, config, ready)
+	if err != nil {
+		return pending, err
+	}
+	w.imported = true
+	for _, failure := range result.Failed {
+		w.config.logger().Printf("Пропущена версия %s: %s", failure.Version, failure.Err)
+	}
+	return pending, nil
+}
+
+// folderSettled проверяет, что в папке ничего не менялось последние settle
+func folderSettled(path string, settle time.Duration) (bool, error) {
+	var latest time.Time
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return time.Since(latest) >= settle, nil
+}
+
+// resetTimer перезапускает таймер, сбрасывая несработавшее событие
+func resetTimer(timer *time.Timer, d time.Duration) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(d)
+}
@@ -0,0 +1,182 @@
+package gitconverter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Параметры отправки уведомлений по умолчанию
+const (
+	defaultWebhookTimeout    = 10 * time.Second
+	defaultWebhookRetries    = 2
+	defaultWebhookRetryDelay = time.Second
+	webhookQueueSize         = 100
+)
+
+// webhook отправляет события миграции POST-запросами в фоне, чтобы
+// недоступный адрес не задерживал перенос версий
+type webhook struct {
+	url     string
+	token   string
+	events  map[string]bool
+	retries int
+	client  *http.Client
+	logger  Logger
+
+	queue chan ProgressEvent
+	done  chan struct{}
+	once  sync.Once
+}
+
+// newWebhook создает отправителя по настройкам. Без WebhookURL возвращает nil,
+// методы такого отправителя ничего не делают
+func newWebhook(config Config) (*webhook, error) {
+	if config.WebhookURL == "" {
+		return nil, nil
+	}
+	if _, err := url.Parse(config.WebhookURL); err != nil {
+		return nil, fmt.Errorf("некорректный адрес webhook: %v", redactURL(config.WebhookURL))
+	}
+
+	events := make(map[string]bool)
+	if len(config.WebhookEvents) == 0 {
+		events[EventDone] = true
+	}
+	for _, event := range config.WebhookEvents {
+		switch event {
+		case EventFolder, EventFailed, EventDone:
+			events[event] = true
+		default:
+			return nil, fmt.Errorf("неизвестный тип события webhook: %q", event)
+		}
+	}
+
+	timeout := config.WebhookTimeout
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	retries := config.WebhookRetries
+	if retries <= 0 {
+		retries = defaultWebhookRetries
+	}
+
+	w := &webhook{
+		url:     config.WebhookURL,
+		token:   config.WebhookToken,
+		events:  events,
+		retries: retries,
+		client:  &http.Client{Timeout: timeout},
+		logger:  config.logger(),
+		queue:   make(chan ProgressEvent, webhookQueueSize),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+// send ставит событие в очередь. При переполненной очереди событие теряется
+func (w *webhook) send(event ProgressEvent) {
+	if w == nil || !w.events[event.Type] {
+		return
+	}
+	event.Time = time.Now()
+	select {
+	case w.queue <- event:
+	default:
+		w.logger.Printf("Предупреждение: очередь webhook переполнена, событие %s не отправлено", event.Type)
+	}
+}
+
+// close дожидается отправки оставшихся событий, но не дольше wait
+func (w *webhook) close(wait time.Duration) {
+	if w == nil {
+		return
+	}
+	w.once.Do(func() { close(w.queue) })
+	select {
+	case <-w.done:
+	case <-time.After(wait):
+		w.logger.Printf("Предупреждение: не все события отправлены на webhook %s", redactURL(w.url))
+	}
+}
+
+// run отправляет события из очереди по одному
+func (w *webhook) run() {
+	defer close(w.done)
+	for event := range w.queue {
+		if err := w.post(event); err != nil {
+			w.logger.Printf("Предупреждение: не удалось отправить событие %s на webhook %s: %v",
+				event.Type, redactURL(w.url), err)
+		}
+	}
+}
+
+// post отправляет одно событие, повторяя попытку при сбое
+func (w *webhook) post(event ProgressEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	delay := defaultWebhookRetryDelay
+	for attempt := 0; ; attempt++ {
+		err = w.postOnce(body)
+		if err == nil || attempt >= w.retries {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func (w *webhook) postOnce(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		// Текст ошибки net/http содержит адрес вместе с секретами
+		if uerr, ok := err.(*url.Error); ok {
+			return fmt.Errorf("%s %s: %v", uerr.Op, redactURL(uerr.URL), uerr.Err)
+		}
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("сервер ответил %s", resp.Status)
+	}
+	return nil
+}
+
+// closeTimeout — сколько ждать отправки последних событий при завершении
+func (w *webhook) closeTimeout() time.Duration {
+	if w == nil {
+		return 0
+	}
+	return w.client.Timeout*time.Duration(w.retries+1) + defaultWebhookRetryDelay<<w.retries
+}
+
+// redactURL оставляет от адреса только схему и сервер: токены передаются
+// и в пароле, и в параметрах, и в самом пути (как у Slack)
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "<скрыто>"
+	}
+	redacted := u.Scheme + "://" + u.Host
+	if u.Path != "" && u.Path != "/" || u.RawQuery != "" {
+		redacted += "/…"
+	}
+	return redacted
+}
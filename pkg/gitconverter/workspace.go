@@ -0,0 +1,87 @@
+package gitconverter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// workspacePrefix — префикс временных папок версий в WorkspaceMode
+const workspacePrefix = "folder-to-git-workspace-"
+
+// workspaceRoot возвращает директорию для временных папок версий. По
+// умолчанию это служебная папка Git: она на том же томе, что и рабочая
+// директория, поэтому перенос в нее сводится к переименованию
+func workspaceRoot(config Config) string {
+	if config.TempDir != "" {
+		return config.TempDir
+	}
+	return filepath.Join(gitDirPath(config.TargetDir), manifestDir)
+}
+
+// makeWorkspace создает временную папку для сборки очередной версии
+func makeWorkspace(config Config) (string, error) {
+	root := workspaceRoot(config)
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(root, workspacePrefix)
+}
+
+// removeStaleWorkspaces удаляет временные папки, оставшиеся после аварийного
+// завершения прошлого запуска. Общую TempDir могут использовать другие
+// запуски, поэтому чистится только служебная папка репозитория
+func removeStaleWorkspaces(config Config) {
+	if config.TempDir != "" {
+		return
+	}
+	matches, err := filepath.Glob(filepath.Join(workspaceRoot(config), workspacePrefix+"*"))
+	if err != nil {
+		return
+	}
+	for _, path := range matches {
+		config.logger().Printf("Удаление временной папки прошлого запуска: %s", path)
+		if err := os.RemoveAll(path); err != nil {
+			config.logger().Printf("Предупреждение: не удалось удалить %s: %v", path, err)
+		}
+	}
+}
+
+// moveTree переносит содержимое src в dst. Целиком переименовываются
+// директории, которых в dst нет; существующие объединяются по файлам.
+// Если переименование невозможно (другой том), файл копируется
+func moveTree(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		from := filepath.Join(src, entry.Name())
+		to := filepath.Join(dst, entry.Name())
+
+		if err := os.Rename(from, to); err == nil {
+			continue
+		}
+
+		if entry.IsDir() {
+			if err := os.MkdirAll(to, 0755); err != nil {
+				return err
+			}
+			if err := moveTree(from, to); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := os.Remove(to); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := os.Rename(from, to); err == nil {
+			continue
+		}
+		if err := copyFile(context.Background(), from, to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package gitconverter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// openRepository открывает репозиторий в dir. Связанные рабочие каталоги
+// (git worktree add) хранят объекты и ссылки в основном репозитории,
+// поэтому общий каталог подключается явно
+func openRepository(dir string) (*git.Repository, error) {
+	return git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{EnableDotGitCommonDir: true})
+}
+
+// isLinkedWorktree проверяет, что dir — связанный рабочий каталог: вместо
+// папки .git в нем файл со ссылкой на каталог в основном репозитории
+func isLinkedWorktree(dir string) bool {
+	gitDir, ok := readGitFile(dir)
+	if !ok {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(gitDir, "commondir"))
+	return err == nil
+}
+
+// gitDirPath возвращает служебную папку Git рабочей директории dir. У голого
+// репозитория служебная папка — сам dir
+func gitDirPath(dir string) string {
+	if isBareRepository(dir) {
+		return dir
+	}
+	if gitDir, ok := readGitFile(dir); ok {
+		return gitDir
+	}
+	return filepath.Join(dir, ".git")
+}
+
+// readGitFile читает путь к служебной папке из файла .git (gitdir: <путь>)
+func readGitFile(dir string) (string, bool) {
+	f, err := os.Open(filepath.Join(dir, ".git"))
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && line == "" {
+		return "", false
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(line), "gitdir:")
+	if !ok {
+		return "", false
+	}
+
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(dir, gitDir)
+	}
+	return gitDir, true
+}
+
+// validateBranch проверяет, что имя ветки допустимо для ссылки git.
+// Пустое имя означает ветку по умолчанию
+func validateBranch(branch string) error {
+	if branch == "" {
+		return nil
+	}
+	if err := plumbing.NewBranchReferenceName(branch).Validate(); err != nil {
+		return fmt.Errorf("недопустимое имя ветки %q", branch)
+	}
+	return nil
+}
+
+// checkoutBranch переключает рабочую директорию на ветку branch, создавая ее
+// от текущего HEAD, если ветки еще нет
+func checkoutBranch(repo *git.Repository, worktree *git.Worktree, branch string) error {
+	ref := plumbing.NewBranchReferenceName(branch)
+
+	head, err := repo.Head()
+	if err == nil && head.Name() == ref {
+		return nil
+	}
+
+	_, err = repo.Reference(ref, true)
+	exists := err == nil
+
+	return worktree.Checkout(&git.CheckoutOptions{
+		Branch: ref,
+		Create: !exists,
+	})
+}
+
+// checkCleanWorktree проверяет, что в рабочей директории нет незакоммиченных
+// изменений отслеживаемых файлов: миграция очищает рабочую директорию и
+// откатывает ее после ошибки, и такие изменения были бы потеряны.
+// Неотслеживаемые файлы и файлы конвертера не мешают
+func checkCleanWorktree(worktree *git.Worktree, managed toolPaths) error {
+	status, err := worktree.Status()
+	if err != nil {
+		return fmt.Errorf("ошибка проверки рабочей директории: %v", err)
+	}
+	var changed []string
+	for path, file := range status {
+		if managed.has(path) || file.Staging == git.Untracked {
+			continue
+		}
+		if file.Staging != git.Unmodified || file.Worktree != git.Unmodified {
+			changed = append(changed, path)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	sort.Strings(changed)
+	return fmt.Errorf("%w: %d файлов, например %s; закоммитьте или отмените их", ErrDirtyWorktree, len(changed),
+		escapeControl(strings.Join(firstN(changed, verifyExamples), ", ")))
+}